@@ -0,0 +1,173 @@
+package opennebula
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	ds "github.com/OpenNebula/one/src/oca/go/src/goca/schemas/datastore"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataOpennebulaDatastore() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: datasourceOpennebulaDatastoreRead,
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Name of the Datastore",
+			},
+			"type": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "Type of the Datastore: IMAGE, SYSTEM, FILE",
+				ValidateFunc: func(v interface{}, k string) (ws []string, errors []error) {
+					value := v.(string)
+
+					if inArray(value, datastoreTypes) < 0 {
+						errors = append(errors, fmt.Errorf("Type %q must be one of: %s", k, strings.Join(datastoreTypes, ",")))
+					}
+
+					return
+				},
+			},
+			"ds_mad": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Datastore driver",
+			},
+			"tm_mad": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Transfer driver",
+			},
+			"clusters": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "List of cluster IDs hosting the Datastore",
+				Elem: &schema.Schema{
+					Type: schema.TypeInt,
+				},
+			},
+			"total_mb": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Total capacity of the Datastore in MB",
+			},
+			"free_mb": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Free capacity of the Datastore in MB",
+			},
+			"used_mb": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Used capacity of the Datastore in MB",
+			},
+			"tags": tagsSchema(),
+		},
+	}
+}
+
+func datastoreFilter(d *schema.ResourceData, meta interface{}) (*ds.Datastore, error) {
+
+	config := meta.(*Configuration)
+	controller := config.Controller
+
+	datastores, err := controller.Datastores().Info()
+	if err != nil {
+		return nil, err
+	}
+
+	// filter datastores with user defined criterias
+	name, nameOk := d.GetOk("name")
+	dsType, dsTypeOk := d.GetOk("type")
+	tagsInterface, tagsOk := d.GetOk("tags")
+	tags := tagsInterface.(map[string]interface{})
+
+	match := make([]*ds.Datastore, 0, 1)
+	for i, datastore := range datastores.Datastores {
+
+		if nameOk && datastore.Name != name {
+			continue
+		}
+
+		if dsTypeOk && datastore.Type != strconv.Itoa(inArray(dsType.(string), datastoreTypes)) {
+			continue
+		}
+
+		if tagsOk && !matchTags(datastore.Template.Template, tags) {
+			continue
+		}
+
+		match = append(match, &datastores.Datastores[i])
+	}
+
+	// check filtering results
+	if len(match) == 0 {
+		return nil, fmt.Errorf("no datastore match the constraints")
+	} else if len(match) > 1 {
+		return nil, fmt.Errorf("several datastores match the constraints")
+	}
+
+	return match[0], nil
+}
+
+func datasourceOpennebulaDatastoreRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+
+	var diags diag.Diagnostics
+
+	datastore, err := datastoreFilter(d, meta)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "datastores filtering failed",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+
+	tplPairs := pairsToMap(datastore.Template.Template)
+
+	d.SetId(strconv.FormatInt(int64(datastore.ID), 10))
+	d.Set("name", datastore.Name)
+	d.Set("ds_mad", datastore.DSMad)
+	d.Set("tm_mad", datastore.TMMad)
+	d.Set("total_mb", datastore.TotalMB)
+	d.Set("free_mb", datastore.FreeMB)
+	d.Set("used_mb", datastore.UsedMB)
+
+	dsType, err := strconv.Atoi(datastore.Type)
+	if err == nil && dsType >= 0 && dsType < len(datastoreTypes) {
+		d.Set("type", datastoreTypes[dsType])
+	}
+
+	err = d.Set("clusters", datastore.Clusters.ID)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "setting attribute failed",
+			Detail:   fmt.Sprintf("Datastore (ID: %d): %s", datastore.ID, err),
+		})
+		return diags
+	}
+
+	if len(tplPairs) > 0 {
+		err := d.Set("tags", tplPairs)
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "setting attribute failed",
+				Detail:   fmt.Sprintf("Datastore (ID: %d): %s", datastore.ID, err),
+			})
+			return diags
+		}
+	}
+
+	return nil
+}
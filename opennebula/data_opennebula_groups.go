@@ -0,0 +1,92 @@
+package opennebula
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataOpennebulaGroups() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: datasourceOpennebulaGroupsRead,
+
+		Schema: mergeSchemas(pluralFiltersSchema(false), pluralSortSchema(false), map[string]*schema.Schema{
+			"groups": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Ordered list of the groups matching the constraints",
+				Elem: &schema.Resource{
+					Schema: mergeSchemas(pluralCommonAttributes(), map[string]*schema.Schema{
+						"users": {
+							Type:        schema.TypeList,
+							Computed:    true,
+							Description: "IDs of the users of the group",
+							Elem: &schema.Schema{
+								Type: schema.TypeInt,
+							},
+						},
+						"admins": {
+							Type:        schema.TypeList,
+							Computed:    true,
+							Description: "IDs of the administrators of the group",
+							Elem: &schema.Schema{
+								Type: schema.TypeInt,
+							},
+						},
+					}),
+				},
+			},
+		}),
+	}
+}
+
+func datasourceOpennebulaGroupsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+
+	var diags diag.Diagnostics
+
+	config := meta.(*Configuration)
+	controller := config.Controller
+
+	groups, err := controller.Groups().Info()
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to retrieve groups",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+
+	filter := newPluralFilter(d, meta)
+
+	items := make([]pluralItem, 0)
+	for _, group := range groups.Groups {
+
+		if !filter.match(group.Name, group.Template) {
+			continue
+		}
+
+		items = append(items, pluralItem{
+			id:   group.ID,
+			name: group.Name,
+			attrs: map[string]interface{}{
+				"users":  group.Users.ID,
+				"admins": group.Admins.ID,
+				"tags":   filter.itemTags(group.Template),
+			},
+		})
+	}
+
+	err = setPluralItems(d, "groups", items)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "setting attribute failed",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package opennebula
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccDataSourceGroups(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckGroupDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDataSourceGroupsConfig,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.opennebula_groups.desc", "groups.#", "2"),
+					resource.TestCheckResourceAttrPair("data.opennebula_groups.desc", "groups.0.id", "opennebula_group.second", "id"),
+					resource.TestCheckResourceAttrPair("data.opennebula_groups.desc", "groups.1.id", "opennebula_group.first", "id"),
+					resource.TestCheckResourceAttr("data.opennebula_groups.desc", "groups.1.name", "test-data-groups-first"),
+					resource.TestCheckResourceAttr("data.opennebula_groups.desc", "ids.#", "2"),
+					resource.TestCheckResourceAttr("data.opennebula_groups.tags", "groups.#", "1"),
+					resource.TestCheckResourceAttr("data.opennebula_groups.tags", "groups.0.tags.team", "first"),
+				),
+			},
+		},
+	})
+}
+
+var testAccDataSourceGroupsConfig = `
+resource "opennebula_group" "first" {
+  name = "test-data-groups-first"
+
+  tags = {
+    team = "first"
+  }
+}
+
+resource "opennebula_group" "second" {
+  name = "test-data-groups-second"
+
+  tags = {
+    team = "second"
+  }
+
+  # created after the first one to get a higher ID
+  depends_on = [opennebula_group.first]
+}
+
+data "opennebula_groups" "desc" {
+  name_regex = "^test-data-groups-"
+  sort_order = "DESC"
+
+  depends_on = [
+    opennebula_group.first,
+    opennebula_group.second,
+  ]
+}
+
+data "opennebula_groups" "tags" {
+  name_regex = "^test-data-groups-"
+  tags = {
+    team = "first"
+  }
+
+  depends_on = [
+    opennebula_group.first,
+    opennebula_group.second,
+  ]
+}
+`
@@ -0,0 +1,123 @@
+package opennebula
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/OpenNebula/one/src/oca/go/src/goca/parameters"
+)
+
+func dataOpennebulaImages() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: datasourceOpennebulaImagesRead,
+
+		Schema: mergeSchemas(pluralFiltersSchema(true), pluralSortSchema(true), map[string]*schema.Schema{
+			"images": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Ordered list of the images matching the constraints",
+				Elem: &schema.Resource{
+					Schema: mergeSchemas(pluralCommonAttributes(), pluralOwnerAttributes(), map[string]*schema.Schema{
+						"regtime": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "Registration time of the image",
+						},
+						"type": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Type of the image",
+						},
+						"state": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "State of the image",
+						},
+						"size": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "Size of the image in MB",
+						},
+						"datastore_id": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "ID of the datastore of the image",
+						},
+						"persistent": {
+							Type:        schema.TypeBool,
+							Computed:    true,
+							Description: "Flag which indicates if the image is persistent",
+						},
+					}),
+				},
+			},
+		}),
+	}
+}
+
+func datasourceOpennebulaImagesRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+
+	var diags diag.Diagnostics
+
+	config := meta.(*Configuration)
+	controller := config.Controller
+
+	images, err := controller.Images().Info(parameters.PoolWhoAll, -1, -1)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to retrieve images",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+
+	filter := newPluralFilter(d, meta)
+
+	items := make([]pluralItem, 0)
+	for _, image := range images.Images {
+
+		if !filter.match(image.Name, image.Template.Template) ||
+			!filter.matchOwner(image.UID, image.GID, image.Permissions) {
+			continue
+		}
+
+		attrs := ownerAttributes(image.UID, image.GID, image.UName, image.GName, image.Permissions)
+		attrs["regtime"] = image.RegTime
+		attrs["type"] = imageTypeString(image.Type)
+		attrs["size"] = image.Size
+		attrs["tags"] = filter.itemTags(image.Template.Template)
+
+		state, err := image.StateString()
+		if err == nil {
+			attrs["state"] = state
+		}
+		if image.DatastoreID != nil {
+			attrs["datastore_id"] = *image.DatastoreID
+		}
+		if image.Persistent != nil {
+			attrs["persistent"] = *image.Persistent == 1
+		}
+
+		items = append(items, pluralItem{
+			id:      image.ID,
+			name:    image.Name,
+			regTime: image.RegTime,
+			attrs:   attrs,
+		})
+	}
+
+	err = setPluralItems(d, "images", items)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "setting attribute failed",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+
+	return nil
+}
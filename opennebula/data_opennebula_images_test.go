@@ -0,0 +1,107 @@
+package opennebula
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccDataSourceImages(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckImageDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDataSourceImagesConfig,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.opennebula_images.desc", "images.#", "2"),
+					resource.TestCheckResourceAttrPair("data.opennebula_images.desc", "images.0.id", "opennebula_image.second", "id"),
+					resource.TestCheckResourceAttrPair("data.opennebula_images.desc", "images.1.id", "opennebula_image.first", "id"),
+					resource.TestCheckResourceAttr("data.opennebula_images.desc", "images.1.name", "test-data-images-first"),
+					resource.TestCheckResourceAttr("data.opennebula_images.desc", "ids.#", "2"),
+					resource.TestCheckResourceAttr("data.opennebula_images.tags", "images.#", "1"),
+					resource.TestCheckResourceAttr("data.opennebula_images.tags", "images.0.tags.team", "first"),
+					resource.TestCheckResourceAttr("data.opennebula_images.tags", "images.0.tags.%", "1"),
+					resource.TestCheckResourceAttr("data.opennebula_images.desc", "images.0.tags.%", "0"),
+					resource.TestCheckResourceAttr("data.opennebula_images.desc", "images.0.type", "DATABLOCK"),
+					resource.TestCheckResourceAttr("data.opennebula_images.recent", "images.#", "1"),
+					resource.TestCheckResourceAttrPair("data.opennebula_images.recent", "images.0.id", "opennebula_image.second", "id"),
+					resource.TestCheckResourceAttr("data.opennebula_images.perms", "images.#", "2"),
+					resource.TestCheckResourceAttr("data.opennebula_images.perms", "images.0.permissions", "660"),
+				),
+			},
+		},
+	})
+}
+
+var testAccDataSourceImagesConfig = `
+resource "opennebula_image" "first" {
+  name         = "test-data-images-first"
+  datastore_id = 1
+  type         = "DATABLOCK"
+  size         = "16"
+  permissions  = "660"
+
+  tags = {
+    team = "first"
+  }
+}
+
+resource "opennebula_image" "second" {
+  name         = "test-data-images-second"
+  datastore_id = 1
+  type         = "DATABLOCK"
+  size         = "16"
+  permissions  = "660"
+
+  tags = {
+    team = "second"
+  }
+
+  # created after the first one to get a higher ID
+  depends_on = [opennebula_image.first]
+}
+
+data "opennebula_images" "desc" {
+  name_regex = "^test-data-images-"
+  sort_order = "DESC"
+
+  depends_on = [
+    opennebula_image.first,
+    opennebula_image.second,
+  ]
+}
+
+data "opennebula_images" "tags" {
+  name_regex = "^test-data-images-"
+  tags = {
+    team = "first"
+  }
+
+  depends_on = [
+    opennebula_image.first,
+    opennebula_image.second,
+  ]
+}
+
+data "opennebula_images" "recent" {
+  name_regex  = "^test-data-images-"
+  most_recent = true
+
+  depends_on = [
+    opennebula_image.first,
+    opennebula_image.second,
+  ]
+}
+
+data "opennebula_images" "perms" {
+  name_regex  = "^test-data-images-"
+  permissions = "660"
+
+  depends_on = [
+    opennebula_image.first,
+    opennebula_image.second,
+  ]
+}
+`
@@ -0,0 +1,148 @@
+package opennebula
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	mp "github.com/OpenNebula/one/src/oca/go/src/goca/schemas/marketplace"
+)
+
+func dataOpennebulaMarketPlace() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: datasourceOpennebulaMarketPlaceRead,
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Name of the Marketplace",
+			},
+			"market_mad": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "Marketplace driver, i.e. http, s3, one",
+			},
+			"total_mb": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Total capacity of the Marketplace in MB",
+			},
+			"free_mb": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Free capacity of the Marketplace in MB",
+			},
+			"used_mb": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Used capacity of the Marketplace in MB",
+			},
+			"apps": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "List of the IDs of the Apps of the Marketplace",
+				Elem: &schema.Schema{
+					Type: schema.TypeInt,
+				},
+			},
+			"tags": tagsSchema(),
+		},
+	}
+}
+
+func marketPlaceFilter(d *schema.ResourceData, meta interface{}) (*mp.MarketPlace, error) {
+
+	config := meta.(*Configuration)
+	controller := config.Controller
+
+	marketplaces, err := controller.MarketPlaces().Info()
+	if err != nil {
+		return nil, err
+	}
+
+	// filter marketplaces with user defined criterias
+	name, nameOk := d.GetOk("name")
+	marketMad, marketMadOk := d.GetOk("market_mad")
+	tagsInterface, tagsOk := d.GetOk("tags")
+	tags := tagsInterface.(map[string]interface{})
+
+	match := make([]*mp.MarketPlace, 0, 1)
+	for i, marketplace := range marketplaces.MarketPlaces {
+
+		if nameOk && marketplace.Name != name {
+			continue
+		}
+
+		if marketMadOk && marketplace.MarketMad != marketMad {
+			continue
+		}
+
+		if tagsOk && !matchTags(marketplace.Template.Template, tags) {
+			continue
+		}
+
+		match = append(match, &marketplaces.MarketPlaces[i])
+	}
+
+	// check filtering results
+	if len(match) == 0 {
+		return nil, fmt.Errorf("no marketplace match the constraints")
+	} else if len(match) > 1 {
+		return nil, fmt.Errorf("several marketplaces match the constraints")
+	}
+
+	return match[0], nil
+}
+
+func datasourceOpennebulaMarketPlaceRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+
+	var diags diag.Diagnostics
+
+	marketplace, err := marketPlaceFilter(d, meta)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "marketplaces filtering failed",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+
+	tplPairs := pairsToMap(marketplace.Template.Template)
+
+	d.SetId(strconv.FormatInt(int64(marketplace.ID), 10))
+	d.Set("name", marketplace.Name)
+	d.Set("market_mad", marketplace.MarketMad)
+	d.Set("total_mb", marketplace.TotalMB)
+	d.Set("free_mb", marketplace.FreeMB)
+	d.Set("used_mb", marketplace.UsedMB)
+
+	err = d.Set("apps", marketplace.MarketPlaceAppsIDs.ID)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "setting attribute failed",
+			Detail:   fmt.Sprintf("Marketplace (ID: %d): %s", marketplace.ID, err),
+		})
+		return diags
+	}
+
+	if len(tplPairs) > 0 {
+		err := d.Set("tags", tplPairs)
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "setting attribute failed",
+				Detail:   fmt.Sprintf("Marketplace (ID: %d): %s", marketplace.ID, err),
+			})
+			return diags
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,164 @@
+package opennebula
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/OpenNebula/one/src/oca/go/src/goca/parameters"
+	app "github.com/OpenNebula/one/src/oca/go/src/goca/schemas/marketplaceapp"
+)
+
+func dataOpennebulaMarketPlaceApp() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: datasourceOpennebulaMarketPlaceAppRead,
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Name of the App",
+			},
+			"version": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "Version of the App",
+			},
+			"marketplace_id": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Computed:    true,
+				Description: "ID of the marketplace of the App",
+			},
+			"description": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Description of the App",
+			},
+			"type": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Type of the App",
+			},
+			"state": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "State of the App",
+			},
+			"size": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Size of the App in MB",
+			},
+			"format": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Format of the App image",
+			},
+			"regtime": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Registration time of the App",
+			},
+			"tags": tagsSchema(),
+		},
+	}
+}
+
+func marketPlaceAppFilter(d *schema.ResourceData, meta interface{}) (*app.MarketPlaceApp, error) {
+
+	config := meta.(*Configuration)
+	controller := config.Controller
+
+	apps, err := controller.MarketPlaceApps().Info(parameters.PoolWhoAll, -1, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	// filter apps with user defined criterias
+	name, nameOk := d.GetOk("name")
+	version, versionOk := d.GetOk("version")
+	marketplaceID, marketplaceIDOk := d.GetOkExists("marketplace_id")
+	tagsInterface, tagsOk := d.GetOk("tags")
+	tags := tagsInterface.(map[string]interface{})
+
+	match := make([]*app.MarketPlaceApp, 0, 1)
+	for i, marketApp := range apps.MarketPlaceApps {
+
+		if nameOk && marketApp.Name != name {
+			continue
+		}
+
+		if versionOk && marketApp.Version != version {
+			continue
+		}
+
+		if marketplaceIDOk && (marketApp.MarketPlaceID == nil || *marketApp.MarketPlaceID != marketplaceID.(int)) {
+			continue
+		}
+
+		if tagsOk && !matchTags(marketApp.Template, tags) {
+			continue
+		}
+
+		match = append(match, &apps.MarketPlaceApps[i])
+	}
+
+	// check filtering results
+	if len(match) == 0 {
+		return nil, fmt.Errorf("no marketplace app match the constraints")
+	} else if len(match) > 1 {
+		return nil, fmt.Errorf("several marketplace apps match the constraints")
+	}
+
+	return match[0], nil
+}
+
+func datasourceOpennebulaMarketPlaceAppRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+
+	var diags diag.Diagnostics
+
+	marketApp, err := marketPlaceAppFilter(d, meta)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "marketplace apps filtering failed",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+
+	tplPairs := pairsToMap(marketApp.Template)
+
+	d.SetId(strconv.FormatInt(int64(marketApp.ID), 10))
+	d.Set("name", marketApp.Name)
+	d.Set("version", marketApp.Version)
+	d.Set("description", marketApp.Description)
+	d.Set("type", marketPlaceAppTypeString(marketApp.Type))
+	d.Set("state", marketPlaceAppStateString(marketApp.State))
+	d.Set("size", marketApp.Size)
+	d.Set("format", marketApp.Format)
+	d.Set("regtime", marketApp.RegTime)
+
+	if marketApp.MarketPlaceID != nil {
+		d.Set("marketplace_id", *marketApp.MarketPlaceID)
+	}
+
+	if len(tplPairs) > 0 {
+		err := d.Set("tags", tplPairs)
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "setting attribute failed",
+				Detail:   fmt.Sprintf("Marketplace app (ID: %d): %s", marketApp.ID, err),
+			})
+			return diags
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package opennebula
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/OpenNebula/one/src/oca/go/src/goca/parameters"
+	sgk "github.com/OpenNebula/one/src/oca/go/src/goca/schemas/securitygroup/keys"
+)
+
+func dataOpennebulaSecurityGroups() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: datasourceOpennebulaSecurityGroupsRead,
+
+		Schema: mergeSchemas(pluralFiltersSchema(true), pluralSortSchema(false), map[string]*schema.Schema{
+			"security_groups": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Ordered list of the security groups matching the constraints",
+				Elem: &schema.Resource{
+					Schema: mergeSchemas(pluralCommonAttributes(), pluralOwnerAttributes(), map[string]*schema.Schema{
+						"description": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Description of the security group",
+						},
+					}),
+				},
+			},
+		}),
+	}
+}
+
+func datasourceOpennebulaSecurityGroupsRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+
+	var diags diag.Diagnostics
+
+	config := meta.(*Configuration)
+	controller := config.Controller
+
+	securityGroups, err := controller.SecurityGroups().Info(parameters.PoolWhoAll, -1, -1)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to retrieve security groups",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+
+	filter := newPluralFilter(d, meta)
+
+	items := make([]pluralItem, 0)
+	for _, sg := range securityGroups.SecurityGroups {
+
+		if !filter.match(sg.Name, sg.Template.Template) ||
+			!filter.matchOwner(sg.UID, sg.GID, sg.Permissions) {
+			continue
+		}
+
+		attrs := ownerAttributes(sg.UID, sg.GID, sg.UName, sg.GName, sg.Permissions)
+		attrs["description"], _ = sg.Template.Get(sgk.Description)
+		attrs["tags"] = filter.itemTags(sg.Template.Template)
+
+		items = append(items, pluralItem{
+			id:    sg.ID,
+			name:  sg.Name,
+			attrs: attrs,
+		})
+	}
+
+	err = setPluralItems(d, "security_groups", items)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "setting attribute failed",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package opennebula
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccDataSourceSecurityGroups(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckSecurityGroupDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDataSourceSecurityGroupsConfig,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.opennebula_security_groups.desc", "security_groups.#", "2"),
+					resource.TestCheckResourceAttrPair("data.opennebula_security_groups.desc", "security_groups.0.id", "opennebula_security_group.second", "id"),
+					resource.TestCheckResourceAttrPair("data.opennebula_security_groups.desc", "security_groups.1.id", "opennebula_security_group.first", "id"),
+					resource.TestCheckResourceAttr("data.opennebula_security_groups.desc", "security_groups.1.name", "test-data-sgs-first"),
+					resource.TestCheckResourceAttr("data.opennebula_security_groups.desc", "ids.#", "2"),
+					resource.TestCheckResourceAttr("data.opennebula_security_groups.tags", "security_groups.#", "1"),
+					resource.TestCheckResourceAttr("data.opennebula_security_groups.tags", "security_groups.0.tags.team", "first"),
+				),
+			},
+		},
+	})
+}
+
+var testAccDataSourceSecurityGroupsConfig = `
+resource "opennebula_security_group" "first" {
+  name = "test-data-sgs-first"
+
+  tags = {
+    team = "first"
+  }
+}
+
+resource "opennebula_security_group" "second" {
+  name = "test-data-sgs-second"
+
+  tags = {
+    team = "second"
+  }
+
+  # created after the first one to get a higher ID
+  depends_on = [opennebula_security_group.first]
+}
+
+data "opennebula_security_groups" "desc" {
+  name_regex = "^test-data-sgs-"
+  sort_order = "DESC"
+
+  depends_on = [
+    opennebula_security_group.first,
+    opennebula_security_group.second,
+  ]
+}
+
+data "opennebula_security_groups" "tags" {
+  name_regex = "^test-data-sgs-"
+  tags = {
+    team = "first"
+  }
+
+  depends_on = [
+    opennebula_security_group.first,
+    opennebula_security_group.second,
+  ]
+}
+`
@@ -0,0 +1,113 @@
+package opennebula
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/OpenNebula/one/src/oca/go/src/goca/parameters"
+)
+
+func dataOpennebulaTemplates() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: datasourceOpennebulaTemplatesRead,
+
+		Schema: mergeSchemas(pluralFiltersSchema(true), pluralSortSchema(true), map[string]*schema.Schema{
+			"templates": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Ordered list of the templates matching the constraints",
+				Elem: &schema.Resource{
+					Schema: mergeSchemas(pluralCommonAttributes(), pluralOwnerAttributes(), map[string]*schema.Schema{
+						"regtime": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "Registration time of the template",
+						},
+						"cpu": {
+							Type:        schema.TypeFloat,
+							Computed:    true,
+							Description: "Amount of CPU shares assigned to the virtual machine",
+						},
+						"vcpu": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "Number of virtual CPUs assigned to the virtual machine",
+						},
+						"memory": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "Amount of memory (RAM) in MB assigned to the virtual machine",
+						},
+					}),
+				},
+			},
+		}),
+	}
+}
+
+func datasourceOpennebulaTemplatesRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+
+	var diags diag.Diagnostics
+
+	config := meta.(*Configuration)
+	controller := config.Controller
+
+	templates, err := controller.Templates().Info(parameters.PoolWhoAll, -1, -1)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to retrieve templates",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+
+	filter := newPluralFilter(d, meta)
+
+	items := make([]pluralItem, 0)
+	for _, template := range templates.Templates {
+
+		if !filter.match(template.Name, template.Template.Template) ||
+			!filter.matchOwner(template.UID, template.GID, template.Permissions) {
+			continue
+		}
+
+		attrs := ownerAttributes(template.UID, template.GID, template.UName, template.GName, template.Permissions)
+		attrs["regtime"] = template.RegTime
+		attrs["tags"] = filter.itemTags(template.Template.Template)
+
+		cpu, err := template.Template.GetCPU()
+		if err == nil {
+			attrs["cpu"] = cpu
+		}
+		vcpu, err := template.Template.GetVCPU()
+		if err == nil {
+			attrs["vcpu"] = vcpu
+		}
+		memory, err := template.Template.GetMemory()
+		if err == nil {
+			attrs["memory"] = memory
+		}
+
+		items = append(items, pluralItem{
+			id:      template.ID,
+			name:    template.Name,
+			regTime: template.RegTime,
+			attrs:   attrs,
+		})
+	}
+
+	err = setPluralItems(d, "templates", items)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "setting attribute failed",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package opennebula
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccDataSourceTemplates(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckTemplateDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDataSourceTemplatesConfig,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.opennebula_templates.desc", "templates.#", "2"),
+					resource.TestCheckResourceAttrPair("data.opennebula_templates.desc", "templates.0.id", "opennebula_template.second", "id"),
+					resource.TestCheckResourceAttrPair("data.opennebula_templates.desc", "templates.1.id", "opennebula_template.first", "id"),
+					resource.TestCheckResourceAttr("data.opennebula_templates.desc", "templates.1.name", "test-data-templates-first"),
+					resource.TestCheckResourceAttr("data.opennebula_templates.desc", "ids.#", "2"),
+					resource.TestCheckResourceAttr("data.opennebula_templates.tags", "templates.#", "1"),
+					resource.TestCheckResourceAttr("data.opennebula_templates.tags", "templates.0.tags.team", "first"),
+				),
+			},
+		},
+	})
+}
+
+var testAccDataSourceTemplatesConfig = `
+resource "opennebula_template" "first" {
+  name   = "test-data-templates-first"
+  cpu    = "0.5"
+  memory = "128"
+
+  tags = {
+    team = "first"
+  }
+}
+
+resource "opennebula_template" "second" {
+  name   = "test-data-templates-second"
+  cpu    = "0.5"
+  memory = "128"
+
+  tags = {
+    team = "second"
+  }
+
+  # created after the first one to get a higher ID
+  depends_on = [opennebula_template.first]
+}
+
+data "opennebula_templates" "desc" {
+  name_regex = "^test-data-templates-"
+  sort_order = "DESC"
+
+  depends_on = [
+    opennebula_template.first,
+    opennebula_template.second,
+  ]
+}
+
+data "opennebula_templates" "tags" {
+  name_regex = "^test-data-templates-"
+  tags = {
+    team = "first"
+  }
+
+  depends_on = [
+    opennebula_template.first,
+    opennebula_template.second,
+  ]
+}
+`
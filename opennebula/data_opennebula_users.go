@@ -0,0 +1,110 @@
+package opennebula
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataOpennebulaUsers() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: datasourceOpennebulaUsersRead,
+
+		Schema: mergeSchemas(pluralFiltersSchema(false), pluralSortSchema(false), map[string]*schema.Schema{
+			"gid": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Description: "ID of the primary group of the users",
+			},
+			"users": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Ordered list of the users matching the constraints",
+				Elem: &schema.Resource{
+					Schema: mergeSchemas(pluralCommonAttributes(), map[string]*schema.Schema{
+						"gid": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "ID of the primary group of the user",
+						},
+						"gname": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Name of the primary group of the user",
+						},
+						"groups": {
+							Type:        schema.TypeList,
+							Computed:    true,
+							Description: "IDs of the groups of the user",
+							Elem: &schema.Schema{
+								Type: schema.TypeInt,
+							},
+						},
+						"auth_driver": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Authentication driver of the user",
+						},
+					}),
+				},
+			},
+		}),
+	}
+}
+
+func datasourceOpennebulaUsersRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+
+	var diags diag.Diagnostics
+
+	config := meta.(*Configuration)
+	controller := config.Controller
+
+	users, err := controller.Users().Info()
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to retrieve users",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+
+	filter := newPluralFilter(d, meta)
+
+	items := make([]pluralItem, 0)
+	for _, user := range users.Users {
+
+		if !filter.match(user.Name, user.Template) {
+			continue
+		}
+
+		if filter.gidOk && user.GID != filter.gid {
+			continue
+		}
+
+		items = append(items, pluralItem{
+			id:   user.ID,
+			name: user.Name,
+			attrs: map[string]interface{}{
+				"gid":         user.GID,
+				"gname":       user.GName,
+				"groups":      user.Groups.ID,
+				"auth_driver": user.AuthDriver,
+				"tags":        filter.itemTags(user.Template),
+			},
+		})
+	}
+
+	err = setPluralItems(d, "users", items)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "setting attribute failed",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package opennebula
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccDataSourceUsers(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckUserDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDataSourceUsersConfig,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.opennebula_users.desc", "users.#", "2"),
+					resource.TestCheckResourceAttrPair("data.opennebula_users.desc", "users.0.id", "opennebula_user.second", "id"),
+					resource.TestCheckResourceAttrPair("data.opennebula_users.desc", "users.1.id", "opennebula_user.first", "id"),
+					resource.TestCheckResourceAttr("data.opennebula_users.desc", "users.1.name", "test-data-users-first"),
+					resource.TestCheckResourceAttr("data.opennebula_users.desc", "ids.#", "2"),
+					resource.TestCheckResourceAttr("data.opennebula_users.tags", "users.#", "1"),
+					resource.TestCheckResourceAttr("data.opennebula_users.tags", "users.0.tags.team", "first"),
+				),
+			},
+		},
+	})
+}
+
+var testAccDataSourceUsersConfig = `
+resource "opennebula_user" "first" {
+  name     = "test-data-users-first"
+  password = "p@ssw0rd"
+
+  tags = {
+    team = "first"
+  }
+}
+
+resource "opennebula_user" "second" {
+  name     = "test-data-users-second"
+  password = "p@ssw0rd"
+
+  tags = {
+    team = "second"
+  }
+
+  # created after the first one to get a higher ID
+  depends_on = [opennebula_user.first]
+}
+
+data "opennebula_users" "desc" {
+  name_regex = "^test-data-users-"
+  sort_order = "DESC"
+
+  depends_on = [
+    opennebula_user.first,
+    opennebula_user.second,
+  ]
+}
+
+data "opennebula_users" "tags" {
+  name_regex = "^test-data-users-"
+  tags = {
+    team = "first"
+  }
+
+  depends_on = [
+    opennebula_user.first,
+    opennebula_user.second,
+  ]
+}
+`
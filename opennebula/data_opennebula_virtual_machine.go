@@ -0,0 +1,482 @@
+package opennebula
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/OpenNebula/one/src/oca/go/src/goca/parameters"
+	"github.com/OpenNebula/one/src/oca/go/src/goca/schemas/shared"
+	vmSc "github.com/OpenNebula/one/src/oca/go/src/goca/schemas/vm"
+)
+
+func dataOpennebulaVirtualMachine() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: datasourceOpennebulaVirtualMachineRead,
+
+		Schema: mergeSchemas(dataVirtualMachineAttributes(), dataVirtualMachineFilters(true)),
+	}
+}
+
+// dataVirtualMachineFilters returns the filtering attributes, computed is
+// set for the singular data source which also exports these attributes
+func dataVirtualMachineFilters(computed bool) map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"name": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Computed:    computed,
+			Description: "Name of the virtual machine",
+		},
+		"name_regex": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			Description:  "Regular expression matching the name of the virtual machine",
+			ValidateFunc: validateRegexp,
+		},
+		"state": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Computed:    computed,
+			Description: "State of the virtual machine, or LCM state when the virtual machine is active, i.e. POWEROFF or RUNNING",
+		},
+		"uid": {
+			Type:        schema.TypeInt,
+			Optional:    true,
+			Computed:    computed,
+			Description: "ID of the user owning the virtual machine",
+		},
+		"gid": {
+			Type:        schema.TypeInt,
+			Optional:    true,
+			Computed:    computed,
+			Description: "ID of the group owning the virtual machine",
+		},
+		"tags": func() *schema.Schema {
+			s := tagsSchema()
+			s.Computed = computed
+			return s
+		}(),
+	}
+}
+
+// dataVirtualMachineAttributes returns the exported attributes of a virtual machine
+func dataVirtualMachineAttributes() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"name": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "Name of the virtual machine",
+		},
+		"uid": {
+			Type:        schema.TypeInt,
+			Computed:    true,
+			Description: "ID of the user owning the virtual machine",
+		},
+		"gid": {
+			Type:        schema.TypeInt,
+			Computed:    true,
+			Description: "ID of the group owning the virtual machine",
+		},
+		"uname": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "Name of the user owning the virtual machine",
+		},
+		"gname": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "Name of the group owning the virtual machine",
+		},
+		"state": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "State of the virtual machine",
+		},
+		"lcm_state": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "LCM state of the virtual machine",
+		},
+		"cpu": {
+			Type:        schema.TypeFloat,
+			Computed:    true,
+			Description: "Amount of CPU shares assigned to the virtual machine",
+		},
+		"vcpu": {
+			Type:        schema.TypeInt,
+			Computed:    true,
+			Description: "Number of virtual CPUs assigned to the virtual machine",
+		},
+		"memory": {
+			Type:        schema.TypeInt,
+			Computed:    true,
+			Description: "Amount of memory (RAM) in MB assigned to the virtual machine",
+		},
+		"ip": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "IP of the first NIC of the virtual machine",
+		},
+		"nic": {
+			Type:        schema.TypeList,
+			Computed:    true,
+			Description: "NICs of the virtual machine",
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"nic_id": {
+						Type:     schema.TypeInt,
+						Computed: true,
+					},
+					"network_id": {
+						Type:     schema.TypeInt,
+						Computed: true,
+					},
+					"network": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+					"ip": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+					"ip6": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+					"ip6_global": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+					"ip6_ula": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+					"mac": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+					"security_groups": {
+						Type:     schema.TypeList,
+						Computed: true,
+						Elem: &schema.Schema{
+							Type: schema.TypeInt,
+						},
+					},
+				},
+			},
+		},
+		"disk": {
+			Type:        schema.TypeList,
+			Computed:    true,
+			Description: "Disks of the virtual machine",
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"disk_id": {
+						Type:     schema.TypeInt,
+						Computed: true,
+					},
+					"image_id": {
+						Type:     schema.TypeInt,
+						Computed: true,
+					},
+					"size": {
+						Type:     schema.TypeInt,
+						Computed: true,
+					},
+					"target": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+					"driver": {
+						Type:     schema.TypeString,
+						Computed: true,
+					},
+				},
+			},
+		},
+		"host_id": {
+			Type:        schema.TypeInt,
+			Computed:    true,
+			Description: "ID of the host of the last placement, -1 if never deployed",
+		},
+		"host_name": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "Name of the host of the last placement",
+		},
+		"cluster_id": {
+			Type:        schema.TypeInt,
+			Computed:    true,
+			Description: "ID of the cluster of the last placement, -1 if never deployed",
+		},
+		"system_datastore_id": {
+			Type:        schema.TypeInt,
+			Computed:    true,
+			Description: "ID of the system datastore of the last placement, -1 if never deployed",
+		},
+		"deploy_id": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "Deployment ID of the virtual machine on the hypervisor",
+		},
+		"tags": {
+			Type:        schema.TypeMap,
+			Computed:    true,
+			Description: "Tags of the virtual machine",
+			Elem: &schema.Schema{
+				Type: schema.TypeString,
+			},
+		},
+	}
+}
+
+// vmStateMatch checks the state, or the LCM state for active virtual machines
+func vmStateMatch(vm *vmSc.VM, state string) bool {
+
+	vmState, lcmState, err := vm.StateString()
+	if err != nil {
+		return false
+	}
+
+	return strings.EqualFold(vmState, state) ||
+		(vmState == "ACTIVE" && strings.EqualFold(lcmState, state))
+}
+
+// vmFilter returns all the virtual machines matching the user defined criterias
+func vmFilter(d *schema.ResourceData, meta interface{}) ([]*vmSc.VM, error) {
+
+	config := meta.(*Configuration)
+	controller := config.Controller
+
+	// retrieve the full templates to get NICs and disks
+	vms, err := controller.VMs().InfoExtended(parameters.PoolWhoAll, -1, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	// filter virtual machines with user defined criterias
+	name, nameOk := d.GetOk("name")
+	nameRegex, nameRegexOk := d.GetOk("name_regex")
+	state, stateOk := d.GetOk("state")
+	uid, uidOk := d.GetOkExists("uid")
+	gid, gidOk := d.GetOkExists("gid")
+	tagsInterface, tagsOk := d.GetOk("tags")
+	tags := tagsInterface.(map[string]interface{})
+
+	var re *regexp.Regexp
+	if nameRegexOk {
+		re = regexp.MustCompile(nameRegex.(string))
+	}
+
+	match := make([]*vmSc.VM, 0, 1)
+	for i, vm := range vms.VMs {
+
+		if nameOk && vm.Name != name {
+			continue
+		}
+
+		if nameRegexOk && !re.MatchString(vm.Name) {
+			continue
+		}
+
+		if stateOk && !vmStateMatch(&vms.VMs[i], state.(string)) {
+			continue
+		}
+
+		if uidOk && vm.UID != uid.(int) {
+			continue
+		}
+
+		if gidOk && vm.GID != gid.(int) {
+			continue
+		}
+
+		if tagsOk && !matchTags(vm.UserTemplate.Template, tags) {
+			continue
+		}
+
+		match = append(match, &vms.VMs[i])
+	}
+
+	return match, nil
+}
+
+func flattenDataVMNIC(nic shared.NIC) map[string]interface{} {
+
+	nicID, _ := nic.ID()
+	networkID, err := nic.GetI(shared.NetworkID)
+	if err != nil {
+		networkID = -1
+	}
+	network, _ := nic.Get(shared.Network)
+	ip, _ := nic.Get(shared.IP)
+	ip6, _ := nic.GetStr("IP6")
+	ip6Global, _ := nic.GetStr("IP6_GLOBAL")
+	ip6ULA, _ := nic.GetStr("IP6_ULA")
+	mac, _ := nic.Get(shared.MAC)
+
+	sg := make([]int, 0)
+	securityGroups, _ := nic.Get(shared.SecurityGroups)
+	if len(securityGroups) > 0 {
+		for _, s := range strings.Split(securityGroups, ",") {
+			sgID, err := strconv.Atoi(s)
+			if err != nil {
+				continue
+			}
+			sg = append(sg, sgID)
+		}
+	}
+
+	return map[string]interface{}{
+		"nic_id":          nicID,
+		"network_id":      networkID,
+		"network":         network,
+		"ip":              ip,
+		"ip6":             ip6,
+		"ip6_global":      ip6Global,
+		"ip6_ula":         ip6ULA,
+		"mac":             mac,
+		"security_groups": sg,
+	}
+}
+
+func flattenDataVMDisk(disk shared.Disk) map[string]interface{} {
+
+	diskID, _ := disk.ID()
+	imageID, err := disk.GetI(shared.ImageID)
+	if err != nil {
+		imageID = -1
+	}
+	size, _ := disk.GetI(shared.Size)
+	target, _ := disk.Get(shared.TargetDisk)
+	driver, _ := disk.Get(shared.Driver)
+
+	return map[string]interface{}{
+		"disk_id":  diskID,
+		"image_id": imageID,
+		"size":     size,
+		"target":   target,
+		"driver":   driver,
+	}
+}
+
+// flattenDataVirtualMachine returns the exported attributes of a virtual machine
+func flattenDataVirtualMachine(vm *vmSc.VM) map[string]interface{} {
+
+	state, lcmState, _ := vm.StateString()
+	cpu, _ := vm.Template.GetCPU()
+	vcpu, _ := vm.Template.GetVCPU()
+	memory, _ := vm.Template.GetMemory()
+
+	ip := ""
+	nics := vm.Template.GetNICs()
+	nicList := make([]interface{}, 0, len(nics))
+	for i, nic := range nics {
+		nicMap := flattenDataVMNIC(nic)
+		if i == 0 {
+			ip = nicMap["ip"].(string)
+		}
+		nicList = append(nicList, nicMap)
+	}
+
+	disks := vm.Template.GetDisks()
+	diskList := make([]interface{}, 0, len(disks))
+	for _, disk := range disks {
+		diskList = append(diskList, flattenDataVMDisk(disk))
+	}
+
+	// placement from the last history record
+	hostID, clusterID, dsID := -1, -1, -1
+	hostName := ""
+	if len(vm.HistoryRecords) > 0 {
+		last := vm.HistoryRecords[len(vm.HistoryRecords)-1]
+		hostID = last.HID
+		hostName = last.Hostname
+		clusterID = last.CID
+		dsID = last.DSID
+	}
+
+	return map[string]interface{}{
+		"name":                vm.Name,
+		"uid":                 vm.UID,
+		"gid":                 vm.GID,
+		"uname":               vm.UName,
+		"gname":               vm.GName,
+		"state":               state,
+		"lcm_state":           lcmState,
+		"cpu":                 cpu,
+		"vcpu":                vcpu,
+		"memory":              memory,
+		"ip":                  ip,
+		"nic":                 nicList,
+		"disk":                diskList,
+		"host_id":             hostID,
+		"host_name":           hostName,
+		"cluster_id":          clusterID,
+		"system_datastore_id": dsID,
+		"deploy_id":           vm.DeployID,
+		"tags":                pairsToMap(vm.UserTemplate.Template),
+	}
+}
+
+func datasourceOpennebulaVirtualMachineRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+
+	var diags diag.Diagnostics
+
+	vms, err := vmFilter(d, meta)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "virtual machines filtering failed",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+
+	// check filtering results
+	if len(vms) == 0 {
+		err = fmt.Errorf("no virtual machine match the constraints")
+	} else if len(vms) > 1 {
+		err = fmt.Errorf("several virtual machines match the constraints")
+	}
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "virtual machines filtering failed",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+
+	vm := vms[0]
+
+	d.SetId(strconv.Itoa(vm.ID))
+
+	_, stateOk := d.GetOk("state")
+
+	for k, v := range flattenDataVirtualMachine(vm) {
+
+		// keep the user defined state which could be an LCM state
+		if k == "state" && stateOk {
+			continue
+		}
+
+		err = d.Set(k, v)
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "setting attribute failed",
+				Detail:   fmt.Sprintf("Virtual machine (ID: %d): %s", vm.ID, err),
+			})
+			return diags
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package opennebula
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+
+	vmSc "github.com/OpenNebula/one/src/oca/go/src/goca/schemas/vm"
+)
+
+func TestVMStateMatch(t *testing.T) {
+
+	running := &vmSc.VM{StateRaw: int(vmSc.Active), LCMStateRaw: int(vmSc.Running)}
+	poweroff := &vmSc.VM{StateRaw: int(vmSc.Poweroff), LCMStateRaw: int(vmSc.LcmInit)}
+	// the LCM state of an inactive VM is meaningless
+	stopped := &vmSc.VM{StateRaw: int(vmSc.Stopped), LCMStateRaw: int(vmSc.Running)}
+	invalid := &vmSc.VM{StateRaw: 1000}
+
+	cases := []struct {
+		name   string
+		vm     *vmSc.VM
+		state  string
+		expect bool
+	}{
+		{"running VM state", running, "ACTIVE", true},
+		{"running LCM state", running, "RUNNING", true},
+		{"running lowercase", running, "running", true},
+		{"running other LCM state", running, "PROLOG", false},
+		{"running other state", running, "POWEROFF", false},
+		{"poweroff state", poweroff, "POWEROFF", true},
+		{"poweroff LCM state", poweroff, "LCM_INIT", false},
+		{"stopped LCM state", stopped, "RUNNING", false},
+		{"stopped state", stopped, "STOPPED", true},
+		{"invalid state", invalid, "ACTIVE", false},
+	}
+
+	for _, c := range cases {
+		if vmStateMatch(c.vm, c.state) != c.expect {
+			t.Errorf("%s: expected match %t for state %s", c.name, c.expect, c.state)
+		}
+	}
+}
+
+func TestAccDataSourceVirtualMachine(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVirtualMachineDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDataSourceVirtualMachineConfig,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair("data.opennebula_virtual_machine.by_name", "id", "opennebula_virtual_machine.test", "id"),
+					resource.TestCheckResourceAttr("data.opennebula_virtual_machine.by_name", "state", "RUNNING"),
+					resource.TestCheckResourceAttr("data.opennebula_virtual_machine.by_name", "lcm_state", "RUNNING"),
+					resource.TestCheckResourceAttr("data.opennebula_virtual_machine.by_name", "memory", "128"),
+					resource.TestCheckResourceAttr("data.opennebula_virtual_machine.by_name", "tags.env", "data"),
+					resource.TestCheckResourceAttr("data.opennebula_virtual_machines.by_tags", "virtual_machines.#", "2"),
+					resource.TestCheckResourceAttr("data.opennebula_virtual_machines.by_regex", "virtual_machines.#", "1"),
+					resource.TestCheckResourceAttrPair("data.opennebula_virtual_machines.by_regex", "virtual_machines.0.id", "opennebula_virtual_machine.other", "id"),
+				),
+			},
+		},
+	})
+}
+
+var testAccDataSourceVirtualMachineConfig = `
+resource "opennebula_virtual_machine" "test" {
+  name   = "test-data-virtual_machine"
+  group  = "oneadmin"
+  memory = 128
+  cpu    = 0.1
+
+  tags = {
+    env = "data"
+  }
+}
+
+resource "opennebula_virtual_machine" "other" {
+  name   = "test-data-virtual_machine-other"
+  group  = "oneadmin"
+  memory = 128
+  cpu    = 0.1
+
+  tags = {
+    env = "data"
+  }
+}
+
+data "opennebula_virtual_machine" "by_name" {
+  name  = opennebula_virtual_machine.test.name
+  state = "RUNNING"
+}
+
+data "opennebula_virtual_machines" "by_tags" {
+  tags = {
+    env = "data"
+  }
+
+  depends_on = [
+    opennebula_virtual_machine.test,
+    opennebula_virtual_machine.other,
+  ]
+}
+
+data "opennebula_virtual_machines" "by_regex" {
+  name_regex = "^test-data-virtual_machine-"
+
+  depends_on = [
+    opennebula_virtual_machine.test,
+    opennebula_virtual_machine.other,
+  ]
+}
+`
@@ -0,0 +1,74 @@
+package opennebula
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataOpennebulaVirtualMachines() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: datasourceOpennebulaVirtualMachinesRead,
+
+		Schema: mergeSchemas(dataVirtualMachineFilters(false), map[string]*schema.Schema{
+			"virtual_machines": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "List of the virtual machines matching the constraints",
+				Elem: &schema.Resource{
+					Schema: mergeSchemas(dataVirtualMachineAttributes(), map[string]*schema.Schema{
+						"id": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "ID of the virtual machine",
+						},
+					}),
+				},
+			},
+		}),
+	}
+}
+
+func datasourceOpennebulaVirtualMachinesRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+
+	var diags diag.Diagnostics
+
+	vms, err := vmFilter(d, meta)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "virtual machines filtering failed",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+
+	vmIDs := make([]string, 0, len(vms))
+	vmList := make([]map[string]interface{}, 0, len(vms))
+	for _, vm := range vms {
+		vmMap := flattenDataVirtualMachine(vm)
+		vmMap["id"] = vm.ID
+
+		vmIDs = append(vmIDs, strconv.Itoa(vm.ID))
+		vmList = append(vmList, vmMap)
+	}
+
+	// the ID depends on the list of matching virtual machines
+	d.SetId(strconv.Itoa(schema.HashString(strings.Join(vmIDs, ","))))
+
+	err = d.Set("virtual_machines", vmList)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "setting attribute failed",
+			Detail:   fmt.Sprintf("virtual machines: %s", err),
+		})
+		return diags
+	}
+
+	return nil
+}
@@ -0,0 +1,204 @@
+package opennebula
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/OpenNebula/one/src/oca/go/src/goca"
+)
+
+func dataOpennebulaVirtualNetworkLeases() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: datasourceOpennebulaVirtualNetworkLeasesRead,
+
+		Schema: map[string]*schema.Schema{
+			"virtual_network_id": {
+				Type:        schema.TypeInt,
+				Required:    true,
+				Description: "ID of the virtual network",
+			},
+			"ar_id": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Description: "Only retrieve the leases of this address range",
+			},
+			"vm_id": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Description: "Only retrieve the leases owned by this virtual machine",
+			},
+			"leases": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "List of the leases of the virtual network",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"ar_id": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "ID of the address range of the lease",
+						},
+						"ip": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "IPv4 of the lease",
+						},
+						"ip6": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "IPv6 of the lease",
+						},
+						"ip6_global": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Global IPv6 of the lease",
+						},
+						"ip6_ula": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "ULA IPv6 of the lease",
+						},
+						"mac": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "MAC of the lease",
+						},
+						"vm_id": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "ID of the virtual machine owning the lease, -1 if none or if the lease is on hold",
+						},
+						"virtual_router_id": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "ID of the virtual router owning the lease, -1 if none",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// vnetLeases retrieves the leases of each address range, the goca lease
+// structure doesn't allow to differentiate an unset owner from the ID 0
+type vnetLeases struct {
+	XMLName xml.Name `xml:"VNET"`
+	ARs     []struct {
+		ID     int         `xml:"AR_ID"`
+		Leases []vnetLease `xml:"LEASES>LEASE"`
+	} `xml:"AR_POOL>AR"`
+}
+
+type vnetLease struct {
+	IP        string `xml:"IP"`
+	IP6       string `xml:"IP6"`
+	IP6Global string `xml:"IP6_GLOBAL"`
+	IP6ULA    string `xml:"IP6_ULA"`
+	MAC       string `xml:"MAC"`
+	VM        *int   `xml:"VM"`
+	VRouter   *int   `xml:"VROUTER"`
+}
+
+func vnetLeasesInfo(controller *goca.Controller, vnetID int) (*vnetLeases, error) {
+
+	response, err := controller.Client.Call("one.vn.info", vnetID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	leases := &vnetLeases{}
+	err = xml.Unmarshal([]byte(response.Body()), leases)
+	if err != nil {
+		return nil, err
+	}
+
+	return leases, nil
+}
+
+// flattenVnetLeases returns the leases of the address range arID and owned by
+// the virtual machine vmID, each filter being applied only when its flag is set
+func flattenVnetLeases(vnetLeases *vnetLeases, arID int, arIDOk bool, vmID int, vmIDOk bool) []map[string]interface{} {
+
+	leases := make([]map[string]interface{}, 0)
+	for _, ar := range vnetLeases.ARs {
+
+		if arIDOk && ar.ID != arID {
+			continue
+		}
+
+		for _, lease := range ar.Leases {
+
+			leaseVMID := -1
+			if lease.VM != nil {
+				leaseVMID = *lease.VM
+			}
+			leaseVRouterID := -1
+			if lease.VRouter != nil {
+				leaseVRouterID = *lease.VRouter
+			}
+
+			if vmIDOk && leaseVMID != vmID {
+				continue
+			}
+
+			leases = append(leases, map[string]interface{}{
+				"ar_id":             ar.ID,
+				"ip":                lease.IP,
+				"ip6":               lease.IP6,
+				"ip6_global":        lease.IP6Global,
+				"ip6_ula":           lease.IP6ULA,
+				"mac":               lease.MAC,
+				"vm_id":             leaseVMID,
+				"virtual_router_id": leaseVRouterID,
+			})
+		}
+	}
+
+	return leases
+}
+
+func datasourceOpennebulaVirtualNetworkLeasesRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+
+	var diags diag.Diagnostics
+
+	config := meta.(*Configuration)
+	controller := config.Controller
+
+	vnetID := d.Get("virtual_network_id").(int)
+
+	vnetLeases, err := vnetLeasesInfo(controller, vnetID)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to retrieve informations",
+			Detail:   fmt.Sprintf("virtual network (ID: %d): %s", vnetID, err),
+		})
+		return diags
+	}
+
+	// filter leases with user defined criterias
+	arID, arIDOk := d.GetOkExists("ar_id")
+	vmID, vmIDOk := d.GetOkExists("vm_id")
+
+	leases := flattenVnetLeases(vnetLeases, arID.(int), arIDOk, vmID.(int), vmIDOk)
+
+	d.SetId(strconv.Itoa(vnetID))
+
+	err = d.Set("leases", leases)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "setting attribute failed",
+			Detail:   fmt.Sprintf("Virtual network (ID: %d): %s", vnetID, err),
+		})
+		return diags
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package opennebula
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+var testVnetLeasesXML = `
+<VNET>
+  <AR_POOL>
+    <AR>
+      <AR_ID>0</AR_ID>
+      <LEASES>
+        <LEASE><IP>172.16.100.110</IP><MAC>02:00:ac:10:64:6e</MAC><VM>0</VM></LEASE>
+        <LEASE><IP>172.16.100.111</IP><MAC>02:00:ac:10:64:6f</MAC><VM>-1</VM></LEASE>
+        <LEASE><IP>172.16.100.112</IP><MAC>02:00:ac:10:64:70</MAC><VROUTER>3</VROUTER></LEASE>
+      </LEASES>
+    </AR>
+    <AR>
+      <AR_ID>1</AR_ID>
+      <LEASES>
+        <LEASE><IP6>fd00::1</IP6><MAC>02:00:00:00:00:01</MAC><VM>7</VM></LEASE>
+      </LEASES>
+    </AR>
+  </AR_POOL>
+</VNET>`
+
+func TestFlattenVnetLeases(t *testing.T) {
+
+	leases := &vnetLeases{}
+	err := xml.Unmarshal([]byte(testVnetLeasesXML), leases)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	cases := []struct {
+		name   string
+		arID   int
+		arOk   bool
+		vmID   int
+		vmOk   bool
+		expect []string
+	}{
+		{"all", 0, false, 0, false, []string{"02:00:ac:10:64:6e", "02:00:ac:10:64:6f", "02:00:ac:10:64:70", "02:00:00:00:00:01"}},
+		{"ar 0", 0, true, 0, false, []string{"02:00:ac:10:64:6e", "02:00:ac:10:64:6f", "02:00:ac:10:64:70"}},
+		{"ar 1", 1, true, 0, false, []string{"02:00:00:00:00:01"}},
+		// the VM 0 must not match the leases without owner
+		{"vm 0", 0, false, 0, true, []string{"02:00:ac:10:64:6e"}},
+		{"on hold", 0, false, -1, true, []string{"02:00:ac:10:64:6f", "02:00:ac:10:64:70"}},
+		{"vm 7 in ar 0", 0, true, 7, true, []string{}},
+	}
+
+	for _, c := range cases {
+		result := flattenVnetLeases(leases, c.arID, c.arOk, c.vmID, c.vmOk)
+
+		macs := make([]string, 0, len(result))
+		for _, lease := range result {
+			macs = append(macs, lease["mac"].(string))
+		}
+
+		if len(macs) != len(c.expect) {
+			t.Errorf("%s: expected leases %v, got %v", c.name, c.expect, macs)
+			continue
+		}
+		for i := range macs {
+			if macs[i] != c.expect[i] {
+				t.Errorf("%s: expected leases %v, got %v", c.name, c.expect, macs)
+				break
+			}
+		}
+	}
+
+	lease := flattenVnetLeases(leases, 0, true, 0, false)[2]
+	if lease["vm_id"] != -1 || lease["virtual_router_id"] != 3 {
+		t.Errorf("expected lease owned by the virtual router 3, got VM %v and virtual router %v", lease["vm_id"], lease["virtual_router_id"])
+	}
+
+	lease = flattenVnetLeases(leases, 1, true, 0, false)[0]
+	if lease["ip6"] != "fd00::1" || lease["ar_id"] != 1 {
+		t.Errorf("expected lease fd00::1 of the address range 1, got %v of %v", lease["ip6"], lease["ar_id"])
+	}
+}
+
+func TestAccDataSourceVirtualNetworkLeases(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVirtualNetworkAddressRangeDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDataSourceVirtualNetworkLeasesConfig,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.opennebula_virtual_network_leases.all", "leases.#", "1"),
+					resource.TestCheckResourceAttr("data.opennebula_virtual_network_leases.all", "leases.0.ip", "172.16.100.112"),
+					resource.TestCheckResourceAttr("data.opennebula_virtual_network_leases.all", "leases.0.ar_id", "0"),
+					resource.TestCheckResourceAttr("data.opennebula_virtual_network_leases.all", "leases.0.vm_id", "-1"),
+					resource.TestCheckResourceAttr("data.opennebula_virtual_network_leases.other_ar", "leases.#", "0"),
+				),
+			},
+		},
+	})
+}
+
+var testAccDataSourceVirtualNetworkLeasesConfig = `
+resource "opennebula_virtual_network" "test" {
+  name   = "test-virtual_network-leases"
+  type   = "dummy"
+  bridge = "onebr"
+  mtu    = 1500
+  ar {
+    ar_type = "IP4"
+    size    = 16
+    ip4     = "172.16.100.110"
+  }
+}
+
+resource "opennebula_virtual_network_ip_hold" "ip" {
+  virtual_network_id = opennebula_virtual_network.test.id
+  ip                 = "172.16.100.112"
+}
+
+data "opennebula_virtual_network_leases" "all" {
+  virtual_network_id = opennebula_virtual_network_ip_hold.ip.virtual_network_id
+}
+
+data "opennebula_virtual_network_leases" "other_ar" {
+  virtual_network_id = opennebula_virtual_network_ip_hold.ip.virtual_network_id
+  ar_id              = 1
+}
+`
@@ -0,0 +1,112 @@
+package opennebula
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/OpenNebula/one/src/oca/go/src/goca/parameters"
+)
+
+func dataOpennebulaVirtualNetworks() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: datasourceOpennebulaVirtualNetworksRead,
+
+		Schema: mergeSchemas(pluralFiltersSchema(true), pluralSortSchema(false), map[string]*schema.Schema{
+			"virtual_networks": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Ordered list of the virtual networks matching the constraints",
+				Elem: &schema.Resource{
+					Schema: mergeSchemas(pluralCommonAttributes(), pluralOwnerAttributes(), map[string]*schema.Schema{
+						"type": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Driver of the virtual network",
+						},
+						"bridge": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "Bridge of the virtual network",
+						},
+						"vlan_id": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "VLAN ID of the virtual network",
+						},
+						"mtu": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "MTU of the virtual network",
+						},
+						"used_leases": {
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "Number of used leases of the virtual network",
+						},
+					}),
+				},
+			},
+		}),
+	}
+}
+
+func datasourceOpennebulaVirtualNetworksRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+
+	var diags diag.Diagnostics
+
+	config := meta.(*Configuration)
+	controller := config.Controller
+
+	vnets, err := controller.VirtualNetworks().Info(parameters.PoolWhoAll, -1, -1)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to retrieve virtual networks",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+
+	filter := newPluralFilter(d, meta)
+
+	items := make([]pluralItem, 0)
+	for _, vnet := range vnets.VirtualNetworks {
+
+		if !filter.match(vnet.Name, vnet.Template.Template) ||
+			!filter.matchOwner(vnet.UID, vnet.GID, vnet.Permissions) {
+			continue
+		}
+
+		attrs := ownerAttributes(vnet.UID, vnet.GID, vnet.UName, vnet.GName, vnet.Permissions)
+		attrs["type"] = vnet.VNMad
+		attrs["bridge"] = vnet.Bridge
+		attrs["vlan_id"] = vnet.VlanID
+		attrs["used_leases"] = vnet.UsedLeases
+		attrs["tags"] = filter.itemTags(vnet.Template.Template)
+
+		mtu, err := vnet.Template.GetI("MTU")
+		if err == nil {
+			attrs["mtu"] = mtu
+		}
+
+		items = append(items, pluralItem{
+			id:    vnet.ID,
+			name:  vnet.Name,
+			attrs: attrs,
+		})
+	}
+
+	err = setPluralItems(d, "virtual_networks", items)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "setting attribute failed",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+
+	return nil
+}
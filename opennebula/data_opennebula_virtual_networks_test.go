@@ -0,0 +1,78 @@
+package opennebula
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccDataSourceVirtualNetworks(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVirtualNetworkDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDataSourceVirtualNetworksConfig,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.opennebula_virtual_networks.desc", "virtual_networks.#", "2"),
+					resource.TestCheckResourceAttrPair("data.opennebula_virtual_networks.desc", "virtual_networks.0.id", "opennebula_virtual_network.second", "id"),
+					resource.TestCheckResourceAttrPair("data.opennebula_virtual_networks.desc", "virtual_networks.1.id", "opennebula_virtual_network.first", "id"),
+					resource.TestCheckResourceAttr("data.opennebula_virtual_networks.desc", "virtual_networks.1.name", "test-data-vnets-first"),
+					resource.TestCheckResourceAttr("data.opennebula_virtual_networks.desc", "ids.#", "2"),
+					resource.TestCheckResourceAttr("data.opennebula_virtual_networks.tags", "virtual_networks.#", "1"),
+					resource.TestCheckResourceAttr("data.opennebula_virtual_networks.tags", "virtual_networks.0.tags.team", "first"),
+				),
+			},
+		},
+	})
+}
+
+var testAccDataSourceVirtualNetworksConfig = `
+resource "opennebula_virtual_network" "first" {
+  name   = "test-data-vnets-first"
+  type   = "dummy"
+  bridge = "onebr"
+  mtu    = 1500
+
+  tags = {
+    team = "first"
+  }
+}
+
+resource "opennebula_virtual_network" "second" {
+  name   = "test-data-vnets-second"
+  type   = "dummy"
+  bridge = "onebr"
+  mtu    = 1500
+
+  tags = {
+    team = "second"
+  }
+
+  # created after the first one to get a higher ID
+  depends_on = [opennebula_virtual_network.first]
+}
+
+data "opennebula_virtual_networks" "desc" {
+  name_regex = "^test-data-vnets-"
+  sort_order = "DESC"
+
+  depends_on = [
+    opennebula_virtual_network.first,
+    opennebula_virtual_network.second,
+  ]
+}
+
+data "opennebula_virtual_networks" "tags" {
+  name_regex = "^test-data-vnets-"
+  tags = {
+    team = "first"
+  }
+
+  depends_on = [
+    opennebula_virtual_network.first,
+    opennebula_virtual_network.second,
+  ]
+}
+`
@@ -144,3 +144,18 @@ func mergeSchemas(schema map[string]*schema.Schema, schemas ...map[string]*schem
 
 	return schema
 }
+
+// getClustersValue returns the IDs of the "clusters" attribute, or -1 for the default cluster
+func getClustersValue(d *schema.ResourceData) []int {
+	var result = make([]int, 0)
+
+	if clusters, ok := d.GetOk("clusters"); ok {
+		clusterList := clusters.([]interface{})
+		for i := 0; i < len(clusterList); i++ {
+			result = append(result, clusterList[i].(int))
+		}
+	} else {
+		result = append(result, -1)
+	}
+	return result
+}
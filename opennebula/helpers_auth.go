@@ -0,0 +1,288 @@
+package opennebula
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/OpenNebula/one/src/oca/go/src/goca"
+)
+
+// defaultLoginTokenExpiration is the validity in seconds of the tokens signed with
+// a SSH or x509 key when no login token expiration is defined
+var defaultLoginTokenExpiration = 3600
+
+// x509NameShort maps the OIDs of a distinguished name to the short names used by OpenSSL
+var x509NameShort = map[string]string{
+	"2.5.4.3":                    "CN",
+	"2.5.4.5":                    "serialNumber",
+	"2.5.4.6":                    "C",
+	"2.5.4.7":                    "L",
+	"2.5.4.8":                    "ST",
+	"2.5.4.9":                    "street",
+	"2.5.4.10":                   "O",
+	"2.5.4.11":                   "OU",
+	"2.5.4.17":                   "postalCode",
+	"0.9.2342.19200300.100.1.1":  "UID",
+	"0.9.2342.19200300.100.1.25": "DC",
+	"1.2.840.113549.1.9.1":       "emailAddress",
+}
+
+// oneAuthPath returns the path of the one_auth file: the provider configuration,
+// then the ONE_AUTH environment variable, then ~/.one/one_auth
+func oneAuthPath(d *schema.ResourceData) string {
+
+	if path, ok := d.GetOk("one_auth"); ok {
+		return path.(string)
+	}
+
+	if path := os.Getenv("ONE_AUTH"); path != "" {
+		return path
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(home, ".one", "one_auth")
+}
+
+// readOneAuth reads the credentials from a one_auth file, in the format <user>:<secret>
+func readOneAuth(path string) (string, string, error) {
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", "", err
+	}
+
+	credentials := strings.SplitN(strings.TrimSpace(string(content)), ":", 2)
+	if len(credentials) != 2 || credentials[0] == "" || credentials[1] == "" {
+		return "", "", fmt.Errorf("%s: expected format <username>:<password>", path)
+	}
+
+	return credentials[0], credentials[1], nil
+}
+
+func readRSAPrivateKey(path string) (*rsa.PrivateKey, error) {
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM encoded private key found", path)
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		rsaKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("%s: only RSA private keys are supported", path)
+		}
+		return rsaKey, nil
+	}
+
+	return nil, fmt.Errorf("%s: unsupported private key type %q, the key must be PEM encoded", path, block.Type)
+}
+
+// rsaPrivateEncrypt signs the data with the private key the same way than
+// OpenSSL private_encrypt with the PKCS#1 v1.5 padding
+func rsaPrivateEncrypt(key *rsa.PrivateKey, data string) (string, error) {
+
+	signed, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.Hash(0), []byte(data))
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(signed), nil
+}
+
+// sshLoginToken generates the secret checked by the ssh authentication driver
+func sshLoginToken(username, keyPath string, expiration int) (string, error) {
+
+	key, err := readRSAPrivateKey(keyPath)
+	if err != nil {
+		return "", err
+	}
+
+	expire := time.Now().Unix() + int64(expiration)
+
+	return rsaPrivateEncrypt(key, fmt.Sprintf("%s:%d", username, expire))
+}
+
+// x509DN formats the distinguished name as OpenSSL, i.e. /C=ES/O=OpenNebula/CN=user
+func x509DN(cert *x509.Certificate) (string, error) {
+
+	var rdns pkix.RDNSequence
+	_, err := asn1.Unmarshal(cert.RawSubject, &rdns)
+	if err != nil {
+		return "", err
+	}
+
+	var dn strings.Builder
+	for _, rdn := range rdns {
+		for _, atv := range rdn {
+			name, ok := x509NameShort[atv.Type.String()]
+			if !ok {
+				name = atv.Type.String()
+			}
+			fmt.Fprintf(&dn, "/%s=%v", name, atv.Value)
+		}
+	}
+
+	return dn.String(), nil
+}
+
+// x509LoginToken generates the secret checked by the x509 authentication driver
+func x509LoginToken(username, certPath, keyPath string, expiration int) (string, error) {
+
+	key, err := readRSAPrivateKey(keyPath)
+	if err != nil {
+		return "", err
+	}
+
+	content, err := ioutil.ReadFile(certPath)
+	if err != nil {
+		return "", err
+	}
+
+	// the certificate chain is sent with the signed text
+	certsPEM := make([]string, 0, 1)
+	var cert *x509.Certificate
+	for block, rest := pem.Decode(content); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		if cert == nil {
+			cert, err = x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return "", err
+			}
+		}
+		certsPEM = append(certsPEM, string(pem.EncodeToMemory(block)))
+	}
+	if cert == nil {
+		return "", fmt.Errorf("%s: no PEM encoded certificate found", certPath)
+	}
+
+	dn, err := x509DN(cert)
+	if err != nil {
+		return "", err
+	}
+
+	expire := time.Now().Unix() + int64(expiration)
+
+	signed, err := rsaPrivateEncrypt(key, fmt.Sprintf("%s:%s:%d", username, dn, expire))
+	if err != nil {
+		return "", err
+	}
+
+	token := fmt.Sprintf("%s:%s", signed, strings.Join(certsPEM, ":"))
+
+	return base64.StdEncoding.EncodeToString([]byte(token)), nil
+}
+
+// providerCredentials returns the user and the secret used to authenticate the
+// requests. The secret is the first one defined among: the token, the password,
+// a token signed with a SSH or x509 key, then the content of the one_auth file.
+func providerCredentials(d *schema.ResourceData) (string, string, error) {
+
+	username := d.Get("username").(string)
+
+	expiration := d.Get("login_token_expiration").(int)
+	if expiration <= 0 {
+		expiration = defaultLoginTokenExpiration
+	}
+
+	if token, ok := d.GetOk("token"); ok {
+		if username == "" {
+			return "", "", fmt.Errorf("username should be defined with token")
+		}
+		return username, token.(string), nil
+	}
+
+	if password, ok := d.GetOk("password"); ok {
+		if username == "" {
+			return "", "", fmt.Errorf("username should be defined with password")
+		}
+		return username, password.(string), nil
+	}
+
+	if keyPath, ok := d.GetOk("ssh_private_key"); ok {
+		if username == "" {
+			return "", "", fmt.Errorf("username should be defined with ssh_private_key")
+		}
+
+		secret, err := sshLoginToken(username, keyPath.(string), expiration)
+		if err != nil {
+			return "", "", fmt.Errorf("Failed to sign the SSH login token: %s", err)
+		}
+		return username, secret, nil
+	}
+
+	if certPath, ok := d.GetOk("x509_certificate"); ok {
+		if username == "" {
+			return "", "", fmt.Errorf("username should be defined with x509_certificate")
+		}
+
+		keyPath, ok := d.GetOk("x509_private_key")
+		if !ok {
+			return "", "", fmt.Errorf("x509_private_key should be defined with x509_certificate")
+		}
+
+		secret, err := x509LoginToken(username, certPath.(string), keyPath.(string), expiration)
+		if err != nil {
+			return "", "", fmt.Errorf("Failed to sign the x509 login token: %s", err)
+		}
+		return username, secret, nil
+	}
+
+	path := oneAuthPath(d)
+	fileUser, secret, err := readOneAuth(path)
+	if err != nil {
+		return "", "", fmt.Errorf("no password, token or key defined, and failed to read the one_auth file: %s", err)
+	}
+
+	if username != "" && username != fileUser {
+		return "", "", fmt.Errorf("username %q doesn't match the user %q of the one_auth file %s", username, fileUser, path)
+	}
+
+	return fileUser, secret, nil
+}
+
+// generateLoginToken asks OpenNebula for a login token valid for the given
+// duration in seconds, so that the secret isn't sent with each request
+func generateLoginToken(httpClient *http.Client, username, secret, endpoint string, expiration int) (string, error) {
+
+	client := goca.NewClient(goca.NewConfig(username, secret, endpoint), httpClient)
+
+	response, err := client.Call("one.user.login", username, "", expiration, -1)
+	if err != nil {
+		return "", err
+	}
+
+	return response.Body(), nil
+}
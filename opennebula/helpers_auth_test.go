@@ -0,0 +1,382 @@
+package opennebula
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// testAuthEnv are the environment variables read by the provider credentials
+var testAuthEnv = []string{
+	"OPENNEBULA_USERNAME",
+	"OPENNEBULA_PASSWORD",
+	"OPENNEBULA_TOKEN",
+	"OPENNEBULA_SSH_PRIVATE_KEY",
+	"OPENNEBULA_X509_CERTIFICATE",
+	"OPENNEBULA_X509_PRIVATE_KEY",
+	"OPENNEBULA_LOGIN_TOKEN_EXPIRATION",
+	"ONE_AUTH",
+}
+
+// testUnsetEnv unsets the environment variables and returns a function restoring them
+func testUnsetEnv(keys []string) func() {
+
+	saved := make(map[string]string)
+	for _, k := range keys {
+		if v, ok := os.LookupEnv(k); ok {
+			saved[k] = v
+			os.Unsetenv(k)
+		}
+	}
+
+	return func() {
+		for k, v := range saved {
+			os.Setenv(k, v)
+		}
+	}
+}
+
+func testTempDir(t *testing.T) (string, func()) {
+
+	dir, err := ioutil.TempDir("", "terraform-provider-opennebula")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	return dir, func() { os.RemoveAll(dir) }
+}
+
+func testWriteFile(t *testing.T, dir, name, content string) string {
+
+	path := filepath.Join(dir, name)
+	err := ioutil.WriteFile(path, []byte(content), 0600)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	return path
+}
+
+// testRSAKey generates a RSA key and writes it in the PKCS#1 PEM format
+func testRSAKey(t *testing.T, dir string) (*rsa.PrivateKey, string) {
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	return key, testWriteFile(t, dir, "key.pem", string(keyPEM))
+}
+
+// testCertificate writes a self signed certificate of the key
+func testCertificate(t *testing.T, dir string, key *rsa.PrivateKey) (*x509.Certificate, string) {
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject: pkix.Name{
+			Country:      []string{"ES"},
+			Organization: []string{"OpenNebula"},
+			CommonName:   "user",
+		},
+		NotBefore: time.Now(),
+		NotAfter:  time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+
+	return cert, testWriteFile(t, dir, "cert.pem", string(certPEM))
+}
+
+// testRSAPublicDecrypt reverts rsaPrivateEncrypt as OpenSSL public_decrypt does
+func testRSAPublicDecrypt(t *testing.T, key *rsa.PublicKey, signed string) string {
+
+	raw, err := base64.StdEncoding.DecodeString(signed)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	m := new(big.Int).Exp(new(big.Int).SetBytes(raw), big.NewInt(int64(key.E)), key.N)
+	em := m.Bytes()
+
+	// the leading 0x00 is dropped by big.Int, then 0x01 0xff... 0x00 data
+	if len(em) == 0 || em[0] != 0x01 {
+		t.Fatalf("invalid PKCS#1 v1.5 padding")
+	}
+	sep := strings.IndexByte(string(em), 0x00)
+	if sep < 0 {
+		t.Fatalf("invalid PKCS#1 v1.5 padding")
+	}
+
+	return string(em[sep+1:])
+}
+
+// testCheckExpiration checks that the token expires expiration seconds from now
+func testCheckExpiration(t *testing.T, value string, expiration int) {
+
+	expire, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := time.Now().Unix() + int64(expiration)
+	if expire < expected-60 || expire > expected {
+		t.Errorf("expected expiration around %d, got %d", expected, expire)
+	}
+}
+
+func TestReadOneAuth(t *testing.T) {
+
+	dir, cleanup := testTempDir(t)
+	defer cleanup()
+
+	cases := []struct {
+		name     string
+		content  string
+		user     string
+		password string
+		err      bool
+	}{
+		{"basic", "oneadmin:secret", "oneadmin", "secret", false},
+		{"trailing newline", "oneadmin:secret\n", "oneadmin", "secret", false},
+		{"colon in secret", "oneadmin:sec:ret", "oneadmin", "sec:ret", false},
+		{"no secret", "oneadmin", "", "", true},
+		{"empty secret", "oneadmin:", "", "", true},
+		{"empty user", ":secret", "", "", true},
+		{"empty", "", "", "", true},
+	}
+
+	for i, c := range cases {
+		path := testWriteFile(t, dir, strconv.Itoa(i), c.content)
+
+		user, password, err := readOneAuth(path)
+		if (err != nil) != c.err {
+			t.Errorf("%s: expected error %t, got %v", c.name, c.err, err)
+			continue
+		}
+		if user != c.user || password != c.password {
+			t.Errorf("%s: expected %s:%s, got %s:%s", c.name, c.user, c.password, user, password)
+		}
+	}
+
+	_, _, err := readOneAuth(filepath.Join(dir, "missing"))
+	if err == nil {
+		t.Errorf("missing file: expected an error")
+	}
+}
+
+func TestX509DN(t *testing.T) {
+
+	dir, cleanup := testTempDir(t)
+	defer cleanup()
+
+	key, _ := testRSAKey(t, dir)
+	cert, _ := testCertificate(t, dir, key)
+
+	dn, err := x509DN(cert)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if dn != "/C=ES/O=OpenNebula/CN=user" {
+		t.Errorf("expected DN /C=ES/O=OpenNebula/CN=user, got %s", dn)
+	}
+}
+
+func TestSSHLoginToken(t *testing.T) {
+
+	dir, cleanup := testTempDir(t)
+	defer cleanup()
+
+	key, keyPath := testRSAKey(t, dir)
+
+	token, err := sshLoginToken("user", keyPath, 600)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	parts := strings.Split(testRSAPublicDecrypt(t, &key.PublicKey, token), ":")
+	if len(parts) != 2 || parts[0] != "user" {
+		t.Fatalf("expected user:<expiration>, got %v", parts)
+	}
+	testCheckExpiration(t, parts[1], 600)
+
+	_, err = sshLoginToken("user", testWriteFile(t, dir, "invalid.pem", "invalid"), 600)
+	if err == nil {
+		t.Errorf("invalid key: expected an error")
+	}
+}
+
+func TestX509LoginToken(t *testing.T) {
+
+	dir, cleanup := testTempDir(t)
+	defer cleanup()
+
+	key, keyPath := testRSAKey(t, dir)
+	_, certPath := testCertificate(t, dir, key)
+
+	token, err := x509LoginToken("user", certPath, keyPath, 600)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(token)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// the signed text is followed by the certificate
+	parts := strings.SplitN(string(decoded), ":", 2)
+	if len(parts) != 2 || !strings.HasPrefix(parts[1], "-----BEGIN CERTIFICATE-----") {
+		t.Fatalf("expected <signed>:<certificate>, got %s", decoded)
+	}
+
+	signed := strings.Split(testRSAPublicDecrypt(t, &key.PublicKey, parts[0]), ":")
+	if len(signed) != 3 || signed[0] != "user" || signed[1] != "/C=ES/O=OpenNebula/CN=user" {
+		t.Fatalf("expected user:<dn>:<expiration>, got %v", signed)
+	}
+	testCheckExpiration(t, signed[2], 600)
+
+	_, err = x509LoginToken("user", keyPath, keyPath, 600)
+	if err == nil {
+		t.Errorf("no certificate: expected an error")
+	}
+}
+
+func TestProviderCredentials(t *testing.T) {
+
+	defer testUnsetEnv(testAuthEnv)()
+
+	dir, cleanup := testTempDir(t)
+	defer cleanup()
+
+	key, keyPath := testRSAKey(t, dir)
+	_, certPath := testCertificate(t, dir, key)
+	oneAuth := testWriteFile(t, dir, "one_auth", "fileuser:filesecret\n")
+
+	cases := []struct {
+		name   string
+		raw    map[string]interface{}
+		user   string
+		secret string
+		err    string
+	}{
+		{
+			name:   "token first",
+			raw:    map[string]interface{}{"username": "user", "token": "tok", "password": "pass", "ssh_private_key": keyPath},
+			user:   "user",
+			secret: "tok",
+		},
+		{
+			name:   "password before keys",
+			raw:    map[string]interface{}{"username": "user", "password": "pass", "ssh_private_key": keyPath, "x509_certificate": certPath, "x509_private_key": keyPath},
+			user:   "user",
+			secret: "pass",
+		},
+		{
+			name:   "ssh before x509",
+			raw:    map[string]interface{}{"username": "user", "ssh_private_key": keyPath, "x509_certificate": certPath, "x509_private_key": keyPath},
+			user:   "user",
+			secret: "ssh",
+		},
+		{
+			name:   "x509",
+			raw:    map[string]interface{}{"username": "user", "x509_certificate": certPath, "x509_private_key": keyPath},
+			user:   "user",
+			secret: "x509",
+		},
+		{
+			name:   "one_auth file last",
+			raw:    map[string]interface{}{"one_auth": oneAuth},
+			user:   "fileuser",
+			secret: "filesecret",
+		},
+		{
+			name:   "one_auth file with the same username",
+			raw:    map[string]interface{}{"username": "fileuser", "one_auth": oneAuth},
+			user:   "fileuser",
+			secret: "filesecret",
+		},
+		{
+			name: "one_auth file with another username",
+			raw:  map[string]interface{}{"username": "user", "one_auth": oneAuth},
+			err:  "doesn't match the user",
+		},
+		{
+			name: "missing one_auth file",
+			raw:  map[string]interface{}{"one_auth": filepath.Join(dir, "missing")},
+			err:  "failed to read the one_auth file",
+		},
+		{
+			name: "token without username",
+			raw:  map[string]interface{}{"token": "tok"},
+			err:  "username should be defined with token",
+		},
+		{
+			name: "x509 without key",
+			raw:  map[string]interface{}{"username": "user", "x509_certificate": certPath},
+			err:  "x509_private_key should be defined",
+		},
+	}
+
+	for _, c := range cases {
+		d := schema.TestResourceDataRaw(t, Provider().Schema, c.raw)
+
+		user, secret, err := providerCredentials(d)
+		if c.err != "" {
+			if err == nil || !strings.Contains(err.Error(), c.err) {
+				t.Errorf("%s: expected error %q, got %v", c.name, c.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: err: %s", c.name, err)
+			continue
+		}
+		if user != c.user {
+			t.Errorf("%s: expected user %s, got %s", c.name, c.user, user)
+		}
+
+		switch c.secret {
+		case "ssh":
+			// a SSH token decrypts to <user>:<expiration>
+			if strings.Count(testRSAPublicDecrypt(t, &key.PublicKey, secret), ":") != 1 {
+				t.Errorf("%s: expected a SSH login token", c.name)
+			}
+		case "x509":
+			// a x509 token is base64 encoded with the certificate
+			decoded, err := base64.StdEncoding.DecodeString(secret)
+			if err != nil || !strings.Contains(string(decoded), "-----BEGIN CERTIFICATE-----") {
+				t.Errorf("%s: expected a x509 login token", c.name)
+			}
+		default:
+			if secret != c.secret {
+				t.Errorf("%s: expected secret %s, got %s", c.name, c.secret, secret)
+			}
+		}
+	}
+}
@@ -0,0 +1,371 @@
+package opennebula
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	dyn "github.com/OpenNebula/one/src/oca/go/src/goca/dynamic"
+	"github.com/OpenNebula/one/src/oca/go/src/goca/schemas/shared"
+)
+
+// pluralItem is an object retrieved by a plural data source
+type pluralItem struct {
+	id      int
+	name    string
+	regTime int
+	attrs   map[string]interface{}
+}
+
+// pluralFilter holds the filtering criterias of a plural data source
+type pluralFilter struct {
+	nameRegex   *regexp.Regexp
+	tags        map[string]interface{}
+	defaultTags map[string]interface{}
+	uid         int
+	uidOk       bool
+	gid         int
+	gidOk       bool
+	permissions string
+}
+
+// pluralFiltersSchema returns the filtering attributes, owned is set for the
+// objects having an owner and permissions
+func pluralFiltersSchema(owned bool) map[string]*schema.Schema {
+
+	filters := map[string]*schema.Schema{
+		"name_regex": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			Description:  "Regular expression matching the name",
+			ValidateFunc: validateRegexp,
+		},
+		"tags": tagsSchema(),
+	}
+
+	if !owned {
+		return filters
+	}
+
+	filters["uid"] = &schema.Schema{
+		Type:        schema.TypeInt,
+		Optional:    true,
+		Description: "ID of the owner",
+	}
+	filters["gid"] = &schema.Schema{
+		Type:        schema.TypeInt,
+		Optional:    true,
+		Description: "ID of the group",
+	}
+	filters["permissions"] = &schema.Schema{
+		Type:        schema.TypeString,
+		Optional:    true,
+		Description: "Permissions in Unix format, owner-group-other, use-manage-admin",
+	}
+
+	return filters
+}
+
+// pluralSortSchema returns the ordering attributes, regTime is set for the objects having a registration time
+func pluralSortSchema(regTime bool) map[string]*schema.Schema {
+
+	sortKeys := []string{"id", "name"}
+	if regTime {
+		sortKeys = append(sortKeys, "regtime")
+	}
+
+	return map[string]*schema.Schema{
+		"sort_by": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Default:     "id",
+			Description: fmt.Sprintf("Attribute used to sort the results: %s", strings.Join(sortKeys, ", ")),
+			ValidateFunc: func(v interface{}, k string) (ws []string, errors []error) {
+				value := v.(string)
+
+				if inArray(value, sortKeys) < 0 {
+					errors = append(errors, fmt.Errorf("%q must be one of: %s", k, strings.Join(sortKeys, ",")))
+				}
+
+				return
+			},
+		},
+		"sort_order": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Default:     "ASC",
+			Description: "Order of the results: ASC or DESC",
+			ValidateFunc: func(v interface{}, k string) (ws []string, errors []error) {
+				validOrders := []string{"ASC", "DESC"}
+				value := v.(string)
+
+				if inArray(value, validOrders) < 0 {
+					errors = append(errors, fmt.Errorf("%q must be one of: %s", k, strings.Join(validOrders, ",")))
+				}
+
+				return
+			},
+		},
+		"most_recent": {
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     false,
+			Description: "Only keep the most recent result",
+		},
+		"ids": {
+			Type:        schema.TypeList,
+			Computed:    true,
+			Description: "Ordered list of the IDs of the results",
+			Elem: &schema.Schema{
+				Type: schema.TypeInt,
+			},
+		},
+	}
+}
+
+// pluralOwnerAttributes returns the ownership attributes of the results
+func pluralOwnerAttributes() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"uid": {
+			Type:        schema.TypeInt,
+			Computed:    true,
+			Description: "ID of the owner",
+		},
+		"gid": {
+			Type:        schema.TypeInt,
+			Computed:    true,
+			Description: "ID of the group",
+		},
+		"uname": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "Name of the owner",
+		},
+		"gname": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "Name of the group",
+		},
+		"permissions": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "Permissions in Unix format, owner-group-other, use-manage-admin",
+		},
+	}
+}
+
+// pluralCommonAttributes returns the attributes shared by all the results
+func pluralCommonAttributes() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"id": {
+			Type:        schema.TypeInt,
+			Computed:    true,
+			Description: "ID of the object",
+		},
+		"name": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "Name of the object",
+		},
+		"tags": {
+			Type:        schema.TypeMap,
+			Computed:    true,
+			Description: "Tags of the object, only the keys of the tags filter and of the provider default tags are read",
+			Elem: &schema.Schema{
+				Type: schema.TypeString,
+			},
+		},
+	}
+}
+
+func validateRegexp(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+
+	_, err := regexp.Compile(value)
+	if err != nil {
+		errors = append(errors, fmt.Errorf("%q must be a valid regular expression: %s", k, err))
+	}
+
+	return
+}
+
+func newPluralFilter(d *schema.ResourceData, meta interface{}) *pluralFilter {
+
+	f := &pluralFilter{
+		defaultTags: configDefaultTags(meta),
+	}
+
+	if nameRegex, ok := d.GetOk("name_regex"); ok {
+		f.nameRegex = regexp.MustCompile(nameRegex.(string))
+	}
+
+	if tagsInterface, ok := d.GetOk("tags"); ok {
+		f.tags = tagsInterface.(map[string]interface{})
+	}
+
+	if uid, ok := d.GetOkExists("uid"); ok {
+		f.uid = uid.(int)
+		f.uidOk = true
+	}
+
+	if gid, ok := d.GetOkExists("gid"); ok {
+		f.gid = gid.(int)
+		f.gidOk = true
+	}
+
+	if permissions, ok := d.GetOk("permissions"); ok {
+		f.permissions = permissions.(string)
+	}
+
+	return f
+}
+
+func (f *pluralFilter) match(name string, tpl dyn.Template) bool {
+
+	if f.nameRegex != nil && !f.nameRegex.MatchString(name) {
+		return false
+	}
+
+	if len(f.tags) > 0 && !matchTags(tpl, f.tags) {
+		return false
+	}
+
+	return true
+}
+
+// itemTags returns the tags of an item. As in the resources, only the keys of
+// the tags filter and of the provider default tags are read, the other pairs of
+// the template aren't tags.
+func (f *pluralFilter) itemTags(tpl dyn.Template) map[string]interface{} {
+
+	keys := make(map[string]string)
+	for _, tags := range []map[string]interface{}{f.defaultTags, f.tags} {
+		for k := range tags {
+			keys[strings.ToUpper(k)] = k
+		}
+	}
+
+	tags := make(map[string]interface{})
+	for upperKey, k := range keys {
+		value, err := tpl.GetStr(upperKey)
+		if err != nil {
+			continue
+		}
+		tags[k] = value
+	}
+
+	return tags
+}
+
+func (f *pluralFilter) matchOwner(uid, gid int, permissions *shared.Permissions) bool {
+
+	if f.uidOk && uid != f.uid {
+		return false
+	}
+
+	if f.gidOk && gid != f.gid {
+		return false
+	}
+
+	if len(f.permissions) > 0 && (permissions == nil || permissionsUnixString(*permissions) != f.permissions) {
+		return false
+	}
+
+	return true
+}
+
+// ownerAttributes returns the values of the attributes defined in pluralOwnerAttributes
+func ownerAttributes(uid, gid int, uname, gname string, permissions *shared.Permissions) map[string]interface{} {
+
+	attrs := map[string]interface{}{
+		"uid":   uid,
+		"gid":   gid,
+		"uname": uname,
+		"gname": gname,
+	}
+
+	if permissions != nil {
+		attrs["permissions"] = permissionsUnixString(*permissions)
+	}
+
+	return attrs
+}
+
+// sortPluralItems orders the results and keep only the most recent one if required,
+// the most recent object is the one with the highest registration time or ID
+func sortPluralItems(d *schema.ResourceData, items []pluralItem) []pluralItem {
+
+	if d.Get("most_recent").(bool) {
+		if len(items) == 0 {
+			return items
+		}
+
+		mostRecent := items[0]
+		for _, item := range items[1:] {
+			if item.regTime > mostRecent.regTime ||
+				(item.regTime == mostRecent.regTime && item.id > mostRecent.id) {
+				mostRecent = item
+			}
+		}
+
+		return []pluralItem{mostRecent}
+	}
+
+	sortBy := d.Get("sort_by").(string)
+	desc := d.Get("sort_order").(string) == "DESC"
+
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if desc {
+			a, b = b, a
+		}
+
+		switch sortBy {
+		case "name":
+			if a.name != b.name {
+				return a.name < b.name
+			}
+		case "regtime":
+			if a.regTime != b.regTime {
+				return a.regTime < b.regTime
+			}
+		}
+
+		return a.id < b.id
+	})
+
+	return items
+}
+
+// setPluralItems sets the ordered results in the list attribute named key
+func setPluralItems(d *schema.ResourceData, key string, items []pluralItem) error {
+
+	items = sortPluralItems(d, items)
+
+	ids := make([]int, 0, len(items))
+	idsStr := make([]string, 0, len(items))
+	list := make([]map[string]interface{}, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.id)
+		idsStr = append(idsStr, strconv.Itoa(item.id))
+
+		attrs := item.attrs
+		attrs["id"] = item.id
+		attrs["name"] = item.name
+		list = append(list, attrs)
+	}
+
+	// the ID depends on the list of results
+	d.SetId(strconv.Itoa(schema.HashString(strings.Join(idsStr, ","))))
+
+	err := d.Set("ids", ids)
+	if err != nil {
+		return err
+	}
+
+	return d.Set(key, list)
+}
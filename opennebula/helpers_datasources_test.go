@@ -0,0 +1,141 @@
+package opennebula
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	dyn "github.com/OpenNebula/one/src/oca/go/src/goca/dynamic"
+	"github.com/OpenNebula/one/src/oca/go/src/goca/schemas/shared"
+)
+
+func testPluralItems() []pluralItem {
+	return []pluralItem{
+		{id: 3, name: "b", regTime: 100},
+		{id: 1, name: "c", regTime: 200},
+		{id: 4, name: "a", regTime: 200},
+		{id: 2, name: "a", regTime: 50},
+	}
+}
+
+func TestSortPluralItems(t *testing.T) {
+
+	cases := []struct {
+		name   string
+		raw    map[string]interface{}
+		expect []int
+	}{
+		{"default", map[string]interface{}{}, []int{1, 2, 3, 4}},
+		{"id DESC", map[string]interface{}{"sort_order": "DESC"}, []int{4, 3, 2, 1}},
+		// equal names are ordered by ID
+		{"name", map[string]interface{}{"sort_by": "name"}, []int{2, 4, 3, 1}},
+		{"name DESC", map[string]interface{}{"sort_by": "name", "sort_order": "DESC"}, []int{1, 3, 4, 2}},
+		// equal registration times are ordered by ID
+		{"regtime", map[string]interface{}{"sort_by": "regtime"}, []int{2, 3, 1, 4}},
+		{"regtime DESC", map[string]interface{}{"sort_by": "regtime", "sort_order": "DESC"}, []int{4, 1, 3, 2}},
+		// the highest ID wins between equal registration times
+		{"most_recent", map[string]interface{}{"most_recent": true}, []int{4}},
+		{"most_recent ignores order", map[string]interface{}{"most_recent": true, "sort_by": "name"}, []int{4}},
+	}
+
+	for _, c := range cases {
+		d := schema.TestResourceDataRaw(t, pluralSortSchema(true), c.raw)
+
+		ids := make([]int, 0)
+		for _, item := range sortPluralItems(d, testPluralItems()) {
+			ids = append(ids, item.id)
+		}
+
+		if !reflect.DeepEqual(ids, c.expect) {
+			t.Errorf("%s: expected IDs %v, got %v", c.name, c.expect, ids)
+		}
+	}
+
+	d := schema.TestResourceDataRaw(t, pluralSortSchema(true), map[string]interface{}{"most_recent": true})
+	if len(sortPluralItems(d, []pluralItem{})) != 0 {
+		t.Errorf("most_recent: expected no result without items")
+	}
+}
+
+func TestPluralFilterMatchOwner(t *testing.T) {
+
+	permissions := permissionUnix("640")
+
+	cases := []struct {
+		name        string
+		filter      pluralFilter
+		uid         int
+		gid         int
+		permissions *shared.Permissions
+		expect      bool
+	}{
+		{"no filter", pluralFilter{}, 1, 1, nil, true},
+		// the user and the group 0 are valid filters
+		{"uid 0", pluralFilter{uid: 0, uidOk: true}, 0, 1, nil, true},
+		{"uid 0 mismatch", pluralFilter{uid: 0, uidOk: true}, 1, 1, nil, false},
+		{"gid", pluralFilter{gid: 1, gidOk: true}, 0, 1, nil, true},
+		{"gid mismatch", pluralFilter{gid: 0, gidOk: true}, 0, 1, nil, false},
+		{"permissions", pluralFilter{permissions: "640"}, 0, 0, &permissions, true},
+		{"permissions mismatch", pluralFilter{permissions: "600"}, 0, 0, &permissions, false},
+		{"permissions unknown", pluralFilter{permissions: "640"}, 0, 0, nil, false},
+	}
+
+	for _, c := range cases {
+		if c.filter.matchOwner(c.uid, c.gid, c.permissions) != c.expect {
+			t.Errorf("%s: expected match %t", c.name, c.expect)
+		}
+	}
+}
+
+func TestPluralFilterMatch(t *testing.T) {
+
+	tpl := dyn.NewTemplate()
+	tpl.AddPair("ENV", "prod")
+
+	cases := []struct {
+		name   string
+		filter pluralFilter
+		expect bool
+	}{
+		{"no filter", pluralFilter{}, true},
+		{"name_regex", pluralFilter{nameRegex: regexp.MustCompile("^web-")}, true},
+		{"name_regex mismatch", pluralFilter{nameRegex: regexp.MustCompile("^db-")}, false},
+		{"tags", pluralFilter{tags: map[string]interface{}{"env": "prod"}}, true},
+		{"tags mismatch", pluralFilter{tags: map[string]interface{}{"env": "dev"}}, false},
+	}
+
+	for _, c := range cases {
+		if c.filter.match("web-1", *tpl) != c.expect {
+			t.Errorf("%s: expected match %t", c.name, c.expect)
+		}
+	}
+}
+
+func TestPluralFilterItemTags(t *testing.T) {
+
+	tpl := dyn.NewTemplate()
+	tpl.AddPair("ENV", "prod")
+	tpl.AddPair("TEAM", "web")
+	tpl.AddPair("DEV_PREFIX", "vd")
+	tpl.AddPair("MD5", "d604a220708aa59433ba410986cd4ffa")
+
+	cases := []struct {
+		name   string
+		filter pluralFilter
+		expect map[string]interface{}
+	}{
+		{"no tags", pluralFilter{}, map[string]interface{}{}},
+		{"tags", pluralFilter{tags: map[string]interface{}{"env": "prod"}}, map[string]interface{}{"env": "prod"}},
+		{"default tags", pluralFilter{defaultTags: map[string]interface{}{"team": "ops"}}, map[string]interface{}{"team": "web"}},
+		{"missing tag", pluralFilter{defaultTags: map[string]interface{}{"owner": "ops"}}, map[string]interface{}{}},
+	}
+
+	for _, c := range cases {
+		tags := c.filter.itemTags(*tpl)
+		if !reflect.DeepEqual(tags, c.expect) {
+			t.Errorf("%s: expected %v, got %v", c.name, c.expect, tags)
+		}
+	}
+}
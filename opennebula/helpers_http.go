@@ -0,0 +1,96 @@
+package opennebula
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// headersTransport adds custom headers to each request
+type headersTransport struct {
+	headers   map[string]string
+	transport http.RoundTripper
+}
+
+func (t *headersTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+
+	// a RoundTripper shouldn't modify the original request
+	req = req.Clone(req.Context())
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+
+	return t.transport.RoundTrip(req)
+}
+
+// providerHTTPClient returns the HTTP client shared by the XML-RPC and Flow clients,
+// configured with the TLS, proxy, timeout and headers provider arguments
+func providerHTTPClient(d *schema.ResourceData) (*http.Client, error) {
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	tlsConfig := &tls.Config{}
+
+	if caFile, ok := d.GetOk("ca_file"); ok {
+		caCerts, err := ioutil.ReadFile(caFile.(string))
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read the CA file: %s", err)
+		}
+
+		rootCAs, err := x509.SystemCertPool()
+		if err != nil || rootCAs == nil {
+			rootCAs = x509.NewCertPool()
+		}
+		if !rootCAs.AppendCertsFromPEM(caCerts) {
+			return nil, fmt.Errorf("no PEM encoded certificate found in the CA file %s", caFile)
+		}
+		tlsConfig.RootCAs = rootCAs
+	}
+
+	certFile, certOk := d.GetOk("client_certificate")
+	keyFile, keyOk := d.GetOk("client_key")
+	if certOk != keyOk {
+		return nil, fmt.Errorf("client_certificate and client_key should be defined together")
+	}
+	if certOk {
+		cert, err := tls.LoadX509KeyPair(certFile.(string), keyFile.(string))
+		if err != nil {
+			return nil, fmt.Errorf("Failed to load the client certificate: %s", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	tlsConfig.InsecureSkipVerify = d.Get("insecure").(bool)
+	transport.TLSClientConfig = tlsConfig
+
+	if proxy, ok := d.GetOk("http_proxy"); ok {
+		proxyURL, err := url.Parse(proxy.(string))
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse the proxy URL: %s", err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	client := &http.Client{
+		Transport: transport,
+		Timeout:   time.Duration(d.Get("timeout").(int)) * time.Second,
+	}
+
+	if headersIf, ok := d.GetOk("headers"); ok {
+		headers := make(map[string]string)
+		for k, v := range headersIf.(map[string]interface{}) {
+			headers[k] = v.(string)
+		}
+		client.Transport = &headersTransport{
+			headers:   headers,
+			transport: transport,
+		}
+	}
+
+	return client, nil
+}
@@ -0,0 +1,204 @@
+package opennebula
+
+import (
+	"crypto/tls"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// testHTTPEnv are the environment variables read by the provider HTTP client
+var testHTTPEnv = []string{
+	"OPENNEBULA_CA_FILE",
+	"OPENNEBULA_INSECURE",
+	"OPENNEBULA_CLIENT_CERTIFICATE",
+	"OPENNEBULA_CLIENT_KEY",
+	"OPENNEBULA_TIMEOUT",
+}
+
+func testProviderHTTPClient(t *testing.T, raw map[string]interface{}) (*http.Client, error) {
+	return providerHTTPClient(schema.TestResourceDataRaw(t, Provider().Schema, raw))
+}
+
+func TestProviderHTTPClientTLS(t *testing.T) {
+
+	defer testUnsetEnv(testHTTPEnv)()
+
+	dir, cleanup := testTempDir(t)
+	defer cleanup()
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	caFile := testWriteFile(t, dir, "ca.pem", string(caPEM))
+
+	cases := []struct {
+		name string
+		raw  map[string]interface{}
+		ok   bool
+	}{
+		{"unknown authority", map[string]interface{}{}, false},
+		{"ca_file", map[string]interface{}{"ca_file": caFile}, true},
+		{"insecure", map[string]interface{}{"insecure": true}, true},
+	}
+
+	for _, c := range cases {
+		client, err := testProviderHTTPClient(t, c.raw)
+		if err != nil {
+			t.Fatalf("%s: err: %s", c.name, err)
+		}
+
+		resp, err := client.Get(server.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		if (err == nil) != c.ok {
+			t.Errorf("%s: expected success %t, got %v", c.name, c.ok, err)
+		}
+	}
+}
+
+func TestProviderHTTPClientErrors(t *testing.T) {
+
+	defer testUnsetEnv(testHTTPEnv)()
+
+	dir, cleanup := testTempDir(t)
+	defer cleanup()
+
+	key, keyPath := testRSAKey(t, dir)
+	_, certPath := testCertificate(t, dir, key)
+	invalid := testWriteFile(t, dir, "invalid.pem", "invalid")
+
+	cases := []struct {
+		name string
+		raw  map[string]interface{}
+		err  string
+	}{
+		{"missing ca_file", map[string]interface{}{"ca_file": filepath.Join(dir, "missing")}, "Failed to read the CA file"},
+		{"invalid ca_file", map[string]interface{}{"ca_file": invalid}, "no PEM encoded certificate found"},
+		{"client_certificate only", map[string]interface{}{"client_certificate": certPath}, "should be defined together"},
+		{"client_key only", map[string]interface{}{"client_key": keyPath}, "should be defined together"},
+		{"invalid client_key", map[string]interface{}{"client_certificate": certPath, "client_key": invalid}, "Failed to load the client certificate"},
+		{"invalid http_proxy", map[string]interface{}{"http_proxy": "://proxy"}, "Failed to parse the proxy URL"},
+	}
+
+	for _, c := range cases {
+		_, err := testProviderHTTPClient(t, c.raw)
+		if err == nil || !strings.Contains(err.Error(), c.err) {
+			t.Errorf("%s: expected error %q, got %v", c.name, c.err, err)
+		}
+	}
+}
+
+func TestProviderHTTPClientCertificate(t *testing.T) {
+
+	defer testUnsetEnv(testHTTPEnv)()
+
+	dir, cleanup := testTempDir(t)
+	defer cleanup()
+
+	key, keyPath := testRSAKey(t, dir)
+	_, certPath := testCertificate(t, dir, key)
+
+	var peerCerts int
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		peerCerts = len(r.TLS.PeerCertificates)
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	client, err := testProviderHTTPClient(t, map[string]interface{}{
+		"insecure":           true,
+		"client_certificate": certPath,
+		"client_key":         keyPath,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	resp.Body.Close()
+
+	if peerCerts != 1 {
+		t.Errorf("expected the client certificate to be sent, got %d certificates", peerCerts)
+	}
+}
+
+func TestProviderHTTPClientProxyAndTimeout(t *testing.T) {
+
+	defer testUnsetEnv(testHTTPEnv)()
+
+	client, err := testProviderHTTPClient(t, map[string]interface{}{
+		"http_proxy": "http://proxy.example.com:3128",
+		"timeout":    5,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if client.Timeout != 5*time.Second {
+		t.Errorf("expected timeout 5s, got %s", client.Timeout)
+	}
+
+	transport, ok := client.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("expected a *http.Transport, got %T", client.Transport)
+	}
+
+	req, _ := http.NewRequest("POST", "https://opennebula.example.com:2633/RPC2", nil)
+	proxyURL, err := transport.Proxy(req)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if proxyURL == nil || proxyURL.String() != "http://proxy.example.com:3128" {
+		t.Errorf("expected proxy http://proxy.example.com:3128, got %v", proxyURL)
+	}
+}
+
+func TestProviderHTTPClientHeaders(t *testing.T) {
+
+	defer testUnsetEnv(testHTTPEnv)()
+
+	var received http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header
+	}))
+	defer server.Close()
+
+	client, err := testProviderHTTPClient(t, map[string]interface{}{
+		"headers": map[string]interface{}{
+			"X-Custom":      "custom",
+			"Authorization": "Bearer secret",
+		},
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	req, _ := http.NewRequest("GET", server.URL, nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	resp.Body.Close()
+
+	if received.Get("X-Custom") != "custom" || received.Get("Authorization") != "Bearer secret" {
+		t.Errorf("expected the custom headers to be sent, got %v", received)
+	}
+
+	// the original request isn't modified
+	if len(req.Header) != 0 {
+		t.Errorf("expected the request headers to be unchanged, got %v", req.Header)
+	}
+}
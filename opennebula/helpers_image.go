@@ -0,0 +1,173 @@
+package opennebula
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// imageSourceServer serves the content of an image from the provider host
+// over HTTP, so that the OpenNebula frontend can download it as a regular URL
+type imageSourceServer struct {
+	listener net.Listener
+	server   *http.Server
+	file     *os.File
+	URL      string
+}
+
+// imageSource returns a reader on the local content of the image, the file to
+// close once served, and the name used to serve it.
+// The reader is nil when neither content nor source_file are defined.
+func imageSource(d *schema.ResourceData) (io.ReadSeeker, *os.File, string, error) {
+
+	if content, ok := d.GetOk("content"); ok {
+		return bytes.NewReader([]byte(content.(string))), nil, "content", nil
+	}
+
+	if sourceFile, ok := d.GetOk("source_file"); ok {
+		f, err := os.Open(sourceFile.(string))
+		if err != nil {
+			return nil, nil, "", err
+		}
+		return f, f, filepath.Base(sourceFile.(string)), nil
+	}
+
+	return nil, nil, "", nil
+}
+
+// imageSourceChecksum computes the SHA256 checksum of a local content or file
+func imageSourceChecksum(content string, sourceFile string) (string, error) {
+	h := sha256.New()
+
+	if len(sourceFile) > 0 {
+		f, err := os.Open(sourceFile)
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+
+		_, err = io.Copy(h, f)
+		if err != nil {
+			return "", err
+		}
+	} else {
+		h.Write([]byte(content))
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// imageSourceAddress returns the local IP address used to reach the OpenNebula
+// endpoint. It's the address the frontend is the most likely able to reach back.
+func imageSourceAddress(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "80")
+	}
+
+	// UDP dial doesn't send anything, it only resolves the local address
+	conn, err := net.Dial("udp", host)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	return conn.LocalAddr().(*net.UDPAddr).IP.String(), nil
+}
+
+// newImageSourceServer starts to serve the image content on address.
+// When address has no host, the local address used to reach the endpoint is
+// used, when it has no port, a random one is picked.
+func newImageSourceServer(d *schema.ResourceData, address, endpoint string) (*imageSourceServer, error) {
+
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		host = address
+		port = "0"
+	}
+	if len(host) == 0 {
+		host, err = imageSourceAddress(endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("can't guess the address to serve the image content: %s", err)
+		}
+	}
+
+	content, file, name, err := imageSource(d)
+	if err != nil {
+		return nil, err
+	}
+	if content == nil {
+		return nil, fmt.Errorf("no local content to serve")
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(host, port))
+	if err != nil {
+		if file != nil {
+			file.Close()
+		}
+		return nil, err
+	}
+
+	// a random token avoids serving the content to anyone guessing the URL
+	token := make([]byte, 16)
+	_, err = rand.Read(token)
+	if err != nil {
+		listener.Close()
+		if file != nil {
+			file.Close()
+		}
+		return nil, err
+	}
+	urlPath := fmt.Sprintf("/%s/%s", hex.EncodeToString(token), url.PathEscape(name))
+
+	// the content reader is shared between the requests
+	var mutex sync.Mutex
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(urlPath, func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		log.Printf("[DEBUG] Serving image content to %s", r.RemoteAddr)
+		http.ServeContent(w, r, name, time.Time{}, content)
+	})
+
+	s := &imageSourceServer{
+		listener: listener,
+		server:   &http.Server{Handler: mux},
+		file:     file,
+		URL:      fmt.Sprintf("http://%s%s", listener.Addr().String(), urlPath),
+	}
+
+	go s.server.Serve(listener)
+
+	log.Printf("[INFO] Serving image content at %s", s.URL)
+
+	return s, nil
+}
+
+// Close stops serving the image content
+func (s *imageSourceServer) Close() {
+	s.server.Close()
+	if s.file != nil {
+		s.file.Close()
+	}
+}
@@ -0,0 +1,342 @@
+package opennebula
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/OpenNebula/one/src/oca/go/src/goca"
+	errs "github.com/OpenNebula/one/src/oca/go/src/goca/errors"
+)
+
+// readOnlyMethods are the last part of the names of the XML-RPC methods that
+// don't modify anything, i.e. one.vm.info or one.vmpool.infoextended
+var readOnlyMethods = map[string]bool{
+	"info":         true,
+	"infoextended": true,
+	"infoset":      true,
+	"monitoring":   true,
+	"accounting":   true,
+	"showback":     true,
+	"version":      true,
+	"config":       true,
+}
+
+// retryConfig defines how many times and how fast a request is retried
+type retryConfig struct {
+	maxRetries int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+}
+
+// retryError is returned when a request still fails after all the retries
+type retryError struct {
+	retries int
+	err     error
+}
+
+func (e *retryError) Error() string {
+	return fmt.Sprintf("%s (after %d retries)", e.err, e.retries)
+}
+
+func (e *retryError) Unwrap() error {
+	return e.err
+}
+
+// retryStatusError is returned for the HTTP responses worth retrying, the
+// last response being returned as is once the retries are exhausted
+type retryStatusError struct {
+	statusCode int
+}
+
+func (e *retryStatusError) Error() string {
+	return fmt.Sprintf("HTTP status %d", e.statusCode)
+}
+
+// attemptTimeoutError is returned when an attempt of a Flow request exceeds the
+// provider timeout, unlike the cancellation of the request it may be retried
+type attemptTimeoutError struct {
+	err error
+}
+
+func (e *attemptTimeoutError) Error() string {
+	return fmt.Sprintf("request timeout: %s", e.err)
+}
+
+func (e *attemptTimeoutError) Timeout() bool {
+	return true
+}
+
+func (e *attemptTimeoutError) Temporary() bool {
+	return true
+}
+
+func providerRetryConfig(d *schema.ResourceData) retryConfig {
+	return retryConfig{
+		maxRetries: d.Get("max_retries").(int),
+		minBackoff: time.Duration(d.Get("retry_min_backoff").(int)) * time.Second,
+		maxBackoff: time.Duration(d.Get("retry_max_backoff").(int)) * time.Second,
+	}
+}
+
+// backoff returns the delay before the retry, doubled at each attempt
+func (c retryConfig) backoff(attempt int) time.Duration {
+
+	delay := c.minBackoff
+	for i := 0; i < attempt && delay < c.maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > c.maxBackoff {
+		delay = c.maxBackoff
+	}
+
+	return delay
+}
+
+// do calls f until it succeeds, the error isn't retryable, the retries are
+// exhausted or ctx is done
+func (c retryConfig) do(ctx context.Context, name string, retryable func(error) bool, f func() error) error {
+
+	var err error
+	for attempt := 0; ; attempt++ {
+		err = f()
+		if err == nil || !retryable(err) {
+			return err
+		}
+		if attempt >= c.maxRetries {
+			break
+		}
+
+		delay := c.backoff(attempt)
+		log.Printf("[WARN] %s failed, retry %d/%d in %s: %s", name, attempt+1, c.maxRetries, delay, err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			log.Printf("[WARN] %s retries interrupted: %s", name, ctx.Err())
+			return err
+		case <-timer.C:
+		}
+	}
+
+	if c.maxRetries == 0 {
+		return err
+	}
+
+	return &retryError{retries: c.maxRetries, err: err}
+}
+
+// isReadOnlyMethod returns true when the XML-RPC method can be safely sent several times
+func isReadOnlyMethod(method string) bool {
+	return readOnlyMethods[method[strings.LastIndex(method, ".")+1:]]
+}
+
+// isDialError returns true when the connection to the endpoint failed, so the
+// request wasn't received and may be sent again whatever the method
+func isDialError(err error) bool {
+
+	var opErr *net.OpError
+	if clientErr, ok := err.(*errs.ClientError); ok {
+		err = clientErr.Err
+	}
+
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// isNetworkError returns true when the connection failed or was closed, i.e.
+// when it is reset while oned is busy, or timed out. The other transport errors,
+// like the TLS ones, won't succeed when retried.
+func isNetworkError(err error) bool {
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return opErr.Op == "dial" || opErr.Op == "read" || opErr.Op == "write"
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// isTransientError returns true for the errors of a read that may succeed if
+// retried: network failures, timeouts and server side HTTP errors
+func isTransientError(err error) bool {
+
+	clientErr, ok := err.(*errs.ClientError)
+	if !ok {
+		return false
+	}
+
+	switch clientErr.Code {
+	case errs.ClientReqHTTP:
+		return isNetworkError(clientErr.Err)
+	case errs.ClientRespHTTP:
+		resp := clientErr.GetHTTPResponse()
+		return resp == nil ||
+			resp.StatusCode >= 500 ||
+			resp.StatusCode == http.StatusTooManyRequests
+	}
+
+	return false
+}
+
+// isTransientHTTPError returns true for the errors of a Flow read that may
+// succeed if retried: network failures and server side HTTP errors
+func isTransientHTTPError(err error) bool {
+
+	var statusErr *retryStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.statusCode >= 500 || statusErr.statusCode == http.StatusTooManyRequests
+	}
+
+	return isNetworkError(err)
+}
+
+// isRejectedHTTPError returns true when the connection to the endpoint failed
+// or the request was rejected before being processed
+func isRejectedHTTPError(err error) bool {
+
+	var statusErr *retryStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.statusCode == http.StatusTooManyRequests
+	}
+
+	return isDialError(err)
+}
+
+// retryCaller retries the transient failures of the XML-RPC requests
+type retryCaller struct {
+	caller goca.RPCCaller
+	config retryConfig
+	// ctx interrupts the retries when Terraform is stopped
+	ctx context.Context
+}
+
+func (c *retryCaller) Call(method string, args ...interface{}) (*goca.Response, error) {
+
+	retryable := isDialError
+	if isReadOnlyMethod(method) {
+		retryable = isTransientError
+	}
+
+	var response *goca.Response
+	err := c.config.do(c.ctx, method, retryable, func() error {
+		var err error
+		response, err = c.caller.Call(method, args...)
+		return err
+	})
+
+	return response, err
+}
+
+// retryTransport retries the transient failures of the Flow requests. The Flow
+// client returns the HTTP errors as successful responses, so the retries are
+// done at the HTTP level to check the status of the responses.
+type retryTransport struct {
+	transport http.RoundTripper
+	config    retryConfig
+	// timeout of each attempt, reading the response body included
+	timeout time.Duration
+	// ctx interrupts the retries when Terraform is stopped
+	ctx context.Context
+}
+
+// cancelBody cancels the context of an attempt once its response body is closed
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+
+	// the body of the request can't be sent again
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return t.transport.RoundTrip(req)
+	}
+
+	retryable := isRejectedHTTPError
+	if req.Method == "GET" {
+		retryable = isTransientHTTPError
+	}
+
+	var resp *http.Response
+	attempt := 0
+	err := t.config.do(t.ctx, fmt.Sprintf("%s %s", req.Method, req.URL), retryable, func() error {
+
+		attemptReq := req
+		if attempt > 0 {
+			// discard the response of the previous attempt
+			if resp != nil {
+				io.Copy(ioutil.Discard, resp.Body)
+				resp.Body.Close()
+				resp = nil
+			}
+
+			// the body of the request has been consumed by the previous attempt
+			if req.Body != nil && req.Body != http.NoBody {
+				body, err := req.GetBody()
+				if err != nil {
+					return err
+				}
+				attemptReq = req.Clone(req.Context())
+				attemptReq.Body = body
+			}
+		}
+		attempt++
+
+		cancel := func() {}
+		if t.timeout > 0 {
+			var ctx context.Context
+			ctx, cancel = context.WithTimeout(req.Context(), t.timeout)
+			attemptReq = attemptReq.WithContext(ctx)
+		}
+
+		var err error
+		resp, err = t.transport.RoundTrip(attemptReq)
+		if err != nil {
+			cancel()
+			if attemptReq.Context().Err() == context.DeadlineExceeded && req.Context().Err() == nil {
+				return &attemptTimeoutError{err: err}
+			}
+			return err
+		}
+		resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+
+		if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+			return &retryStatusError{statusCode: resp.StatusCode}
+		}
+
+		return nil
+	})
+
+	// the last response is returned to be handled by the Flow client
+	var statusErr *retryStatusError
+	if errors.As(err, &statusErr) && resp != nil {
+		return resp, nil
+	}
+
+	return resp, err
+}
@@ -0,0 +1,333 @@
+package opennebula
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	errs "github.com/OpenNebula/one/src/oca/go/src/goca/errors"
+)
+
+var testRetryConfig = retryConfig{
+	maxRetries: 2,
+	minBackoff: time.Millisecond,
+	maxBackoff: 2 * time.Millisecond,
+}
+
+func TestRetryBackoff(t *testing.T) {
+
+	config := retryConfig{
+		maxRetries: 10,
+		minBackoff: time.Second,
+		maxBackoff: 5 * time.Second,
+	}
+
+	cases := []struct {
+		attempt int
+		delay   time.Duration
+	}{
+		{0, time.Second},
+		{1, 2 * time.Second},
+		{2, 4 * time.Second},
+		{3, 5 * time.Second},
+		{10, 5 * time.Second},
+	}
+
+	for _, c := range cases {
+		if delay := config.backoff(c.attempt); delay != c.delay {
+			t.Errorf("attempt %d: expected %s, got %s", c.attempt, c.delay, delay)
+		}
+	}
+}
+
+func TestRetryDo(t *testing.T) {
+
+	transient := errors.New("transient")
+	permanent := errors.New("permanent")
+
+	cases := []struct {
+		name     string
+		errs     []error
+		calls    int
+		retryErr bool
+	}{
+		{"success", []error{nil}, 1, false},
+		{"retried", []error{transient, transient, nil}, 3, false},
+		{"not retryable", []error{permanent}, 1, false},
+		{"exhausted", []error{transient, transient, transient}, 3, true},
+	}
+
+	for _, c := range cases {
+		calls := 0
+		err := testRetryConfig.do(context.Background(), c.name, func(err error) bool {
+			return err == transient
+		}, func() error {
+			err := c.errs[calls]
+			calls++
+			return err
+		})
+
+		if calls != c.calls {
+			t.Errorf("%s: expected %d calls, got %d", c.name, c.calls, calls)
+		}
+
+		var retryErr *retryError
+		if errors.As(err, &retryErr) != c.retryErr {
+			t.Errorf("%s: unexpected error: %v", c.name, err)
+		}
+
+		if err != nil && errors.Unwrap(err) != c.errs[calls-1] && err != c.errs[calls-1] {
+			t.Errorf("%s: expected %v, got %v", c.name, c.errs[calls-1], err)
+		}
+	}
+}
+
+func TestRetryDoInterrupted(t *testing.T) {
+
+	config := retryConfig{
+		maxRetries: 5,
+		minBackoff: time.Hour,
+		maxBackoff: time.Hour,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	transient := errors.New("transient")
+	calls := 0
+	err := config.do(ctx, "interrupted", func(error) bool { return true }, func() error {
+		calls++
+		return transient
+	})
+
+	if calls != 1 {
+		t.Errorf("expected 1 call, got %d", calls)
+	}
+	if err != transient {
+		t.Errorf("expected %v, got %v", transient, err)
+	}
+}
+
+func TestIsReadOnlyMethod(t *testing.T) {
+
+	cases := []struct {
+		method   string
+		readOnly bool
+	}{
+		{"one.vm.info", true},
+		{"one.vmpool.infoextended", true},
+		{"one.system.version", true},
+		{"one.vm.action", false},
+		{"one.template.instantiate", false},
+	}
+
+	for _, c := range cases {
+		if isReadOnlyMethod(c.method) != c.readOnly {
+			t.Errorf("%s: expected %t", c.method, c.readOnly)
+		}
+	}
+}
+
+func TestIsDialError(t *testing.T) {
+
+	dialErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	readErr := &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")}
+
+	cases := []struct {
+		name string
+		err  error
+		dial bool
+	}{
+		{"dial", dialErr, true},
+		{"read", readErr, false},
+		{"client dial", &errs.ClientError{Code: errs.ClientReqHTTP, Err: dialErr}, true},
+		{"client read", &errs.ClientError{Code: errs.ClientReqHTTP, Err: readErr}, false},
+		{"plain", errors.New("plain"), false},
+	}
+
+	for _, c := range cases {
+		if isDialError(c.err) != c.dial {
+			t.Errorf("%s: expected %t", c.name, c.dial)
+		}
+	}
+}
+
+func TestIsTransientError(t *testing.T) {
+
+	readErr := &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")}
+	tlsErr := errors.New("x509: certificate signed by unknown authority")
+
+	cases := []struct {
+		name      string
+		err       error
+		transient bool
+	}{
+		{"request reset", &errs.ClientError{Code: errs.ClientReqHTTP, Err: readErr}, true},
+		{"request EOF", &errs.ClientError{Code: errs.ClientReqHTTP, Err: io.EOF}, true},
+		{"request TLS", &errs.ClientError{Code: errs.ClientReqHTTP, Err: tlsErr}, false},
+		{"response body", &errs.ClientError{Code: errs.ClientRespHTTP}, true},
+		{"response 500", &errs.ClientError{Code: errs.ClientRespHTTP, HttpResp: &http.Response{StatusCode: 500}}, true},
+		{"response 429", &errs.ClientError{Code: errs.ClientRespHTTP, HttpResp: &http.Response{StatusCode: 429}}, true},
+		{"response 400", &errs.ClientError{Code: errs.ClientRespHTTP, HttpResp: &http.Response{StatusCode: 400}}, false},
+		{"xmlrpc fault", &errs.ClientError{Code: errs.ClientRespXMLRPCFault}, false},
+		{"opennebula", &errs.ResponseError{Code: errs.OneNoExistsError}, false},
+		{"plain", errors.New("plain"), false},
+	}
+
+	for _, c := range cases {
+		if isTransientError(c.err) != c.transient {
+			t.Errorf("%s: expected %t", c.name, c.transient)
+		}
+	}
+}
+
+func TestIsTransientHTTPError(t *testing.T) {
+
+	cases := []struct {
+		name      string
+		err       error
+		transient bool
+		rejected  bool
+	}{
+		{"503", &retryStatusError{statusCode: 503}, true, false},
+		{"429", &retryStatusError{statusCode: 429}, true, true},
+		{"dial", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, true, true},
+		{"reset", &net.OpError{Op: "read", Err: errors.New("connection reset by peer")}, true, false},
+		{"EOF", io.ErrUnexpectedEOF, true, false},
+		{"canceled", context.Canceled, false, false},
+		{"TLS", errors.New("x509: certificate signed by unknown authority"), false, false},
+		{"timeout", &attemptTimeoutError{err: context.DeadlineExceeded}, true, false},
+	}
+
+	for _, c := range cases {
+		if isTransientHTTPError(c.err) != c.transient {
+			t.Errorf("%s: expected transient %t", c.name, c.transient)
+		}
+		if isRejectedHTTPError(c.err) != c.rejected {
+			t.Errorf("%s: expected rejected %t", c.name, c.rejected)
+		}
+	}
+}
+
+func TestRetryTransport(t *testing.T) {
+
+	cases := []struct {
+		name     string
+		method   string
+		statuses []int
+		calls    int
+		status   int
+	}{
+		{"GET retried", "GET", []int{503, 200}, 2, 200},
+		{"GET exhausted", "GET", []int{503, 503, 503}, 3, 503},
+		{"GET not found", "GET", []int{404}, 1, 404},
+		{"POST not retried", "POST", []int{500}, 1, 500},
+		{"POST throttled", "POST", []int{429, 201}, 2, 201},
+	}
+
+	for _, c := range cases {
+		var mutex sync.Mutex
+		var bodies []string
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			body, _ := ioutil.ReadAll(r.Body)
+			bodies = append(bodies, string(body))
+			w.WriteHeader(c.statuses[len(bodies)-1])
+		}))
+
+		client := &http.Client{
+			Transport: &retryTransport{
+				transport: http.DefaultTransport,
+				config:    testRetryConfig,
+				ctx:       context.Background(),
+			},
+		}
+
+		var body io.Reader
+		if c.method == "POST" {
+			body = strings.NewReader("body")
+		}
+		req, err := http.NewRequest(c.method, server.URL, body)
+		if err != nil {
+			t.Fatalf("%s: err: %s", c.name, err)
+		}
+
+		resp, err := client.Do(req)
+		server.Close()
+		if err != nil {
+			t.Errorf("%s: err: %s", c.name, err)
+			continue
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != c.status {
+			t.Errorf("%s: expected status %d, got %d", c.name, c.status, resp.StatusCode)
+		}
+		if len(bodies) != c.calls {
+			t.Errorf("%s: expected %d calls, got %d", c.name, c.calls, len(bodies))
+		}
+		for _, b := range bodies {
+			if c.method == "POST" && b != "body" {
+				t.Errorf("%s: expected the body to be sent, got %q", c.name, b)
+			}
+		}
+	}
+}
+
+func TestRetryTransportTimeout(t *testing.T) {
+
+	var mutex sync.Mutex
+	calls := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		calls++
+		first := calls == 1
+		mutex.Unlock()
+
+		// only the first attempt exceeds the timeout
+		if first {
+			time.Sleep(200 * time.Millisecond)
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	client := &http.Client{
+		Transport: &retryTransport{
+			transport: http.DefaultTransport,
+			config:    testRetryConfig,
+			timeout:   50 * time.Millisecond,
+			ctx:       context.Background(),
+		},
+	}
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer resp.Body.Close()
+
+	// the body is read after the attempt returned
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil || string(body) != "ok" {
+		t.Errorf("expected the body to be read, got %q: %v", body, err)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if calls != 2 {
+		t.Errorf("expected 2 calls, got %d", calls)
+	}
+}
@@ -0,0 +1,240 @@
+package opennebula
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// securityGroupRuleFields lists the optional attributes of a rule
+var securityGroupRuleFields = []string{"ip", "size", "range", "icmp_type", "network_id"}
+
+type portRange struct {
+	first, last int
+}
+
+// parseSecurityGroupRange parses a comma separated list of ports and port ranges i.e. "22,80:90"
+func parseSecurityGroupRange(value string) ([]portRange, error) {
+
+	items := strings.Split(value, ",")
+	ranges := make([]portRange, 0, len(items))
+
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		bounds := strings.Split(item, ":")
+		if len(bounds) > 2 {
+			return nil, fmt.Errorf("invalid port range %q, expected format: <port> or <first_port>:<last_port>", item)
+		}
+
+		ports := make([]int, 0, 2)
+		for _, bound := range bounds {
+			port, err := strconv.Atoi(bound)
+			if err != nil || port < 0 || port > 65535 {
+				return nil, fmt.Errorf("invalid port %q in %q, must be an integer between 0 and 65535", bound, item)
+			}
+			ports = append(ports, port)
+		}
+
+		r := portRange{first: ports[0], last: ports[len(ports)-1]}
+		if r.first > r.last {
+			return nil, fmt.Errorf("invalid port range %q, the first port must be lower than the last one", item)
+		}
+
+		ranges = append(ranges, r)
+	}
+
+	return ranges, nil
+}
+
+// normalizeSecurityGroupRange sorts and deduplicates the ports and port ranges
+func normalizeSecurityGroupRange(value string) string {
+
+	ranges, err := parseSecurityGroupRange(value)
+	if err != nil {
+		return value
+	}
+
+	sort.Slice(ranges, func(i, j int) bool {
+		if ranges[i].first == ranges[j].first {
+			return ranges[i].last < ranges[j].last
+		}
+		return ranges[i].first < ranges[j].first
+	})
+
+	items := make([]string, 0, len(ranges))
+	for i, r := range ranges {
+		if i > 0 && r == ranges[i-1] {
+			continue
+		}
+		if r.first == r.last {
+			items = append(items, strconv.Itoa(r.first))
+		} else {
+			items = append(items, fmt.Sprintf("%d:%d", r.first, r.last))
+		}
+	}
+
+	return strings.Join(items, ",")
+}
+
+// suppressSecurityGroupRangeDiff ignores equivalent spellings of a port ranges list, i.e. "22,80" and "80,22"
+func suppressSecurityGroupRangeDiff(k, old, new string, d *schema.ResourceData) bool {
+	if len(old) == 0 || len(new) == 0 {
+		return false
+	}
+	return normalizeSecurityGroupRange(old) == normalizeSecurityGroupRange(new)
+}
+
+func validateSecurityGroupRange(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+
+	_, err := parseSecurityGroupRange(value)
+	if err != nil {
+		errors = append(errors, fmt.Errorf("%q: %s", k, err))
+	}
+
+	return
+}
+
+func validateSecurityGroupIP(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+
+	if net.ParseIP(value) == nil {
+		errors = append(errors, fmt.Errorf("%q must be a valid IP address, got: %s", k, value))
+	}
+
+	return
+}
+
+func validateSecurityGroupSize(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+
+	size, err := strconv.Atoi(value)
+	if err != nil || size < 1 {
+		errors = append(errors, fmt.Errorf("%q must be a positive integer, got: %s", k, value))
+	}
+
+	return
+}
+
+func validateSecurityGroupICMPType(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+
+	icmpType, err := strconv.Atoi(value)
+	if err != nil || icmpType < 0 || icmpType > 255 {
+		errors = append(errors, fmt.Errorf("%q must be an integer between 0 and 255, got: %s", k, value))
+	}
+
+	return
+}
+
+// validateSecurityGroupRuleConfig checks the combination of the attributes of a rule
+func validateSecurityGroupRuleConfig(rule map[string]string) error {
+
+	protocol := rule["protocol"]
+	ip := rule["ip"]
+	size := rule["size"]
+
+	if len(rule["range"]) > 0 && protocol != "TCP" && protocol != "UDP" {
+		return fmt.Errorf("\"range\" can only be defined for the TCP and UDP protocols, got: %s", protocol)
+	}
+
+	if len(rule["icmp_type"]) > 0 && protocol != "ICMP" {
+		return fmt.Errorf("\"icmp_type\" can only be defined for the ICMP protocol, got: %s", protocol)
+	}
+
+	if len(rule["network_id"]) > 0 && (len(ip) > 0 || len(size) > 0) {
+		return fmt.Errorf("\"network_id\" can't be defined with \"ip\" and \"size\"")
+	}
+
+	if len(ip) > 0 && len(size) == 0 {
+		return fmt.Errorf("\"size\" is required when \"ip\" is defined")
+	}
+	if len(size) > 0 && len(ip) == 0 {
+		return fmt.Errorf("\"ip\" is required when \"size\" is defined")
+	}
+
+	// check that the addresses range doesn't overflow the address space
+	if len(ip) > 0 {
+		addr := net.ParseIP(ip)
+		sizeInt, err := strconv.Atoi(size)
+		if addr == nil || err != nil {
+			return nil
+		}
+
+		bits := 128
+		if addr.To4() != nil {
+			addr = addr.To4()
+			bits = 32
+		}
+
+		last := new(big.Int).SetBytes(addr)
+		last.Add(last, big.NewInt(int64(sizeInt-1)))
+		if last.BitLen() > bits {
+			return fmt.Errorf("the range starting at %s with size %s exceeds the address space", ip, size)
+		}
+	}
+
+	return nil
+}
+
+// resourceSecurityGroupCustomizeDiff checks the rules of the "rule" blocks
+func resourceSecurityGroupCustomizeDiff(ctx context.Context, diff *schema.ResourceDiff, v interface{}) error {
+
+	rules, ok := diff.Get("rule").([]interface{})
+	if !ok {
+		return nil
+	}
+
+	for i, ruleIf := range rules {
+		ruleMap, ok := ruleIf.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		rule := map[string]string{
+			"protocol": ruleMap["protocol"].(string),
+		}
+		known := true
+		for _, k := range securityGroupRuleFields {
+			// values are not known yet
+			if !diff.NewValueKnown(fmt.Sprintf("rule.%d.%s", i, k)) {
+				known = false
+				break
+			}
+			rule[k], _ = ruleMap[k].(string)
+		}
+		if !known {
+			continue
+		}
+
+		err := validateSecurityGroupRuleConfig(rule)
+		if err != nil {
+			return fmt.Errorf("rule %d: %s", i, err)
+		}
+	}
+
+	return nil
+}
+
+// resourceSecurityGroupRuleCustomizeDiff checks the attributes of a single rule
+func resourceSecurityGroupRuleCustomizeDiff(ctx context.Context, diff *schema.ResourceDiff, v interface{}) error {
+
+	rule := map[string]string{
+		"protocol": diff.Get("protocol").(string),
+	}
+	for _, k := range securityGroupRuleFields {
+		// values are not known yet
+		if !diff.NewValueKnown(k) {
+			return nil
+		}
+		rule[k] = diff.Get(k).(string)
+	}
+
+	return validateSecurityGroupRuleConfig(rule)
+}
@@ -1,9 +1,13 @@
 package opennebula
 
 import (
+	"context"
 	"log"
+	"reflect"
 	"strings"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
 	dyn "github.com/OpenNebula/one/src/oca/go/src/goca/dynamic"
 )
 
@@ -62,3 +66,107 @@ func matchTags(tpl dyn.Template, tags map[string]interface{}) bool {
 
 	return true
 }
+
+// providerDefaultTags returns the tags of the default_tags block of the provider
+func providerDefaultTags(d *schema.ResourceData) map[string]interface{} {
+
+	defaultTags := d.Get("default_tags").([]interface{})
+	if len(defaultTags) == 0 || defaultTags[0] == nil {
+		return map[string]interface{}{}
+	}
+
+	return defaultTags[0].(map[string]interface{})["tags"].(map[string]interface{})
+}
+
+// configDefaultTags returns the provider default tags from the meta of a resource
+func configDefaultTags(meta interface{}) map[string]interface{} {
+
+	config, ok := meta.(*Configuration)
+	if !ok || config.DefaultTags == nil {
+		return map[string]interface{}{}
+	}
+
+	return config.DefaultTags
+}
+
+// mergeTags returns the default tags overridden by the tags of the resource.
+// The keys are compared case insensitively as they are uppercased in the templates.
+func mergeTags(defaultTags, tags map[string]interface{}) map[string]interface{} {
+
+	tagsAll := make(map[string]interface{})
+
+	for k, v := range defaultTags {
+		tagsAll[k] = v
+	}
+
+	for k, v := range tags {
+		for dk := range defaultTags {
+			if strings.ToUpper(dk) == strings.ToUpper(k) {
+				delete(tagsAll, dk)
+			}
+		}
+		tagsAll[k] = v
+	}
+
+	return tagsAll
+}
+
+// setTagsAll sets tags_all to the effective tags of the resource, so that the
+// templates are generated from it on create and update
+func setTagsAll(d *schema.ResourceData, meta interface{}) {
+	d.Set("tags_all", mergeTags(configDefaultTags(meta), d.Get("tags").(map[string]interface{})))
+}
+
+// tagsAllChange returns the tags to apply to the template, the previous ones
+// being taken from the state as setTagsAll only modifies the new value
+func tagsAllChange(d *schema.ResourceData) (map[string]interface{}, map[string]interface{}) {
+
+	oldTagsIf, _ := d.GetChange("tags_all")
+
+	return oldTagsIf.(map[string]interface{}), d.Get("tags_all").(map[string]interface{})
+}
+
+// flattenTagsAll reads tags_all from the template. Only the keys of the tags of
+// the resource, of the provider default tags and of the previous state are
+// read, the other pairs of the template aren't managed as tags.
+func flattenTagsAll(d *schema.ResourceData, meta interface{}, tpl *dyn.Template) error {
+
+	// the last spelling of a key wins, as in mergeTags
+	keys := make(map[string]string)
+	for _, tags := range []map[string]interface{}{
+		d.Get("tags_all").(map[string]interface{}),
+		configDefaultTags(meta),
+		d.Get("tags").(map[string]interface{}),
+	} {
+		for k := range tags {
+			keys[strings.ToUpper(k)] = k
+		}
+	}
+
+	tagsAll := make(map[string]interface{})
+	for upperKey, k := range keys {
+		value, err := tpl.GetStr(upperKey)
+		if err != nil {
+			continue
+		}
+		tagsAll[k] = value
+	}
+
+	return d.Set("tags_all", tagsAll)
+}
+
+// tagsAllCustomizeDiff computes tags_all from the provider default tags and the
+// tags of the resource, so that a change of the default tags updates the resource
+func tagsAllCustomizeDiff(ctx context.Context, diff *schema.ResourceDiff, meta interface{}) error {
+
+	if !diff.NewValueKnown("tags") {
+		return diff.SetNewComputed("tags_all")
+	}
+
+	tagsAll := mergeTags(configDefaultTags(meta), diff.Get("tags").(map[string]interface{}))
+	if reflect.DeepEqual(tagsAll, diff.Get("tags_all")) {
+		return nil
+	}
+
+	return diff.SetNew("tags_all", tagsAll)
+}
@@ -0,0 +1,63 @@
+package opennebula
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeTags(t *testing.T) {
+
+	cases := []struct {
+		name        string
+		defaultTags map[string]interface{}
+		tags        map[string]interface{}
+		expected    map[string]interface{}
+	}{
+		{
+			name:        "nil maps",
+			defaultTags: nil,
+			tags:        nil,
+			expected:    map[string]interface{}{},
+		},
+		{
+			name:        "defaults only",
+			defaultTags: map[string]interface{}{"env": "default", "owner": "ops"},
+			tags:        nil,
+			expected:    map[string]interface{}{"env": "default", "owner": "ops"},
+		},
+		{
+			name:        "tags only",
+			defaultTags: nil,
+			tags:        map[string]interface{}{"env": "dev"},
+			expected:    map[string]interface{}{"env": "dev"},
+		},
+		{
+			name:        "override",
+			defaultTags: map[string]interface{}{"env": "default", "owner": "ops"},
+			tags:        map[string]interface{}{"env": "dev", "customer": "test"},
+			expected:    map[string]interface{}{"env": "dev", "owner": "ops", "customer": "test"},
+		},
+		{
+			name:        "case insensitive override",
+			defaultTags: map[string]interface{}{"ENV": "default", "Owner": "ops"},
+			tags:        map[string]interface{}{"env": "dev", "OWNER": "dev-team"},
+			expected:    map[string]interface{}{"env": "dev", "OWNER": "dev-team"},
+		},
+	}
+
+	for _, c := range cases {
+		defaultTags := make(map[string]interface{})
+		for k, v := range c.defaultTags {
+			defaultTags[k] = v
+		}
+
+		tagsAll := mergeTags(c.defaultTags, c.tags)
+		if !reflect.DeepEqual(tagsAll, c.expected) {
+			t.Errorf("%s: expected %v, got %v", c.name, c.expected, tagsAll)
+		}
+
+		if c.defaultTags != nil && !reflect.DeepEqual(c.defaultTags, defaultTags) {
+			t.Errorf("%s: the default tags have been modified: %v", c.name, c.defaultTags)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package opennebula
 
 import (
 	"context"
+	"encoding/xml"
 	"fmt"
 	"log"
 	"strings"
@@ -357,3 +358,450 @@ func vmNICDetach(ctx context.Context, vmc *goca.VMController, timeout time.Durat
 
 	return nil
 }
+
+// vmDiskSnapshots contains the disk snapshots of a VM.
+// The VM schema from goca doesn't keep the DISK_ID of each SNAPSHOTS element.
+type vmDiskSnapshots struct {
+	XMLName   xml.Name `xml:"VM"`
+	Snapshots []struct {
+		DiskID int `xml:"DISK_ID"`
+		shared.DiskSnapshot
+	} `xml:"SNAPSHOTS"`
+}
+
+// vmGetDiskSnapshots retrieve the snapshots list of a VM disk
+func vmGetDiskSnapshots(controller *goca.Controller, vmID, diskID int) ([]shared.Snapshot, error) {
+
+	// TODO: fix it after 5.10 release
+	// Force the "decrypt" bool to false to keep ONE 5.8 behavior
+	response, err := controller.Client.Call("one.vm.info", vmID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	vmSnapshots := &vmDiskSnapshots{}
+	err = xml.Unmarshal([]byte(response.Body()), vmSnapshots)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, disk := range vmSnapshots.Snapshots {
+		if disk.DiskID == diskID {
+			return disk.Snapshots, nil
+		}
+	}
+
+	return []shared.Snapshot{}, nil
+}
+
+// vmGetSnapshot retrieve a system snapshot from the VM template
+func vmGetSnapshot(vmInfos *vm.VM, snapshotID int) *dyn.Vector {
+
+	for _, snapshot := range vmInfos.Template.GetVectors("SNAPSHOT") {
+		id, err := snapshot.GetInt("SNAPSHOT_ID")
+		if err == nil && id == snapshotID {
+			return snapshot
+		}
+	}
+
+	return nil
+}
+
+// vmSnapshotCreate is an helper that synchronously create a system snapshot
+func vmSnapshotCreate(ctx context.Context, vmc *goca.VMController, timeout time.Duration, name string) (int, error) {
+
+	log.Printf("[DEBUG] Create snapshot of virtual machine (ID:%d)", vmc.ID)
+
+	// Retrieve snapshot list
+	vmInfos, err := vmc.Info(false)
+	if err != nil {
+		return -1, err
+	}
+
+	lastID := -1
+	for _, snapshot := range vmInfos.Template.GetVectors("SNAPSHOT") {
+		id, err := snapshot.GetInt("SNAPSHOT_ID")
+		if err == nil && id > lastID {
+			lastID = id
+		}
+	}
+
+	err = vmc.SnapshotCreate(name)
+	if err != nil {
+		return -1, fmt.Errorf("can't create snapshot of virtual machine (ID:%d): %s\n", vmc.ID, err)
+	}
+
+	// wait before checking snapshot list
+	// final states ar added to transient one in case of slow cloud
+	transient := vmSnapshotTransientStates.
+		Append(vmSnapshotReadyStates)
+	finalStrs := vmSnapshotReadyStates.ToStrings()
+	stateConf := NewVMUpdateStateConf(timeout, transient.ToStrings(), finalStrs)
+
+	_, err = waitForVMStates(ctx, vmc, stateConf)
+	if err != nil {
+		return -1, fmt.Errorf(
+			"waiting for virtual machine (ID:%d) to be in state %s: %s", vmc.ID, strings.Join(finalStrs, ","), err)
+	}
+
+	// the new snapshot has the highest ID
+	vmInfos, err = vmc.Info(false)
+	if err != nil {
+		return -1, err
+	}
+
+	snapshotID := -1
+	for _, snapshot := range vmInfos.Template.GetVectors("SNAPSHOT") {
+		id, err := snapshot.GetInt("SNAPSHOT_ID")
+		if err == nil && id > lastID {
+			snapshotID = id
+		}
+	}
+
+	if snapshotID == -1 {
+		// If snapshot not created, retrieve error message
+		vmerr, _ := vmInfos.UserTemplate.Get(vmk.Error)
+
+		return -1, fmt.Errorf("virtual machine (ID:%d): %s", vmc.ID, vmerr)
+	}
+
+	return snapshotID, nil
+}
+
+// vmSnapshotRevert is an helper that synchronously revert a VM to a system snapshot
+func vmSnapshotRevert(ctx context.Context, vmc *goca.VMController, timeout time.Duration, snapshotID int) error {
+
+	log.Printf("[DEBUG] Revert virtual machine (ID:%d) to snapshot %d", vmc.ID, snapshotID)
+
+	err := vmc.SnapshotRevert(snapshotID)
+	if err != nil {
+		return fmt.Errorf("can't revert to snapshot %d: %s\n", snapshotID, err)
+	}
+
+	// final states ar added to transient one in case of slow cloud
+	transient := vmSnapshotTransientStates.
+		Append(vmSnapshotReadyStates)
+	finalStrs := vmSnapshotReadyStates.ToStrings()
+	stateConf := NewVMUpdateStateConf(timeout, transient.ToStrings(), finalStrs)
+
+	_, err = waitForVMStates(ctx, vmc, stateConf)
+	if err != nil {
+		return fmt.Errorf(
+			"waiting for virtual machine (ID:%d) to be in state %s: %s", vmc.ID, strings.Join(finalStrs, ","), err)
+	}
+
+	return nil
+}
+
+// vmSnapshotDelete is an helper that synchronously delete a system snapshot
+func vmSnapshotDelete(ctx context.Context, vmc *goca.VMController, timeout time.Duration, snapshotID int) error {
+
+	log.Printf("[DEBUG] Delete snapshot %d", snapshotID)
+
+	err := vmc.SnapshotDelete(snapshotID)
+	if err != nil {
+		return fmt.Errorf("can't delete snapshot %d: %s\n", snapshotID, err)
+	}
+
+	// final states ar added to transient one in case of slow cloud
+	transient := vmSnapshotTransientStates.
+		Append(vmSnapshotReadyStates)
+	finalStrs := vmSnapshotReadyStates.ToStrings()
+	stateConf := NewVMUpdateStateConf(timeout, transient.ToStrings(), finalStrs)
+
+	_, err = waitForVMStates(ctx, vmc, stateConf)
+	if err != nil {
+		return fmt.Errorf(
+			"waiting for virtual machine (ID:%d) to be in state %s: %s", vmc.ID, strings.Join(finalStrs, ","), err)
+	}
+
+	// Check that the snapshot is deleted
+	vmInfos, err := vmc.Info(false)
+	if err != nil {
+		return err
+	}
+
+	if vmGetSnapshot(vmInfos, snapshotID) != nil {
+		// If snapshot still present, retrieve error message
+		vmerr, _ := vmInfos.UserTemplate.Get(vmk.Error)
+
+		return fmt.Errorf("snapshot %d: %s", snapshotID, vmerr)
+	}
+
+	return nil
+}
+
+// vmDiskSnapshotCreate is an helper that synchronously create a disk snapshot
+func vmDiskSnapshotCreate(ctx context.Context, controller *goca.Controller, vmID, diskID int, timeout time.Duration, name string) (int, error) {
+
+	log.Printf("[DEBUG] Create snapshot of disk %d of virtual machine (ID:%d)", diskID, vmID)
+
+	vmc := controller.VM(vmID)
+
+	snapshotID, err := vmc.Disk(diskID).SnapshotCreate(name)
+	if err != nil {
+		return -1, fmt.Errorf("can't create snapshot of disk %d: %s\n", diskID, err)
+	}
+
+	err = vmDiskSnapshotWait(ctx, vmc, timeout)
+	if err != nil {
+		return snapshotID, err
+	}
+
+	// the VM may be back in a ready state before the snapshot operation started
+	snapshots, err := vmGetDiskSnapshots(controller, vmID, diskID)
+	if err != nil {
+		return snapshotID, err
+	}
+
+	for _, s := range snapshots {
+		if s.ID == snapshotID {
+			return snapshotID, nil
+		}
+	}
+
+	// If snapshot not created, retrieve error message
+	vmInfos, err := vmc.Info(false)
+	if err != nil {
+		return snapshotID, err
+	}
+	vmerr, _ := vmInfos.UserTemplate.Get(vmk.Error)
+
+	return -1, fmt.Errorf("virtual machine (ID:%d) disk %d: snapshot %d not found: %s", vmID, diskID, snapshotID, vmerr)
+}
+
+// vmDiskSnapshotWait wait for the end of a disk snapshot operation
+func vmDiskSnapshotWait(ctx context.Context, vmc *goca.VMController, timeout time.Duration) error {
+
+	// final states ar added to transient one in case of slow cloud
+	transient := vmDiskSnapshotTransientStates.
+		Append(vmDiskSnapshotReadyStates)
+	finalStrs := vmDiskSnapshotReadyStates.ToStrings()
+	stateConf := NewVMUpdateStateConf(timeout, transient.ToStrings(), finalStrs)
+
+	_, err := waitForVMStates(ctx, vmc, stateConf)
+	if err != nil {
+		return fmt.Errorf(
+			"waiting for virtual machine (ID:%d) to be in state %s: %s", vmc.ID, strings.Join(finalStrs, ","), err)
+	}
+
+	return nil
+}
+
+// vmGetDesiredState returns the power state of the VM as one of vmDesiredStates,
+// or an empty string when the VM is in none of these states
+func vmGetDesiredState(vmInfos *vm.VM) string {
+
+	vmState, vmLCMState, err := vmInfos.State()
+	if err != nil {
+		return ""
+	}
+
+	switch vmState {
+	case vm.Active:
+		if vmLCMState == vm.Running {
+			return vmLCMState.String()
+		}
+	case vm.Poweroff, vm.Suspended, vm.Undeployed, vm.Stopped:
+		return vmState.String()
+	}
+
+	return ""
+}
+
+// vmSetDesiredState is an helper that synchronously brings a VM to one of vmDesiredStates.
+// Only a running VM can be powered off, suspended, undeployed or stopped,
+// so a parked VM is resumed first, except to undeploy a powered off VM.
+func vmSetDesiredState(ctx context.Context, vmc *goca.VMController, timeout time.Duration, desiredState string, hard bool) error {
+
+	vmInfos, err := vmc.Info(false)
+	if err != nil {
+		return err
+	}
+
+	currentState := vmGetDesiredState(vmInfos)
+	if currentState == desiredState {
+		return nil
+	}
+
+	log.Printf("[DEBUG] Change state of virtual machine (ID:%d) from %s to %s", vmc.ID, currentState, desiredState)
+
+	if currentState == "" {
+		vmState, vmLCMState, _ := vmInfos.State()
+		return fmt.Errorf("virtual machine (ID:%d) is in state %s and LCM state %s, can't change it to %s",
+			vmc.ID, vmState.String(), vmLCMState.String(), desiredState)
+	}
+
+	if currentState != vm.Running.String() &&
+		!(currentState == vm.Poweroff.String() && desiredState == vm.Undeployed.String()) {
+
+		err = vmc.Resume()
+		if err != nil {
+			return fmt.Errorf("can't resume virtual machine (ID:%d): %s", vmc.ID, err)
+		}
+
+		// parked states are added to transient one in case of slow cloud
+		transient := vmResumeTransientStates.
+			Append(vmParkedStates)
+		finalStrs := NewVMLCMState(vm.Running).ToStrings()
+		stateConf := NewVMStateConf(timeout, transient.ToStrings(), finalStrs)
+
+		_, err = waitForVMStates(ctx, vmc, stateConf)
+		if err != nil {
+			return fmt.Errorf(
+				"waiting for virtual machine (ID:%d) to be in state %s: %s", vmc.ID, strings.Join(finalStrs, ","), err)
+		}
+	}
+
+	var transient, final VMStates
+
+	switch desiredState {
+	case vm.Running.String():
+		return nil
+	case vm.Poweroff.String():
+		if hard {
+			err = vmc.PoweroffHard()
+		} else {
+			err = vmc.Poweroff()
+		}
+		transient = vmPowerOffTransientStates
+		final = NewVMState(vm.Poweroff)
+	case vm.Suspended.String():
+		err = vmc.Suspend()
+		transient = vmSuspendTransientStates
+		final = NewVMState(vm.Suspended)
+	case vm.Undeployed.String():
+		if hard {
+			err = vmc.UndeployHard()
+		} else {
+			err = vmc.Undeploy()
+		}
+		transient = vmUndeployTransientStates.
+			Append(NewVMState(vm.Poweroff))
+		final = NewVMState(vm.Undeployed)
+	case vm.Stopped.String():
+		err = vmc.Stop()
+		transient = vmStopTransientStates
+		final = NewVMState(vm.Stopped)
+	default:
+		return fmt.Errorf("unsupported desired state %s", desiredState)
+	}
+	if err != nil {
+		return fmt.Errorf("can't change state of virtual machine (ID:%d) to %s: %s", vmc.ID, desiredState, err)
+	}
+
+	// RUNNING state is added to transient one in case of slow cloud
+	transient = NewVMLCMState(vm.Running).
+		Append(transient)
+	finalStrs := final.ToStrings()
+	stateConf := NewVMStateConf(timeout, transient.ToStrings(), finalStrs)
+
+	_, err = waitForVMStates(ctx, vmc, stateConf)
+	if err != nil {
+		return fmt.Errorf(
+			"waiting for virtual machine (ID:%d) to be in state %s: %s", vmc.ID, strings.Join(finalStrs, ","), err)
+	}
+
+	return nil
+}
+
+var vmHistoryReasons = []string{"NONE", "ERROR", "USER"}
+
+// vmHistoryReasonRecords retrieves the end reason of the history records,
+// it's not part of the goca history records
+type vmHistoryReasonRecords struct {
+	XMLName xml.Name `xml:"VM"`
+	Records []struct {
+		SEQ    int `xml:"SEQ"`
+		Reason int `xml:"REASON"`
+	} `xml:"HISTORY_RECORDS>HISTORY"`
+}
+
+// vmInfoWithHistory retrieves the VM informations and the end reason of each
+// history record, indexed by sequence number
+func vmInfoWithHistory(controller *goca.Controller, vmID int) (*vm.VM, map[int]string, error) {
+
+	// TODO: fix it after 5.10 release
+	// Force the "decrypt" bool to false to keep ONE 5.8 behavior
+	response, err := controller.Client.Call("one.vm.info", vmID, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	vmInfos := &vm.VM{}
+	err = xml.Unmarshal([]byte(response.Body()), vmInfos)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	history := &vmHistoryReasonRecords{}
+	err = xml.Unmarshal([]byte(response.Body()), history)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reasons := make(map[int]string, len(history.Records))
+	for _, record := range history.Records {
+		if record.Reason >= 0 && record.Reason < len(vmHistoryReasons) {
+			reasons[record.SEQ] = vmHistoryReasons[record.Reason]
+		}
+	}
+
+	return vmInfos, reasons, nil
+}
+
+// vmReschedule is an helper that flags a running VM to be rescheduled and waits
+// for the scheduler to migrate it to a host satisfying its requirements
+func vmReschedule(ctx context.Context, vmc *goca.VMController, timeout time.Duration) error {
+
+	log.Printf("[DEBUG] Reschedule virtual machine (ID:%d)", vmc.ID)
+
+	err := vmc.Resched()
+	if err != nil {
+		return fmt.Errorf("can't reschedule virtual machine (ID:%d): %s", vmc.ID, err)
+	}
+
+	// RESCHED is a pseudo state: the VM is running but waits for the scheduler
+	transient := vmReschedTransientStates.ToStrings()
+	transient = append(transient, "RESCHED")
+	finalStrs := NewVMLCMState(vm.Running).ToStrings()
+
+	stateConf := NewVMStateConf(timeout, transient, finalStrs)
+	stateConf.Refresh = func() (interface{}, string, error) {
+
+		log.Println("Refreshing VM rescheduling state...")
+
+		vmInfos, err := vmc.Info(false)
+		if err != nil {
+			return nil, "err", err
+		}
+
+		vmState, vmLCMState, err := vmInfos.State()
+		if err != nil {
+			return vmInfos, "err_unknown_state", err
+		}
+
+		if vmState != vm.Active {
+			return vmInfos, vmState.String(), nil
+		}
+
+		if vmLCMState == vm.BootMigrateFailure || vmLCMState == vm.PrologMigrateFailure {
+			vmerr, _ := vmInfos.UserTemplate.Get(vmk.Error)
+			return vmInfos, vmLCMState.String(), fmt.Errorf("VM (ID:%d) entered fail state, error: %s", vmInfos.ID, vmerr)
+		}
+
+		if vmLCMState == vm.Running && vmInfos.ReschedValue == 1 {
+			return vmInfos, "RESCHED", nil
+		}
+
+		return vmInfos, vmLCMState.String(), nil
+	}
+
+	_, err = stateConf.WaitForStateContext(ctx)
+	if err != nil {
+		return fmt.Errorf(
+			"waiting for virtual machine (ID:%d) to be in state %s: %s", vmc.ID, strings.Join(finalStrs, ","), err)
+	}
+
+	return nil
+}
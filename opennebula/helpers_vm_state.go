@@ -42,6 +42,34 @@ var (
 		LCMs: []vm.LCMState{vm.ShutdownPoweroff},
 	}
 
+	// Power state management: resume, suspend, undeploy and stop the VM
+	vmResumeTransientStates = VMStates{
+		States: []vm.State{vm.Pending},
+		LCMs:   []vm.LCMState{vm.BootPoweroff, vm.BootSuspended, vm.BootStopped, vm.BootUndeploy, vm.PrologResume, vm.PrologUndeploy},
+	}
+
+	vmSuspendTransientStates = VMStates{
+		LCMs: []vm.LCMState{vm.SaveSuspend},
+	}
+
+	vmUndeployTransientStates = VMStates{
+		LCMs: []vm.LCMState{vm.ShutdownUndeploy, vm.EpilogUndeploy},
+	}
+
+	vmStopTransientStates = VMStates{
+		LCMs: []vm.LCMState{vm.SaveStop, vm.EpilogStop},
+	}
+
+	// Rescheduling: the VM is migrated by the scheduler
+	vmReschedTransientStates = VMStates{
+		LCMs: []vm.LCMState{vm.Migrate, vm.SaveMigrate, vm.PrologMigrate, vm.BootMigrate},
+	}
+
+	// Parked states: the VM is not running but may be resumed
+	vmParkedStates = VMStates{
+		States: []vm.State{vm.Poweroff, vm.Suspended, vm.Undeployed, vm.Stopped},
+	}
+
 	// Update: VM resize
 	vmResizeTransientStates = VMStates{
 		LCMs: []vm.LCMState{vm.HotplugResize},
@@ -71,6 +99,27 @@ var (
 	vmNICTransientStates = VMStates{
 		LCMs: []vm.LCMState{vm.HotplugNic, vm.HotplugNicPoweroff},
 	}
+
+	// System snapshots
+	vmSnapshotReadyStates = VMStates{
+		LCMs: []vm.LCMState{vm.Running},
+	}
+
+	vmSnapshotTransientStates = VMStates{
+		LCMs: []vm.LCMState{vm.HotplugSnapshot},
+	}
+
+	// Disk snapshots
+	vmDiskSnapshotReadyStates = VMStates{
+		States: []vm.State{vm.Poweroff, vm.Suspended},
+		LCMs:   []vm.LCMState{vm.Running},
+	}
+
+	vmDiskSnapshotTransientStates = VMStates{
+		LCMs: []vm.LCMState{vm.DiskSnapshot, vm.DiskSnapshotDelete,
+			vm.DiskSnapshotPoweroff, vm.DiskSnapshotRevertPoweroff, vm.DiskSnapshotDeletePoweroff,
+			vm.DiskSnapshotSuspended, vm.DiskSnapshotRevertSuspended, vm.DiskSnapshotDeleteSuspended},
+	}
 )
 
 // VMStates represents a collection of VM states
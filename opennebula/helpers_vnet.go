@@ -0,0 +1,211 @@
+package opennebula
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+var vnetARTypes = []string{"IP4", "IP6", "IP6_STATIC", "IP4_6", "IP4_6_STATIC", "ETHER"}
+
+// ULA addresses are in fc00::/7, link local addresses in fe80::/10
+var (
+	_, ulaNetwork, _       = net.ParseCIDR("fc00::/7")
+	_, linkLocalNetwork, _ = net.ParseCIDR("fe80::/10")
+)
+
+func validateARType(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+
+	if inArray(value, vnetARTypes) < 0 {
+		errors = append(errors, fmt.Errorf("Address Range type %q must be one of: %s", k, strings.Join(vnetARTypes, ",")))
+	}
+
+	return
+}
+
+func validateIPv4(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+
+	ip := net.ParseIP(value)
+	if ip == nil || ip.To4() == nil {
+		errors = append(errors, fmt.Errorf("%q must be a valid IPv4 address, got: %s", k, value))
+	}
+
+	return
+}
+
+func validateIPv6(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+
+	ip := net.ParseIP(value)
+	if ip == nil || ip.To4() != nil {
+		errors = append(errors, fmt.Errorf("%q must be a valid IPv6 address, got: %s", k, value))
+	}
+
+	return
+}
+
+// validateIPv6Prefix checks that the value is an IPv6 /64 prefix, OpenNebula
+// generates the interface identifier from the MAC address
+func validateIPv6Prefix(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+
+	ip := net.ParseIP(value)
+	if ip == nil || ip.To4() != nil {
+		errors = append(errors, fmt.Errorf("%q must be a valid IPv6 prefix, got: %s", k, value))
+		return
+	}
+
+	for _, b := range ip[8:] {
+		if b != 0 {
+			errors = append(errors, fmt.Errorf("%q must be a /64 IPv6 prefix with the last 64 bits set to zero, got: %s", k, value))
+			return
+		}
+	}
+
+	return
+}
+
+func validateMAC(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+
+	_, err := net.ParseMAC(value)
+	if err != nil {
+		errors = append(errors, fmt.Errorf("%q must be a valid MAC address, got: %s", k, value))
+	}
+
+	return
+}
+
+func validatePrefixLength(v interface{}, k string) (ws []string, errors []error) {
+	value := v.(string)
+
+	length, err := strconv.Atoi(value)
+	if err != nil || length < 1 || length > 128 {
+		errors = append(errors, fmt.Errorf("%q must be an integer between 1 and 128, got: %s", k, value))
+	}
+
+	return
+}
+
+// validateARConfig checks the attributes of an address range depending on its type
+func validateARConfig(ar map[string]interface{}) error {
+
+	arType := ar["ar_type"].(string)
+	globalPrefix := ar["global_prefix"].(string)
+	ulaPrefix := ar["ula_prefix"].(string)
+
+	var required, forbidden []string
+
+	switch arType {
+	case "IP4":
+		required = []string{"ip4"}
+		forbidden = []string{"ip6", "prefix_length", "global_prefix", "ula_prefix"}
+	case "IP6":
+		forbidden = []string{"ip4", "ip6", "prefix_length"}
+	case "IP6_STATIC":
+		required = []string{"ip6", "prefix_length"}
+		forbidden = []string{"ip4", "global_prefix", "ula_prefix"}
+	case "IP4_6":
+		required = []string{"ip4"}
+		forbidden = []string{"ip6", "prefix_length"}
+	case "IP4_6_STATIC":
+		required = []string{"ip4", "ip6", "prefix_length"}
+		forbidden = []string{"global_prefix", "ula_prefix"}
+	case "ETHER":
+		forbidden = []string{"ip4", "ip6", "prefix_length", "global_prefix", "ula_prefix"}
+	}
+
+	for _, k := range required {
+		if len(ar[k].(string)) == 0 {
+			return fmt.Errorf("%q is required for the address range type %s", k, arType)
+		}
+	}
+	for _, k := range forbidden {
+		if len(ar[k].(string)) > 0 {
+			return fmt.Errorf("%q can't be defined for the address range type %s", k, arType)
+		}
+	}
+
+	if len(ulaPrefix) > 0 {
+		ip := net.ParseIP(ulaPrefix)
+		if ip != nil && !ulaNetwork.Contains(ip) {
+			return fmt.Errorf("\"ula_prefix\" %s is not an unique local address prefix (fc00::/7)", ulaPrefix)
+		}
+	}
+
+	if len(globalPrefix) > 0 {
+		ip := net.ParseIP(globalPrefix)
+		if ip != nil && (ulaNetwork.Contains(ip) || linkLocalNetwork.Contains(ip)) {
+			return fmt.Errorf("\"global_prefix\" %s is not a global unicast prefix, use \"ula_prefix\" for unique local addresses", globalPrefix)
+		}
+	}
+
+	return nil
+}
+
+// vnetARsCustomizeDiff checks the address ranges of the "ar" blocks
+func vnetARsCustomizeDiff(ctx context.Context, diff *schema.ResourceDiff, v interface{}) error {
+
+	// values are not known yet
+	if !diff.NewValueKnown("ar") {
+		return nil
+	}
+
+	arSet, ok := diff.Get("ar").(*schema.Set)
+	if !ok {
+		return nil
+	}
+
+	for _, arIf := range arSet.List() {
+		ar := arIf.(map[string]interface{})
+
+		err := validateARConfig(ar)
+		if err != nil {
+			return fmt.Errorf("address range of type %s and size %d: %s", ar["ar_type"], ar["size"], err)
+		}
+	}
+
+	return nil
+}
+
+// ipEqual compares two IP addresses or prefixes regardless of their textual representation
+func ipEqual(a, b string) bool {
+	if a == b {
+		return true
+	}
+
+	ipA := net.ParseIP(a)
+	ipB := net.ParseIP(b)
+	if ipA == nil || ipB == nil {
+		return false
+	}
+
+	return ipA.Equal(ipB)
+}
+
+// macEqual compares two MAC addresses regardless of their case
+func macEqual(a, b string) bool {
+	return strings.EqualFold(a, b)
+}
+
+// emptyOrEqualIP is similar to emptyOrEqual but compare addresses values
+func emptyOrEqualIP(config interface{}, value string) bool {
+	configStr, _ := config.(string)
+	return len(configStr) == 0 || ipEqual(configStr, value)
+}
+
+// configOrRead returns the configured address when it's equivalent to the read
+// one to avoid diffs due to the textual representation
+func configOrRead(config interface{}, value string, equal func(a, b string) bool) string {
+	configStr, _ := config.(string)
+	if len(configStr) > 0 && equal(configStr, value) {
+		return configStr
+	}
+	return value
+}
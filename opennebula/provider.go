@@ -7,6 +7,7 @@ import (
 	ver "github.com/hashicorp/go-version"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 
 	"github.com/OpenNebula/one/src/oca/go/src/goca"
 )
@@ -16,9 +17,9 @@ func Provider() *schema.Provider {
 		Schema: map[string]*schema.Schema{
 			"endpoint": {
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
 				Description: "The URL to your public or private OpenNebula",
-				DefaultFunc: schema.EnvDefaultFunc("OPENNEBULA_ENDPOINT", nil),
+				DefaultFunc: schema.MultiEnvDefaultFunc([]string{"OPENNEBULA_ENDPOINT", "ONE_XMLRPC"}, nil),
 			},
 			"flow_endpoint": {
 				Type:        schema.TypeString,
@@ -28,41 +29,185 @@ func Provider() *schema.Provider {
 			},
 			"username": {
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
 				Description: "The ID of the user to identify as",
 				DefaultFunc: schema.EnvDefaultFunc("OPENNEBULA_USERNAME", nil),
 			},
 			"password": {
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
+				Sensitive:   true,
 				Description: "The password for the user",
 				DefaultFunc: schema.EnvDefaultFunc("OPENNEBULA_PASSWORD", nil),
 			},
+			"token": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Sensitive:   true,
+				Description: "A login token of the user, used instead of the password",
+				DefaultFunc: schema.EnvDefaultFunc("OPENNEBULA_TOKEN", nil),
+			},
+			"one_auth": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Path of the file containing the credentials, in the format <username>:<password>. Defaults to $ONE_AUTH or ~/.one/one_auth",
+			},
+			"ssh_private_key": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Path of the PEM encoded RSA private key used to authenticate with the ssh driver",
+				DefaultFunc: schema.EnvDefaultFunc("OPENNEBULA_SSH_PRIVATE_KEY", nil),
+			},
+			"x509_certificate": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Path of the PEM encoded certificate chain used to authenticate with the x509 driver",
+				DefaultFunc: schema.EnvDefaultFunc("OPENNEBULA_X509_CERTIFICATE", nil),
+			},
+			"x509_private_key": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Path of the PEM encoded RSA private key of the x509 certificate",
+				DefaultFunc: schema.EnvDefaultFunc("OPENNEBULA_X509_PRIVATE_KEY", nil),
+			},
+			"login_token_expiration": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Description: "When greater than 0, validity in seconds of a login token generated at startup and used instead of the credentials",
+				DefaultFunc: schema.EnvDefaultFunc("OPENNEBULA_LOGIN_TOKEN_EXPIRATION", nil),
+			},
+			"ca_file": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Path of a PEM encoded CA bundle used to verify the certificates of the endpoints",
+				DefaultFunc: schema.EnvDefaultFunc("OPENNEBULA_CA_FILE", nil),
+			},
+			"insecure": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Description: "Disable the verification of the certificates of the endpoints",
+				DefaultFunc: schema.EnvDefaultFunc("OPENNEBULA_INSECURE", false),
+			},
+			"client_certificate": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Path of a PEM encoded client certificate presented to the endpoints",
+				DefaultFunc: schema.EnvDefaultFunc("OPENNEBULA_CLIENT_CERTIFICATE", nil),
+			},
+			"client_key": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Path of the PEM encoded private key of the client certificate",
+				DefaultFunc: schema.EnvDefaultFunc("OPENNEBULA_CLIENT_KEY", nil),
+			},
+			"http_proxy": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "URL of the proxy used to reach the endpoints, defaults to the HTTP_PROXY and HTTPS_PROXY environment variables",
+			},
+			"timeout": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Description: "Timeout in seconds of the requests to the endpoints, 0 means no timeout",
+				DefaultFunc: schema.EnvDefaultFunc("OPENNEBULA_TIMEOUT", 0),
+			},
+			"headers": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Description: "Custom HTTP headers added to the requests to the endpoints",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"max_retries": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Description:  "Maximum number of retries of the requests that failed with a transient error",
+				DefaultFunc:  schema.EnvDefaultFunc("OPENNEBULA_MAX_RETRIES", 3),
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			"retry_min_backoff": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Description:  "Delay in seconds before the first retry, doubled at each retry",
+				DefaultFunc:  schema.EnvDefaultFunc("OPENNEBULA_RETRY_MIN_BACKOFF", 1),
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			"retry_max_backoff": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Description:  "Maximum delay in seconds between two retries",
+				DefaultFunc:  schema.EnvDefaultFunc("OPENNEBULA_RETRY_MAX_BACKOFF", 30),
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			"default_tags": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				MaxItems:    1,
+				Description: "Tags added to all the resources that support tags",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"tags": {
+							Type:        schema.TypeMap,
+							Optional:    true,
+							Description: "Tags added to all the resources, overridden by the tags of the resource",
+							Elem: &schema.Schema{
+								Type: schema.TypeString,
+							},
+						},
+					},
+				},
+			},
 		},
 
 		DataSourcesMap: map[string]*schema.Resource{
-			"opennebula_cluster":               dataOpennebulaCluster(),
-			"opennebula_group":                 dataOpennebulaGroup(),
-			"opennebula_image":                 dataOpennebulaImage(),
-			"opennebula_security_group":        dataOpennebulaSecurityGroup(),
-			"opennebula_template":              dataOpennebulaTemplate(),
-			"opennebula_user":                  dataOpennebulaUser(),
-			"opennebula_virtual_data_center":   dataOpennebulaVirtualDataCenter(),
-			"opennebula_virtual_network":       dataOpennebulaVirtualNetwork(),
-			"opennebula_virtual_machine_group": dataOpennebulaVMGroup(),
+			"opennebula_cluster":                dataOpennebulaCluster(),
+			"opennebula_datastore":              dataOpennebulaDatastore(),
+			"opennebula_group":                  dataOpennebulaGroup(),
+			"opennebula_groups":                 dataOpennebulaGroups(),
+			"opennebula_host":                   dataOpennebulaHost(),
+			"opennebula_image":                  dataOpennebulaImage(),
+			"opennebula_images":                 dataOpennebulaImages(),
+			"opennebula_marketplace":            dataOpennebulaMarketPlace(),
+			"opennebula_marketplace_app":        dataOpennebulaMarketPlaceApp(),
+			"opennebula_security_group":         dataOpennebulaSecurityGroup(),
+			"opennebula_security_groups":        dataOpennebulaSecurityGroups(),
+			"opennebula_template":               dataOpennebulaTemplate(),
+			"opennebula_templates":              dataOpennebulaTemplates(),
+			"opennebula_user":                   dataOpennebulaUser(),
+			"opennebula_users":                  dataOpennebulaUsers(),
+			"opennebula_virtual_data_center":    dataOpennebulaVirtualDataCenter(),
+			"opennebula_virtual_machine":        dataOpennebulaVirtualMachine(),
+			"opennebula_virtual_machines":       dataOpennebulaVirtualMachines(),
+			"opennebula_virtual_network":        dataOpennebulaVirtualNetwork(),
+			"opennebula_virtual_networks":       dataOpennebulaVirtualNetworks(),
+			"opennebula_virtual_network_leases": dataOpennebulaVirtualNetworkLeases(),
+			"opennebula_virtual_machine_group":  dataOpennebulaVMGroup(),
 		},
 
 		ResourcesMap: map[string]*schema.Resource{
 			"opennebula_acl":                              resourceOpennebulaACL(),
+			"opennebula_cluster":                          resourceOpennebulaCluster(),
+			"opennebula_datastore":                        resourceOpennebulaDatastore(),
 			"opennebula_group":                            resourceOpennebulaGroup(),
 			"opennebula_group_admins":                     resourceOpennebulaGroupAdmins(),
+			"opennebula_host":                             resourceOpennebulaHost(),
 			"opennebula_image":                            resourceOpennebulaImage(),
+			"opennebula_marketplace":                      resourceOpennebulaMarketPlace(),
+			"opennebula_marketplace_app":                  resourceOpennebulaMarketPlaceApp(),
+			"opennebula_marketplace_app_export":           resourceOpennebulaMarketPlaceAppExport(),
 			"opennebula_security_group":                   resourceOpennebulaSecurityGroup(),
+			"opennebula_security_group_rule":              resourceOpennebulaSecurityGroupRule(),
 			"opennebula_template":                         resourceOpennebulaTemplate(),
 			"opennebula_user":                             resourceOpennebulaUser(),
 			"opennebula_virtual_data_center":              resourceOpennebulaVirtualDataCenter(),
 			"opennebula_virtual_machine":                  resourceOpennebulaVirtualMachine(),
+			"opennebula_virtual_machine_snapshot":         resourceOpennebulaVirtualMachineSnapshot(),
+			"opennebula_virtual_machine_disk_snapshot":    resourceOpennebulaVirtualMachineDiskSnapshot(),
 			"opennebula_virtual_network":                  resourceOpennebulaVirtualNetwork(),
+			"opennebula_virtual_network_template":         resourceOpennebulaVirtualNetworkTemplate(),
+			"opennebula_virtual_network_address_range":    resourceOpennebulaVirtualNetworkAddressRange(),
+			"opennebula_virtual_network_ip_hold":          resourceOpennebulaVirtualNetworkIPHold(),
 			"opennebula_virtual_machine_group":            resourceOpennebulaVMGroup(),
 			"opennebula_service":                          resourceOpennebulaService(),
 			"opennebula_service_template":                 resourceOpennebulaServiceTemplate(),
@@ -77,45 +222,74 @@ func Provider() *schema.Provider {
 }
 
 type Configuration struct {
-	OneVersion *ver.Version
-	Controller *goca.Controller
-	mutex      MutexKV
+	OneVersion  *ver.Version
+	Controller  *goca.Controller
+	Endpoint    string
+	DefaultTags map[string]interface{}
+	mutex       MutexKV
 }
 
 func providerConfigure(ctx context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
 
 	var diags diag.Diagnostics
 
-	username, ok := d.GetOk("username")
+	endpoint, ok := d.GetOk("endpoint")
 	if !ok {
 		diags = append(diags, diag.Diagnostic{
 			Severity: diag.Error,
-			Summary:  "username should be defined",
+			Summary:  "endpoint should be defined",
 		})
 		return nil, diags
 	}
 
-	password, ok := d.GetOk("password")
-	if !ok {
+	username, password, err := providerCredentials(d)
+	if err != nil {
 		diags = append(diags, diag.Diagnostic{
 			Severity: diag.Error,
-			Summary:  "password should be defined",
+			Summary:  "Failed to get the credentials",
+			Detail:   err.Error(),
 		})
 		return nil, diags
 	}
 
-	endpoint, ok := d.GetOk("endpoint")
-	if !ok {
+	httpClient, err := providerHTTPClient(d)
+	if err != nil {
 		diags = append(diags, diag.Diagnostic{
 			Severity: diag.Error,
-			Summary:  "endpoint should be defined",
+			Summary:  "Failed to configure the HTTP client",
+			Detail:   err.Error(),
 		})
 		return nil, diags
 	}
 
-	oneClient := goca.NewDefaultClient(goca.NewConfig(username.(string),
-		password.(string),
-		endpoint.(string)))
+	// the credentials are only sent once to get a short-lived token
+	if expiration := d.Get("login_token_expiration").(int); expiration > 0 {
+		password, err = generateLoginToken(httpClient, username, password, endpoint.(string), expiration)
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to generate a login token",
+				Detail:   err.Error(),
+			})
+			return nil, diags
+		}
+	}
+
+	retry := providerRetryConfig(d)
+
+	// the retries are interrupted when Terraform is stopped
+	stopCtx, ok := schema.StopContext(ctx)
+	if !ok {
+		stopCtx = context.Background()
+	}
+
+	oneClient := &retryCaller{
+		caller: goca.NewClient(goca.NewConfig(username,
+			password,
+			endpoint.(string)), httpClient),
+		config: retry,
+		ctx:    stopCtx,
+	}
 
 	versionStr, err := goca.NewController(oneClient).SystemVersion()
 	if err != nil {
@@ -140,22 +314,37 @@ func providerConfigure(ctx context.Context, d *schema.ResourceData) (interface{}
 
 	flowEndpoint, ok := d.GetOk("flow_endpoint")
 	if ok {
-		flowClient := goca.NewDefaultFlowClient(
-			goca.NewFlowConfig(username.(string),
-				password.(string),
-				flowEndpoint.(string)))
+		// the XML-RPC client retries at the request level, the Flow client at
+		// the HTTP level where the timeout applies to each attempt
+		flowHTTPClient := *httpClient
+		flowHTTPClient.Timeout = 0
+		flowHTTPClient.Transport = &retryTransport{
+			transport: httpClient.Transport,
+			config:    retry,
+			timeout:   httpClient.Timeout,
+			ctx:       stopCtx,
+		}
+
+		flowClient := goca.NewFlowClient(
+			goca.NewFlowConfig(username,
+				password,
+				flowEndpoint.(string)), &flowHTTPClient)
 
 		return &Configuration{
-			OneVersion: version,
-			Controller: goca.NewGenericController(oneClient, flowClient),
-			mutex:      *NewMutexKV(),
+			OneVersion:  version,
+			Controller:  goca.NewGenericController(oneClient, flowClient),
+			Endpoint:    endpoint.(string),
+			DefaultTags: providerDefaultTags(d),
+			mutex:       *NewMutexKV(),
 		}, nil
 
 	}
 
 	return &Configuration{
-		OneVersion: version,
-		Controller: goca.NewController(oneClient),
-		mutex:      *NewMutexKV(),
+		OneVersion:  version,
+		Controller:  goca.NewController(oneClient),
+		Endpoint:    endpoint.(string),
+		DefaultTags: providerDefaultTags(d),
+		mutex:       *NewMutexKV(),
 	}, nil
 }
@@ -0,0 +1,568 @@
+package opennebula
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/OpenNebula/one/src/oca/go/src/goca"
+	dyn "github.com/OpenNebula/one/src/oca/go/src/goca/dynamic"
+	"github.com/OpenNebula/one/src/oca/go/src/goca/parameters"
+	clusterSc "github.com/OpenNebula/one/src/oca/go/src/goca/schemas/cluster"
+)
+
+func resourceOpennebulaCluster() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceOpennebulaClusterCreate,
+		ReadContext:   resourceOpennebulaClusterRead,
+		Exists:        resourceOpennebulaClusterExists,
+		UpdateContext: resourceOpennebulaClusterUpdate,
+		DeleteContext: resourceOpennebulaClusterDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+		CustomizeDiff: tagsAllCustomizeDiff,
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Name of the Cluster",
+			},
+			"hosts": {
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Computed:    true,
+				Description: "List of host IDs part of the Cluster",
+				Elem: &schema.Schema{
+					Type: schema.TypeInt,
+				},
+			},
+			"datastores": {
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Computed:    true,
+				Description: "List of datastore IDs part of the Cluster",
+				Elem: &schema.Schema{
+					Type: schema.TypeInt,
+				},
+			},
+			"virtual_networks": {
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Computed:    true,
+				Description: "List of virtual network IDs part of the Cluster",
+				Elem: &schema.Schema{
+					Type: schema.TypeInt,
+				},
+			},
+			"tags":     tagsSchema(),
+			"tags_all": tagsAllSchema(),
+		},
+	}
+}
+
+func getClusterController(d *schema.ResourceData, meta interface{}) (*goca.ClusterController, error) {
+	config := meta.(*Configuration)
+	controller := config.Controller
+	var cc *goca.ClusterController
+
+	// Try to find the Cluster by ID, if specified
+	if d.Id() != "" {
+		id, err := strconv.ParseUint(d.Id(), 10, 0)
+		if err != nil {
+			return nil, err
+		}
+		cc = controller.Cluster(int(id))
+	}
+
+	// Otherwise, try to find the Cluster by name as the de facto compound primary key
+	if d.Id() == "" {
+		id, err := controller.Clusters().ByName(d.Get("name").(string))
+		if err != nil {
+			return nil, err
+		}
+		cc = controller.Cluster(id)
+	}
+
+	return cc, nil
+}
+
+func resourceOpennebulaClusterCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	config := meta.(*Configuration)
+	controller := config.Controller
+
+	var diags diag.Diagnostics
+
+	setTagsAll(d, meta)
+
+	clusterID, err := controller.Clusters().Create(d.Get("name").(string))
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to create the cluster",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+
+	d.SetId(fmt.Sprintf("%v", clusterID))
+
+	cc := controller.Cluster(clusterID)
+
+	clusterTpl := generateClusterTemplate(d)
+	if len(clusterTpl.Elements) > 0 {
+		err = cc.Update(clusterTpl.String(), parameters.Merge)
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to update content",
+				Detail:   fmt.Sprintf("cluster (ID: %s): %s", d.Id(), err),
+			})
+			return diags
+		}
+	}
+
+	if hosts, ok := d.GetOk("hosts"); ok {
+		for _, id := range hosts.(*schema.Set).List() {
+			err = cc.AddHost(id.(int))
+			if err != nil {
+				diags = append(diags, diag.Diagnostic{
+					Severity: diag.Error,
+					Summary:  "Failed to add a host",
+					Detail:   fmt.Sprintf("cluster (ID: %s): %s", d.Id(), err),
+				})
+				return diags
+			}
+		}
+	}
+
+	if datastores, ok := d.GetOk("datastores"); ok {
+		for _, id := range datastores.(*schema.Set).List() {
+			err = cc.AddDatastore(id.(int))
+			if err != nil {
+				diags = append(diags, diag.Diagnostic{
+					Severity: diag.Error,
+					Summary:  "Failed to add a datastore",
+					Detail:   fmt.Sprintf("cluster (ID: %s): %s", d.Id(), err),
+				})
+				return diags
+			}
+		}
+	}
+
+	if vnets, ok := d.GetOk("virtual_networks"); ok {
+		for _, id := range vnets.(*schema.Set).List() {
+			err = cc.AddVnet(id.(int))
+			if err != nil {
+				diags = append(diags, diag.Diagnostic{
+					Severity: diag.Error,
+					Summary:  "Failed to add a virtual network",
+					Detail:   fmt.Sprintf("cluster (ID: %s): %s", d.Id(), err),
+				})
+				return diags
+			}
+		}
+	}
+
+	return resourceOpennebulaClusterRead(ctx, d, meta)
+}
+
+func resourceOpennebulaClusterRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+
+	var diags diag.Diagnostics
+
+	cc, err := getClusterController(d, meta)
+	if err != nil {
+		if NoExists(err) {
+			log.Printf("[WARN] Removing cluster %s from state because it no longer exists in", d.Get("name"))
+			d.SetId("")
+			return nil
+		}
+
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to get the cluster controller",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+
+	cluster, err := cc.Info()
+	if err != nil {
+		if NoExists(err) {
+			log.Printf("[WARN] Removing cluster %s from state because it no longer exists in", d.Get("name"))
+			d.SetId("")
+			return nil
+		}
+
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to retrieve informations",
+			Detail:   fmt.Sprintf("cluster (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
+	d.SetId(fmt.Sprintf("%v", cluster.ID))
+	d.Set("name", cluster.Name)
+
+	err = d.Set("hosts", cluster.Hosts.ID)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to set attribute",
+			Detail:   fmt.Sprintf("cluster (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
+	err = d.Set("datastores", cluster.Datastores.ID)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to set attribute",
+			Detail:   fmt.Sprintf("cluster (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
+	err = d.Set("virtual_networks", cluster.Vnets.ID)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to set attribute",
+			Detail:   fmt.Sprintf("cluster (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
+	err = flattenClusterTemplate(d, &cluster.Template)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to flatten template",
+			Detail:   fmt.Sprintf("cluster (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
+	err = flattenTagsAll(d, meta, &cluster.Template.Template)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to set attribute",
+			Detail:   fmt.Sprintf("cluster (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
+	return nil
+}
+
+func flattenClusterTemplate(d *schema.ResourceData, clusterTpl *clusterSc.Template) error {
+
+	tags := make(map[string]interface{})
+	tagsInterface, tagsOk := d.GetOk("tags")
+	if !tagsOk {
+		return nil
+	}
+
+	for i, _ := range clusterTpl.Elements {
+		pair, ok := clusterTpl.Elements[i].(*dyn.Pair)
+		if !ok {
+			continue
+		}
+
+		// Get only tags described in the configuration
+		for k, _ := range tagsInterface.(map[string]interface{}) {
+			if strings.ToUpper(k) == pair.Key() {
+				tags[k] = pair.Value
+			}
+		}
+	}
+
+	return d.Set("tags", tags)
+}
+
+func resourceOpennebulaClusterExists(d *schema.ResourceData, meta interface{}) (bool, error) {
+	config := meta.(*Configuration)
+	controller := config.Controller
+
+	clusterID, err := strconv.ParseInt(d.Id(), 10, 0)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = controller.Cluster(int(clusterID)).Info()
+	if NoExists(err) {
+		return false, err
+	}
+
+	return true, err
+}
+
+// diffClusterMembers returns the IDs to remove and the IDs to add to reach the new membership
+func diffClusterMembers(d *schema.ResourceData, key string) ([]interface{}, []interface{}) {
+	oldIf, newIf := d.GetChange(key)
+	oldSet := oldIf.(*schema.Set)
+	newSet := newIf.(*schema.Set)
+
+	return oldSet.Difference(newSet).List(), newSet.Difference(oldSet).List()
+}
+
+func resourceOpennebulaClusterUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+
+	var diags diag.Diagnostics
+
+	setTagsAll(d, meta)
+
+	cc, err := getClusterController(d, meta)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to get the cluster controller",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+
+	cluster, err := cc.Info()
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to retrieve informations",
+			Detail:   fmt.Sprintf("cluster (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
+	if d.HasChange("name") {
+		err := cc.Rename(d.Get("name").(string))
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to rename",
+				Detail:   fmt.Sprintf("cluster (ID: %s): %s", d.Id(), err),
+			})
+			return diags
+		}
+		log.Printf("[INFO] Successfully updated name for Cluster %s\n", cluster.Name)
+	}
+
+	if d.HasChange("hosts") {
+		toRem, toAdd := diffClusterMembers(d, "hosts")
+
+		for _, id := range toRem {
+			err := cc.DelHost(id.(int))
+			if err != nil {
+				diags = append(diags, diag.Diagnostic{
+					Severity: diag.Error,
+					Summary:  "Failed to remove a host",
+					Detail:   fmt.Sprintf("cluster (ID: %s): %s", d.Id(), err),
+				})
+				return diags
+			}
+		}
+
+		for _, id := range toAdd {
+			err := cc.AddHost(id.(int))
+			if err != nil {
+				diags = append(diags, diag.Diagnostic{
+					Severity: diag.Error,
+					Summary:  "Failed to add a host",
+					Detail:   fmt.Sprintf("cluster (ID: %s): %s", d.Id(), err),
+				})
+				return diags
+			}
+		}
+		log.Printf("[INFO] Successfully updated hosts for Cluster %s\n", cluster.Name)
+	}
+
+	if d.HasChange("datastores") {
+		toRem, toAdd := diffClusterMembers(d, "datastores")
+
+		for _, id := range toRem {
+			err := cc.DelDatastore(id.(int))
+			if err != nil {
+				diags = append(diags, diag.Diagnostic{
+					Severity: diag.Error,
+					Summary:  "Failed to remove a datastore",
+					Detail:   fmt.Sprintf("cluster (ID: %s): %s", d.Id(), err),
+				})
+				return diags
+			}
+		}
+
+		for _, id := range toAdd {
+			err := cc.AddDatastore(id.(int))
+			if err != nil {
+				diags = append(diags, diag.Diagnostic{
+					Severity: diag.Error,
+					Summary:  "Failed to add a datastore",
+					Detail:   fmt.Sprintf("cluster (ID: %s): %s", d.Id(), err),
+				})
+				return diags
+			}
+		}
+		log.Printf("[INFO] Successfully updated datastores for Cluster %s\n", cluster.Name)
+	}
+
+	if d.HasChange("virtual_networks") {
+		toRem, toAdd := diffClusterMembers(d, "virtual_networks")
+
+		for _, id := range toRem {
+			err := cc.DelVnet(id.(int))
+			if err != nil {
+				diags = append(diags, diag.Diagnostic{
+					Severity: diag.Error,
+					Summary:  "Failed to remove a virtual network",
+					Detail:   fmt.Sprintf("cluster (ID: %s): %s", d.Id(), err),
+				})
+				return diags
+			}
+		}
+
+		for _, id := range toAdd {
+			err := cc.AddVnet(id.(int))
+			if err != nil {
+				diags = append(diags, diag.Diagnostic{
+					Severity: diag.Error,
+					Summary:  "Failed to add a virtual network",
+					Detail:   fmt.Sprintf("cluster (ID: %s): %s", d.Id(), err),
+				})
+				return diags
+			}
+		}
+		log.Printf("[INFO] Successfully updated virtual networks for Cluster %s\n", cluster.Name)
+	}
+
+	if d.HasChange("tags_all") {
+
+		tpl := cluster.Template
+
+		oldTags, newTags := tagsAllChange(d)
+
+		// delete tags
+		for k, _ := range oldTags {
+			_, ok := newTags[k]
+			if ok {
+				continue
+			}
+			tpl.Del(strings.ToUpper(k))
+		}
+
+		// add/update tags
+		for k, v := range newTags {
+			tpl.Del(strings.ToUpper(k))
+			tpl.AddPair(strings.ToUpper(k), v)
+		}
+
+		err = cc.Update(tpl.String(), parameters.Replace)
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to update content",
+				Detail:   fmt.Sprintf("cluster (ID: %s): %s", d.Id(), err),
+			})
+			return diags
+		}
+		log.Printf("[INFO] Successfully updated tags for Cluster %s\n", cluster.Name)
+	}
+
+	return resourceOpennebulaClusterRead(ctx, d, meta)
+}
+
+func resourceOpennebulaClusterDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+
+	var diags diag.Diagnostics
+
+	cc, err := getClusterController(d, meta)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to get the cluster controller",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+
+	cluster, err := cc.Info()
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to retrieve informations",
+			Detail:   fmt.Sprintf("cluster (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
+	// OpenNebula refuses to delete a cluster that still has members
+	for _, id := range cluster.Hosts.ID {
+		err = cc.DelHost(id)
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to remove a host",
+				Detail:   fmt.Sprintf("cluster (ID: %s): %s", d.Id(), err),
+			})
+			return diags
+		}
+	}
+
+	for _, id := range cluster.Datastores.ID {
+		err = cc.DelDatastore(id)
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to remove a datastore",
+				Detail:   fmt.Sprintf("cluster (ID: %s): %s", d.Id(), err),
+			})
+			return diags
+		}
+	}
+
+	for _, id := range cluster.Vnets.ID {
+		err = cc.DelVnet(id)
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to remove a virtual network",
+				Detail:   fmt.Sprintf("cluster (ID: %s): %s", d.Id(), err),
+			})
+			return diags
+		}
+	}
+
+	err = cc.Delete()
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to delete",
+			Detail:   fmt.Sprintf("cluster (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
+	log.Printf("[INFO] Successfully deleted Cluster ID %s\n", d.Id())
+	return nil
+}
+
+func generateClusterTemplate(d *schema.ResourceData) *clusterSc.Template {
+
+	tpl := &clusterSc.Template{}
+
+	tagsInterface := d.Get("tags_all").(map[string]interface{})
+	for k, v := range tagsInterface {
+		tpl.AddPair(strings.ToUpper(k), v)
+	}
+
+	log.Printf("[INFO] Cluster template: %s", tpl.String())
+
+	return tpl
+}
@@ -0,0 +1,182 @@
+package opennebula
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestAccCluster(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckClusterDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccClusterConfigBasic,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("opennebula_cluster.test", "name", "test-cluster"),
+					resource.TestCheckResourceAttr("opennebula_cluster.test", "hosts.#", "1"),
+					resource.TestCheckResourceAttr("opennebula_cluster.test", "datastores.#", "0"),
+					resource.TestCheckResourceAttr("opennebula_cluster.test", "virtual_networks.#", "0"),
+					resource.TestCheckResourceAttr("opennebula_cluster.test", "tags.%", "2"),
+					resource.TestCheckResourceAttr("opennebula_cluster.test", "tags.env", "prod"),
+					resource.TestCheckResourceAttr("opennebula_cluster.test", "tags.customer", "test"),
+					resource.TestCheckResourceAttr("opennebula_cluster.test", "tags_all.%", "2"),
+				),
+			},
+			{
+				Config: testAccClusterConfigUpdate,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("opennebula_cluster.test", "name", "test-cluster-renamed"),
+					resource.TestCheckResourceAttr("opennebula_cluster.test", "hosts.#", "1"),
+					resource.TestCheckResourceAttr("opennebula_cluster.test", "datastores.#", "1"),
+					resource.TestCheckResourceAttr("opennebula_cluster.test", "virtual_networks.#", "1"),
+					resource.TestCheckResourceAttr("opennebula_cluster.test", "tags.%", "3"),
+					resource.TestCheckResourceAttr("opennebula_cluster.test", "tags.env", "dev"),
+					resource.TestCheckResourceAttr("opennebula_cluster.test", "tags.customer", "test"),
+					resource.TestCheckResourceAttr("opennebula_cluster.test", "tags.version", "2"),
+					resource.TestCheckResourceAttr("opennebula_cluster.test", "tags_all.%", "3"),
+				),
+			},
+			{
+				Config: testAccClusterConfigDefaultTags,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("opennebula_cluster.test", "tags.%", "3"),
+					resource.TestCheckResourceAttr("opennebula_cluster.test", "tags.env", "dev"),
+					resource.TestCheckResourceAttr("opennebula_cluster.test", "tags_all.%", "4"),
+					resource.TestCheckResourceAttr("opennebula_cluster.test", "tags_all.env", "dev"),
+					resource.TestCheckResourceAttr("opennebula_cluster.test", "tags_all.owner", "ops"),
+				),
+			},
+		},
+	})
+}
+
+func testAccCheckClusterDestroy(s *terraform.State) error {
+	config := testAccProvider.Meta().(*Configuration)
+	controller := config.Controller
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "opennebula_cluster" {
+			continue
+		}
+		clusterID, _ := strconv.ParseUint(rs.Primary.ID, 10, 0)
+		cc := controller.Cluster(int(clusterID))
+		// Get Cluster Info
+		cluster, _ := cc.Info()
+		if cluster != nil {
+			return fmt.Errorf("Expected cluster %s to have been destroyed", rs.Primary.ID)
+		}
+	}
+
+	return nil
+}
+
+var testAccClusterConfigBasic = `
+resource "opennebula_host" "test" {
+  name   = "test-cluster-host"
+  im_mad = "dummy"
+  vm_mad = "dummy"
+}
+
+resource "opennebula_virtual_network" "test" {
+  name   = "test-cluster-vnet"
+  type   = "dummy"
+  bridge = "onebr"
+  mtu    = 1500
+  ar {
+    ar_type = "IP4"
+    size    = 16
+    ip4     = "172.16.100.1"
+  }
+}
+
+resource "opennebula_cluster" "test" {
+  name  = "test-cluster"
+  hosts = [opennebula_host.test.id]
+
+  tags = {
+    env      = "prod"
+    customer = "test"
+  }
+}
+`
+
+var testAccClusterConfigUpdate = `
+resource "opennebula_host" "test" {
+  name   = "test-cluster-host"
+  im_mad = "dummy"
+  vm_mad = "dummy"
+}
+
+resource "opennebula_virtual_network" "test" {
+  name   = "test-cluster-vnet"
+  type   = "dummy"
+  bridge = "onebr"
+  mtu    = 1500
+  ar {
+    ar_type = "IP4"
+    size    = 16
+    ip4     = "172.16.100.1"
+  }
+}
+
+resource "opennebula_cluster" "test" {
+  name             = "test-cluster-renamed"
+  hosts            = [opennebula_host.test.id]
+  datastores       = [1]
+  virtual_networks = [opennebula_virtual_network.test.id]
+
+  tags = {
+    env      = "dev"
+    customer = "test"
+    version  = "2"
+  }
+}
+`
+
+var testAccClusterConfigDefaultTags = `
+provider "opennebula" {
+  default_tags {
+    tags = {
+      env   = "default"
+      owner = "ops"
+    }
+  }
+}
+
+resource "opennebula_host" "test" {
+  name   = "test-cluster-host"
+  im_mad = "dummy"
+  vm_mad = "dummy"
+}
+
+resource "opennebula_virtual_network" "test" {
+  name   = "test-cluster-vnet"
+  type   = "dummy"
+  bridge = "onebr"
+  mtu    = 1500
+  ar {
+    ar_type = "IP4"
+    size    = 16
+    ip4     = "172.16.100.1"
+  }
+}
+
+resource "opennebula_cluster" "test" {
+  name             = "test-cluster-renamed"
+  hosts            = [opennebula_host.test.id]
+  datastores       = [1]
+  virtual_networks = [opennebula_virtual_network.test.id]
+
+  tags = {
+    env      = "dev"
+    customer = "test"
+    version  = "2"
+  }
+}
+`
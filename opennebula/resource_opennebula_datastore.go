@@ -0,0 +1,847 @@
+package opennebula
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/OpenNebula/one/src/oca/go/src/goca"
+	dyn "github.com/OpenNebula/one/src/oca/go/src/goca/dynamic"
+	"github.com/OpenNebula/one/src/oca/go/src/goca/parameters"
+	ds "github.com/OpenNebula/one/src/oca/go/src/goca/schemas/datastore"
+	dsk "github.com/OpenNebula/one/src/oca/go/src/goca/schemas/datastore/keys"
+)
+
+// datastoreTypes is ordered as the TYPE values returned by OpenNebula
+var datastoreTypes = []string{"IMAGE", "SYSTEM", "FILE"}
+
+// datastore template keys managed through the ceph section
+var datastoreCephKeys = map[string]string{
+	"pool_name":  "POOL_NAME",
+	"user":       "CEPH_USER",
+	"secret":     "CEPH_SECRET",
+	"rbd_format": "RBD_FORMAT",
+	"ceph_conf":  "CEPH_CONF",
+}
+
+// datastore template keys managed through the nfs section
+var datastoreNFSKeys = map[string]string{
+	"host":    "NFS_AUTO_HOST",
+	"path":    "NFS_AUTO_PATH",
+	"options": "NFS_AUTO_OPTS",
+}
+
+func resourceOpennebulaDatastore() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceOpennebulaDatastoreCreate,
+		ReadContext:   resourceOpennebulaDatastoreRead,
+		Exists:        resourceOpennebulaDatastoreExists,
+		UpdateContext: resourceOpennebulaDatastoreUpdate,
+		DeleteContext: resourceOpennebulaDatastoreDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+		CustomizeDiff: tagsAllCustomizeDiff,
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Name of the Datastore",
+			},
+			"type": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "IMAGE",
+				ForceNew:    true,
+				Description: "Type of the Datastore: IMAGE, SYSTEM, FILE. Default is 'IMAGE'",
+				ValidateFunc: func(v interface{}, k string) (ws []string, errors []error) {
+					value := v.(string)
+
+					if inArray(value, datastoreTypes) < 0 {
+						errors = append(errors, fmt.Errorf("Type %q must be one of: %s", k, strings.Join(datastoreTypes, ",")))
+					}
+
+					return
+				},
+			},
+			"ds_mad": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "Datastore driver, example: fs, ceph. Not used for SYSTEM datastores",
+			},
+			"tm_mad": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "Transfer driver, example: shared, ssh, qcow2, ceph",
+			},
+			"clusters": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Computed:    true,
+				Description: "List of cluster IDs hosting the Datastore, if not set it uses the default cluster",
+				Elem: &schema.Schema{
+					Type: schema.TypeInt,
+				},
+			},
+			"restricted_dirs": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Space separated list of paths that can't be used to register images",
+			},
+			"safe_dirs": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Space separated list of paths allowed inside of the restricted directories",
+			},
+			"bridge_list": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "List of hosts used to perform the datastore operations",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"staging_dir": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Path in the bridge hosts used to stage the images",
+			},
+			"ceph": {
+				Type:          schema.TypeList,
+				Optional:      true,
+				MaxItems:      1,
+				Description:   "Ceph specific attributes",
+				ConflictsWith: []string{"nfs"},
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"pool_name": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "Ceph pool name",
+						},
+						"user": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "Ceph user name",
+						},
+						"secret": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Sensitive:   true,
+							Description: "UUID of the libvirt secret holding the Ceph user key",
+						},
+						"host": {
+							Type:        schema.TypeList,
+							Optional:    true,
+							Description: "List of Ceph monitors",
+							Elem: &schema.Schema{
+								Type: schema.TypeString,
+							},
+						},
+						"rbd_format": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "RBD format of the images",
+						},
+						"ceph_conf": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "Non default Ceph configuration file",
+						},
+					},
+				},
+			},
+			"nfs": {
+				Type:          schema.TypeList,
+				Optional:      true,
+				MaxItems:      1,
+				Description:   "Automatic NFS mount of the datastore on the hosts",
+				ConflictsWith: []string{"ceph"},
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"host": {
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "NFS server, example: 10.0.0.1",
+						},
+						"path": {
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "Path exported by the NFS server",
+						},
+						"options": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "Mount options",
+						},
+					},
+				},
+			},
+			"permissions": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "Permissions for the Datastore (in Unix format, owner-group-other, use-manage-admin)",
+				ValidateFunc: func(v interface{}, k string) (ws []string, errors []error) {
+					value := v.(string)
+
+					if len(value) != 3 {
+						errors = append(errors, fmt.Errorf("%q has specify 3 permission sets: owner-group-other", k))
+					}
+
+					all := true
+					for _, c := range strings.Split(value, "") {
+						if c < "0" || c > "7" {
+							all = false
+						}
+					}
+					if !all {
+						errors = append(errors, fmt.Errorf("Each character in %q should specify a Unix-like permission set with a number from 0 to 7", k))
+					}
+
+					return
+				},
+			},
+			"uid": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "ID of the user that will own the Datastore",
+			},
+			"gid": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "ID of the group that will own the Datastore",
+			},
+			"uname": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Name of the user that will own the Datastore",
+			},
+			"gname": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Name of the group that will own the Datastore",
+			},
+			"group": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Name of the Group that onws the Datastore, If empty, it uses caller group",
+			},
+			"tags":     tagsSchema(),
+			"tags_all": tagsAllSchema(),
+		},
+	}
+}
+
+func getDatastoreController(d *schema.ResourceData, meta interface{}) (*goca.DatastoreController, error) {
+	config := meta.(*Configuration)
+	controller := config.Controller
+	var dc *goca.DatastoreController
+
+	// Try to find the Datastore by ID, if specified
+	if d.Id() != "" {
+		id, err := strconv.ParseUint(d.Id(), 10, 0)
+		if err != nil {
+			return nil, err
+		}
+		dc = controller.Datastore(int(id))
+	}
+
+	// Otherwise, try to find the Datastore by name as the de facto compound primary key
+	if d.Id() == "" {
+		id, err := controller.ByName(d.Get("name").(string))
+		if err != nil {
+			return nil, err
+		}
+		dc = controller.Datastore(id)
+	}
+
+	return dc, nil
+}
+
+func changeDatastoreGroup(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Configuration)
+	controller := config.Controller
+	var gid int
+
+	dc, err := getDatastoreController(d, meta)
+	if err != nil {
+		return err
+	}
+
+	if d.Get("group") != "" {
+		group := d.Get("group").(string)
+		gid, err = controller.Groups().ByName(group)
+		if err != nil {
+			return fmt.Errorf("Can't find a group with name `%s`: %s", group, err)
+		}
+	}
+
+	err = dc.Chown(-1, gid)
+	if err != nil {
+		return fmt.Errorf("Can't find a group with ID `%d`: %s", gid, err)
+	}
+
+	return nil
+}
+
+func resourceOpennebulaDatastoreCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	config := meta.(*Configuration)
+	controller := config.Controller
+
+	var diags diag.Diagnostics
+
+	setTagsAll(d, meta)
+
+	dsDef := generateDatastore(d)
+
+	clusters := getClustersValue(d)
+
+	dsID, err := controller.Datastores().Create(dsDef, clusters[0])
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to create the datastore",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+
+	d.SetId(fmt.Sprintf("%v", dsID))
+
+	dc := controller.Datastore(dsID)
+
+	// Set Clusters (first in list is already set)
+	for _, clusterID := range clusters[1:] {
+		err = controller.Cluster(clusterID).AddDatastore(dsID)
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to set cluster",
+				Detail:   fmt.Sprintf("datastore (ID: %s): %s", d.Id(), err),
+			})
+			return diags
+		}
+	}
+
+	if perms, ok := d.GetOk("permissions"); ok {
+		permissions := permissionUnix(perms.(string))
+		err = dc.Chmod(&permissions)
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to change permissions",
+				Detail:   fmt.Sprintf("datastore (ID: %s): %s", d.Id(), err),
+			})
+			return diags
+		}
+	}
+
+	if d.Get("group") != "" {
+		err = changeDatastoreGroup(d, meta)
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to change group",
+				Detail:   fmt.Sprintf("datastore (ID: %s): %s", d.Id(), err),
+			})
+			return diags
+		}
+	}
+
+	return resourceOpennebulaDatastoreRead(ctx, d, meta)
+}
+
+func resourceOpennebulaDatastoreRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+
+	var diags diag.Diagnostics
+
+	dc, err := getDatastoreController(d, meta)
+	if err != nil {
+		if NoExists(err) {
+			log.Printf("[WARN] Removing datastore %s from state because it no longer exists in", d.Get("name"))
+			d.SetId("")
+			return nil
+		}
+
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to get the datastore controller",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+
+	datastore, err := dc.Info(false)
+	if err != nil {
+		if NoExists(err) {
+			log.Printf("[WARN] Removing datastore %s from state because it no longer exists in", d.Get("name"))
+			d.SetId("")
+			return nil
+		}
+
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to retrieve informations",
+			Detail:   fmt.Sprintf("datastore (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
+	d.SetId(fmt.Sprintf("%v", datastore.ID))
+	d.Set("name", datastore.Name)
+
+	dsType, err := strconv.Atoi(datastore.Type)
+	if err == nil && dsType >= 0 && dsType < len(datastoreTypes) {
+		d.Set("type", datastoreTypes[dsType])
+	}
+
+	d.Set("ds_mad", datastore.DSMad)
+	d.Set("tm_mad", datastore.TMMad)
+	d.Set("uid", datastore.UID)
+	d.Set("gid", datastore.GID)
+	d.Set("uname", datastore.UName)
+	d.Set("gname", datastore.GName)
+	d.Set("permissions", permissionsUnixString(*datastore.Permissions))
+
+	err = d.Set("clusters", datastore.Clusters.ID)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to set attribute",
+			Detail:   fmt.Sprintf("datastore (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
+	err = flattenDatastoreTemplate(d, &datastore.Template)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to flatten template",
+			Detail:   fmt.Sprintf("datastore (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
+	err = flattenTagsAll(d, meta, &datastore.Template.Template)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to set attribute",
+			Detail:   fmt.Sprintf("datastore (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
+	return nil
+}
+
+func flattenDatastoreTemplate(d *schema.ResourceData, dsTpl *ds.Template) error {
+
+	ceph := make(map[string]interface{})
+	nfs := make(map[string]interface{})
+	tags := make(map[string]interface{})
+	tagsInterface, tagsOk := d.GetOk("tags")
+
+	for i, _ := range dsTpl.Elements {
+		pair, ok := dsTpl.Elements[i].(*dyn.Pair)
+		if !ok {
+			continue
+		}
+
+		switch pair.Key() {
+		case string(dsk.RestrictedDirs):
+			d.Set("restricted_dirs", pair.Value)
+		case string(dsk.SafeDirs):
+			d.Set("safe_dirs", pair.Value)
+		case string(dsk.StagingDir):
+			d.Set("staging_dir", pair.Value)
+		case string(dsk.BridgeList):
+			d.Set("bridge_list", strings.Fields(pair.Value))
+		case "CEPH_HOST":
+			ceph["host"] = strings.Fields(pair.Value)
+		case "POOL_NAME", "CEPH_USER", "CEPH_SECRET", "RBD_FORMAT", "CEPH_CONF":
+			for k, key := range datastoreCephKeys {
+				if key == pair.Key() {
+					ceph[k] = pair.Value
+				}
+			}
+		case "NFS_AUTO_HOST", "NFS_AUTO_PATH", "NFS_AUTO_OPTS":
+			for k, key := range datastoreNFSKeys {
+				if key == pair.Key() {
+					nfs[k] = pair.Value
+				}
+			}
+		default:
+			// Get only tags described in the configuration
+			if tagsOk {
+				for k, _ := range tagsInterface.(map[string]interface{}) {
+					if strings.ToUpper(k) == pair.Key() {
+						tags[k] = pair.Value
+					}
+				}
+			}
+		}
+	}
+
+	// the ceph section is read only when configured as some keys are
+	// also used by other drivers
+	if _, ok := d.GetOk("ceph"); ok && len(ceph) > 0 {
+		err := d.Set("ceph", []interface{}{ceph})
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(nfs) > 0 {
+		err := d.Set("nfs", []interface{}{nfs})
+		if err != nil {
+			return err
+		}
+	}
+
+	if tagsOk {
+		err := d.Set("tags", tags)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func resourceOpennebulaDatastoreExists(d *schema.ResourceData, meta interface{}) (bool, error) {
+	config := meta.(*Configuration)
+	controller := config.Controller
+
+	dsID, err := strconv.ParseInt(d.Id(), 10, 0)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = controller.Datastore(int(dsID)).Info(false)
+	if NoExists(err) {
+		return false, err
+	}
+
+	return true, err
+}
+
+func resourceOpennebulaDatastoreUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	config := meta.(*Configuration)
+	controller := config.Controller
+
+	var diags diag.Diagnostics
+
+	setTagsAll(d, meta)
+
+	dc, err := getDatastoreController(d, meta)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to get the datastore controller",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+
+	datastore, err := dc.Info(false)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to retrieve informations",
+			Detail:   fmt.Sprintf("datastore (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
+	if d.HasChange("name") {
+		err := dc.Rename(d.Get("name").(string))
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to rename",
+				Detail:   fmt.Sprintf("datastore (ID: %s): %s", d.Id(), err),
+			})
+			return diags
+		}
+		log.Printf("[INFO] Successfully updated name for Datastore %s\n", datastore.Name)
+	}
+
+	if d.HasChange("clusters") {
+		oldIf, newIf := d.GetChange("clusters")
+		oldSet := schema.NewSet(schema.HashInt, oldIf.([]interface{}))
+		newSet := schema.NewSet(schema.HashInt, newIf.([]interface{}))
+
+		// add first to avoid leaving the datastore without any cluster
+		for _, id := range newSet.Difference(oldSet).List() {
+			err := controller.Cluster(id.(int)).AddDatastore(datastore.ID)
+			if err != nil {
+				diags = append(diags, diag.Diagnostic{
+					Severity: diag.Error,
+					Summary:  "Failed to add the datastore to a cluster",
+					Detail:   fmt.Sprintf("datastore (ID: %s): %s", d.Id(), err),
+				})
+				return diags
+			}
+		}
+
+		for _, id := range oldSet.Difference(newSet).List() {
+			err := controller.Cluster(id.(int)).DelDatastore(datastore.ID)
+			if err != nil {
+				diags = append(diags, diag.Diagnostic{
+					Severity: diag.Error,
+					Summary:  "Failed to remove the datastore from a cluster",
+					Detail:   fmt.Sprintf("datastore (ID: %s): %s", d.Id(), err),
+				})
+				return diags
+			}
+		}
+		log.Printf("[INFO] Successfully updated clusters for Datastore %s\n", datastore.Name)
+	}
+
+	update := false
+	tpl := datastore.Template
+
+	if d.HasChange("ds_mad") {
+		tpl.Del(string(dsk.DSMAD))
+		if v, ok := d.GetOk("ds_mad"); ok {
+			tpl.Add(dsk.DSMAD, v.(string))
+		}
+		update = true
+	}
+
+	if d.HasChange("tm_mad") {
+		tpl.Del(string(dsk.TMMAD))
+		if v, ok := d.GetOk("tm_mad"); ok {
+			tpl.Add(dsk.TMMAD, v.(string))
+		}
+		update = true
+	}
+
+	if d.HasChange("restricted_dirs") {
+		tpl.Del(string(dsk.RestrictedDirs))
+		if v, ok := d.GetOk("restricted_dirs"); ok {
+			tpl.Add(dsk.RestrictedDirs, v.(string))
+		}
+		update = true
+	}
+
+	if d.HasChange("safe_dirs") {
+		tpl.Del(string(dsk.SafeDirs))
+		if v, ok := d.GetOk("safe_dirs"); ok {
+			tpl.Add(dsk.SafeDirs, v.(string))
+		}
+		update = true
+	}
+
+	if d.HasChange("staging_dir") {
+		tpl.Del(string(dsk.StagingDir))
+		if v, ok := d.GetOk("staging_dir"); ok {
+			tpl.Add(dsk.StagingDir, v.(string))
+		}
+		update = true
+	}
+
+	if d.HasChange("bridge_list") {
+		tpl.Del(string(dsk.BridgeList))
+		if v, ok := d.GetOk("bridge_list"); ok {
+			tpl.Add(dsk.BridgeList, ArrayToString(v.([]interface{}), " "))
+		}
+		update = true
+	}
+
+	if d.HasChange("ceph") {
+		tpl.Del("CEPH_HOST")
+		for _, key := range datastoreCephKeys {
+			tpl.Del(key)
+		}
+		addDatastoreCeph(d, &tpl)
+		update = true
+	}
+
+	if d.HasChange("nfs") {
+		tpl.Del("NFS_AUTO_ENABLE")
+		for _, key := range datastoreNFSKeys {
+			tpl.Del(key)
+		}
+		addDatastoreNFS(d, &tpl)
+		update = true
+	}
+
+	if d.HasChange("tags_all") {
+
+		oldTags, newTags := tagsAllChange(d)
+
+		// delete tags
+		for k, _ := range oldTags {
+			_, ok := newTags[k]
+			if ok {
+				continue
+			}
+			tpl.Del(strings.ToUpper(k))
+		}
+
+		// add/update tags
+		for k, v := range newTags {
+			tpl.Del(strings.ToUpper(k))
+			tpl.AddPair(strings.ToUpper(k), v)
+		}
+
+		update = true
+	}
+
+	if update {
+		err = dc.Update(tpl.String(), parameters.Replace)
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to update content",
+				Detail:   fmt.Sprintf("datastore (ID: %s): %s", d.Id(), err),
+			})
+			return diags
+		}
+		log.Printf("[INFO] Successfully updated template for Datastore %s\n", datastore.Name)
+	}
+
+	if d.HasChange("permissions") {
+		if perms, ok := d.GetOk("permissions"); ok {
+			permissions := permissionUnix(perms.(string))
+			err = dc.Chmod(&permissions)
+			if err != nil {
+				diags = append(diags, diag.Diagnostic{
+					Severity: diag.Error,
+					Summary:  "Failed to change permissions",
+					Detail:   fmt.Sprintf("datastore (ID: %s): %s", d.Id(), err),
+				})
+				return diags
+			}
+		}
+		log.Printf("[INFO] Successfully updated permissions for Datastore %s\n", datastore.Name)
+	}
+
+	if d.HasChange("group") {
+		err = changeDatastoreGroup(d, meta)
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to change group",
+				Detail:   fmt.Sprintf("datastore (ID: %s): %s", d.Id(), err),
+			})
+			return diags
+		}
+		log.Printf("[INFO] Successfully updated group for Datastore %s\n", datastore.Name)
+	}
+
+	return resourceOpennebulaDatastoreRead(ctx, d, meta)
+}
+
+func resourceOpennebulaDatastoreDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+
+	var diags diag.Diagnostics
+
+	dc, err := getDatastoreController(d, meta)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to get the datastore controller",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+
+	err = dc.Delete()
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to delete",
+			Detail:   fmt.Sprintf("datastore (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
+	log.Printf("[INFO] Successfully deleted Datastore ID %s\n", d.Id())
+	return nil
+}
+
+func addDatastoreCeph(d *schema.ResourceData, tpl *ds.Template) {
+	cephList := d.Get("ceph").([]interface{})
+	if len(cephList) == 0 || cephList[0] == nil {
+		return
+	}
+
+	ceph := cephList[0].(map[string]interface{})
+	for k, key := range datastoreCephKeys {
+		if v := ceph[k].(string); len(v) > 0 {
+			tpl.AddPair(key, v)
+		}
+	}
+
+	if hosts := ceph["host"].([]interface{}); len(hosts) > 0 {
+		tpl.AddPair("CEPH_HOST", ArrayToString(hosts, " "))
+	}
+}
+
+func addDatastoreNFS(d *schema.ResourceData, tpl *ds.Template) {
+	nfsList := d.Get("nfs").([]interface{})
+	if len(nfsList) == 0 || nfsList[0] == nil {
+		return
+	}
+
+	nfs := nfsList[0].(map[string]interface{})
+	tpl.AddPair("NFS_AUTO_ENABLE", "yes")
+	for k, key := range datastoreNFSKeys {
+		if v := nfs[k].(string); len(v) > 0 {
+			tpl.AddPair(key, v)
+		}
+	}
+}
+
+func generateDatastore(d *schema.ResourceData) string {
+
+	tpl := ds.NewTemplate()
+
+	tpl.Add(dsk.Name, d.Get("name").(string))
+	tpl.Add(dsk.Type, d.Get("type").(string)+"_DS")
+
+	if v, ok := d.GetOk("ds_mad"); ok {
+		tpl.Add(dsk.DSMAD, v.(string))
+	}
+	if v, ok := d.GetOk("tm_mad"); ok {
+		tpl.Add(dsk.TMMAD, v.(string))
+	}
+	if v, ok := d.GetOk("restricted_dirs"); ok {
+		tpl.Add(dsk.RestrictedDirs, v.(string))
+	}
+	if v, ok := d.GetOk("safe_dirs"); ok {
+		tpl.Add(dsk.SafeDirs, v.(string))
+	}
+	if v, ok := d.GetOk("staging_dir"); ok {
+		tpl.Add(dsk.StagingDir, v.(string))
+	}
+	if v, ok := d.GetOk("bridge_list"); ok {
+		tpl.Add(dsk.BridgeList, ArrayToString(v.([]interface{}), " "))
+	}
+
+	addDatastoreCeph(d, tpl)
+	addDatastoreNFS(d, tpl)
+
+	tagsInterface := d.Get("tags_all").(map[string]interface{})
+	for k, v := range tagsInterface {
+		tpl.AddPair(strings.ToUpper(k), v)
+	}
+
+	tplStr := tpl.String()
+	log.Printf("[INFO] Datastore definition: %s", tplStr)
+
+	return tplStr
+}
@@ -0,0 +1,125 @@
+package opennebula
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestAccDatastore(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckDatastoreDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDatastoreConfigBasic,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("opennebula_datastore.test", "name", "test-datastore"),
+					resource.TestCheckResourceAttr("opennebula_datastore.test", "type", "IMAGE"),
+					resource.TestCheckResourceAttr("opennebula_datastore.test", "ds_mad", "dummy"),
+					resource.TestCheckResourceAttr("opennebula_datastore.test", "tm_mad", "dummy"),
+					resource.TestCheckResourceAttr("opennebula_datastore.test", "clusters.#", "1"),
+					resource.TestCheckResourceAttr("opennebula_datastore.test", "clusters.0", "0"),
+					resource.TestCheckResourceAttr("opennebula_datastore.test", "restricted_dirs", "/"),
+					resource.TestCheckResourceAttr("opennebula_datastore.test", "permissions", "642"),
+					resource.TestCheckResourceAttr("opennebula_datastore.test", "tags.%", "2"),
+					resource.TestCheckResourceAttr("opennebula_datastore.test", "tags.env", "prod"),
+					resource.TestCheckResourceAttr("opennebula_datastore.test", "tags.customer", "test"),
+					resource.TestCheckResourceAttrSet("opennebula_datastore.test", "uid"),
+					resource.TestCheckResourceAttrSet("opennebula_datastore.test", "gid"),
+					resource.TestCheckResourceAttrSet("opennebula_datastore.test", "uname"),
+					resource.TestCheckResourceAttrSet("opennebula_datastore.test", "gname"),
+					resource.TestCheckResourceAttr("opennebula_datastore.system", "type", "SYSTEM"),
+					resource.TestCheckResourceAttr("opennebula_datastore.system", "tm_mad", "dummy"),
+				),
+			},
+			{
+				Config: testAccDatastoreConfigUpdate,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("opennebula_datastore.test", "name", "test-datastore-renamed"),
+					resource.TestCheckResourceAttr("opennebula_datastore.test", "restricted_dirs", "/etc"),
+					resource.TestCheckResourceAttr("opennebula_datastore.test", "safe_dirs", "/var/tmp"),
+					resource.TestCheckResourceAttr("opennebula_datastore.test", "bridge_list.#", "2"),
+					resource.TestCheckResourceAttr("opennebula_datastore.test", "bridge_list.0", "host1"),
+					resource.TestCheckResourceAttr("opennebula_datastore.test", "bridge_list.1", "host2"),
+					resource.TestCheckResourceAttr("opennebula_datastore.test", "permissions", "660"),
+					resource.TestCheckResourceAttr("opennebula_datastore.test", "tags.%", "3"),
+					resource.TestCheckResourceAttr("opennebula_datastore.test", "tags.env", "dev"),
+					resource.TestCheckResourceAttr("opennebula_datastore.test", "tags.customer", "test"),
+					resource.TestCheckResourceAttr("opennebula_datastore.test", "tags.version", "2"),
+				),
+			},
+		},
+	})
+}
+
+func testAccCheckDatastoreDestroy(s *terraform.State) error {
+	config := testAccProvider.Meta().(*Configuration)
+	controller := config.Controller
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "opennebula_datastore" {
+			continue
+		}
+		dsID, _ := strconv.ParseUint(rs.Primary.ID, 10, 0)
+		dc := controller.Datastore(int(dsID))
+		// Get Datastore Info
+		datastore, _ := dc.Info(false)
+		if datastore != nil {
+			return fmt.Errorf("Expected datastore %s to have been destroyed", rs.Primary.ID)
+		}
+	}
+
+	return nil
+}
+
+var testAccDatastoreConfigBasic = `
+resource "opennebula_datastore" "test" {
+  name            = "test-datastore"
+  type            = "IMAGE"
+  ds_mad          = "dummy"
+  tm_mad          = "dummy"
+  restricted_dirs = "/"
+  permissions     = "642"
+
+  tags = {
+    env      = "prod"
+    customer = "test"
+  }
+}
+
+resource "opennebula_datastore" "system" {
+  name   = "test-system-datastore"
+  type   = "SYSTEM"
+  tm_mad = "dummy"
+}
+`
+
+var testAccDatastoreConfigUpdate = `
+resource "opennebula_datastore" "test" {
+  name            = "test-datastore-renamed"
+  type            = "IMAGE"
+  ds_mad          = "dummy"
+  tm_mad          = "dummy"
+  restricted_dirs = "/etc"
+  safe_dirs       = "/var/tmp"
+  bridge_list     = ["host1", "host2"]
+  permissions     = "660"
+
+  tags = {
+    env      = "dev"
+    customer = "test"
+    version  = "2"
+  }
+}
+
+resource "opennebula_datastore" "system" {
+  name   = "test-system-datastore"
+  type   = "SYSTEM"
+  tm_mad = "dummy"
+}
+`
@@ -25,6 +25,7 @@ func resourceOpennebulaGroup() *schema.Resource {
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
+		CustomizeDiff: tagsAllCustomizeDiff,
 
 		Schema: map[string]*schema.Schema{
 			"name": {
@@ -101,6 +102,7 @@ func resourceOpennebulaGroup() *schema.Resource {
 				s.ConflictsWith = []string{"template"}
 				return s
 			}(),
+			"tags_all": tagsAllSchema(),
 		},
 	}
 }
@@ -137,6 +139,8 @@ func resourceOpennebulaGroupCreate(ctx context.Context, d *schema.ResourceData,
 
 	var diags diag.Diagnostics
 
+	setTagsAll(d, meta)
+
 	groupID, err := controller.Groups().Create(d.Get("name").(string))
 	if err != nil {
 		diags = append(diags, diag.Diagnostic{
@@ -228,7 +232,7 @@ func resourceOpennebulaGroupCreate(ctx context.Context, d *schema.ResourceData,
 		tpl.Elements = append(tpl.Elements, sunstoneVec)
 	}
 
-	tagsInterface := d.Get("tags").(map[string]interface{})
+	tagsInterface := d.Get("tags_all").(map[string]interface{})
 	for k, v := range tagsInterface {
 		tpl.AddPair(strings.ToUpper(k), v)
 	}
@@ -373,6 +377,16 @@ func resourceOpennebulaGroupRead(ctx context.Context, d *schema.ResourceData, me
 		return diags
 	}
 
+	err = flattenTagsAll(d, meta, &group.Template)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to set attribute",
+			Detail:   fmt.Sprintf("group (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
 	return nil
 }
 
@@ -439,6 +453,8 @@ func resourceOpennebulaGroupUpdate(ctx context.Context, d *schema.ResourceData,
 
 	var diags diag.Diagnostics
 
+	setTagsAll(d, meta)
+
 	gc, err := getGroupController(d, meta)
 	if err != nil {
 		diags = append(diags, diag.Diagnostic{
@@ -515,11 +531,9 @@ func resourceOpennebulaGroupUpdate(ctx context.Context, d *schema.ResourceData,
 		update = true
 	}
 
-	if d.HasChange("tags") {
+	if d.HasChange("tags_all") {
 
-		oldTagsIf, newTagsIf := d.GetChange("tags")
-		oldTags := oldTagsIf.(map[string]interface{})
-		newTags := newTagsIf.(map[string]interface{})
+		oldTags, newTags := tagsAllChange(d)
 
 		// delete tags
 		for k, _ := range oldTags {
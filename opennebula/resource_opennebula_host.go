@@ -0,0 +1,606 @@
+package opennebula
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/OpenNebula/one/src/oca/go/src/goca"
+	dyn "github.com/OpenNebula/one/src/oca/go/src/goca/dynamic"
+	"github.com/OpenNebula/one/src/oca/go/src/goca/parameters"
+	hostSc "github.com/OpenNebula/one/src/oca/go/src/goca/schemas/host"
+	hostk "github.com/OpenNebula/one/src/oca/go/src/goca/schemas/host/keys"
+)
+
+var hostStatuses = []string{"ENABLED", "DISABLED", "OFFLINE"}
+var defaultHostTimeout = time.Duration(5) * time.Minute
+
+func resourceOpennebulaHost() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceOpennebulaHostCreate,
+		ReadContext:   resourceOpennebulaHostRead,
+		Exists:        resourceOpennebulaHostExists,
+		UpdateContext: resourceOpennebulaHostUpdate,
+		DeleteContext: resourceOpennebulaHostDelete,
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(defaultHostTimeout),
+			Update: schema.DefaultTimeout(defaultHostTimeout),
+		},
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+		CustomizeDiff: tagsAllCustomizeDiff,
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Name of the Host",
+			},
+			"im_mad": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Information driver of the Host, example: kvm, lxc, dummy",
+			},
+			"vm_mad": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Virtualization driver of the Host, example: kvm, lxc, dummy",
+			},
+			"cluster_id": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Computed:    true,
+				Description: "ID of the cluster hosting the Host, if not set it uses the default cluster",
+			},
+			"status": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "ENABLED",
+				Description: "Status of the Host: ENABLED, DISABLED, OFFLINE. Default is 'ENABLED'",
+				ValidateFunc: func(v interface{}, k string) (ws []string, errors []error) {
+					value := v.(string)
+
+					if inArray(value, hostStatuses) < 0 {
+						errors = append(errors, fmt.Errorf("Status %q must be one of: %s", k, strings.Join(hostStatuses, ",")))
+					}
+
+					return
+				},
+			},
+			"state": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Current state of the Host",
+			},
+			"overcommit": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				MaxItems:    1,
+				Description: "Overcommitment of the Host capacity",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"reserved_cpu": {
+							Type:        schema.TypeInt,
+							Optional:    true,
+							Description: "CPU reserved (in percentage of a CPU, 100 is a full CPU). A negative value increases the capacity of the Host",
+						},
+						"reserved_memory": {
+							Type:        schema.TypeInt,
+							Optional:    true,
+							Description: "Memory reserved (in KB). A negative value increases the capacity of the Host",
+						},
+					},
+				},
+			},
+			"tags":     tagsSchema(),
+			"tags_all": tagsAllSchema(),
+		},
+	}
+}
+
+func getHostController(d *schema.ResourceData, meta interface{}) (*goca.HostController, error) {
+	config := meta.(*Configuration)
+	controller := config.Controller
+	var hc *goca.HostController
+
+	// Try to find the Host by ID, if specified
+	if d.Id() != "" {
+		id, err := strconv.ParseUint(d.Id(), 10, 0)
+		if err != nil {
+			return nil, err
+		}
+		hc = controller.Host(int(id))
+	}
+
+	// Otherwise, try to find the Host by name as the de facto compound primary key
+	if d.Id() == "" {
+		id, err := controller.Hosts().ByName(d.Get("name").(string))
+		if err != nil {
+			return nil, err
+		}
+		hc = controller.Host(id)
+	}
+
+	return hc, nil
+}
+
+// hostStatusToInt converts the status attribute to the value expected by the one.host.status call
+func hostStatusToInt(status string) int {
+	return inArray(status, hostStatuses)
+}
+
+// hostStatusFromState returns the status attribute matching the current state of the Host
+func hostStatusFromState(state hostSc.State) string {
+	switch state {
+	case hostSc.Disabled, hostSc.MonitoringDisabled:
+		return "DISABLED"
+	case hostSc.Offline:
+		return "OFFLINE"
+	default:
+		return "ENABLED"
+	}
+}
+
+// hostTargetStates returns the Host states to wait for after a status change
+func hostTargetStates(status string) []string {
+	switch status {
+	case "DISABLED":
+		return []string{hostSc.State(hostSc.Disabled).String()}
+	case "OFFLINE":
+		return []string{hostSc.State(hostSc.Offline).String()}
+	default:
+		return []string{hostSc.State(hostSc.Monitored).String()}
+	}
+}
+
+func resourceOpennebulaHostCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	config := meta.(*Configuration)
+	controller := config.Controller
+
+	var diags diag.Diagnostics
+
+	setTagsAll(d, meta)
+
+	clusterID := -1
+	if v, ok := d.GetOkExists("cluster_id"); ok {
+		clusterID = v.(int)
+	}
+
+	hostID, err := controller.Hosts().Create(d.Get("name").(string),
+		d.Get("im_mad").(string),
+		d.Get("vm_mad").(string),
+		clusterID)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to create the host",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+
+	d.SetId(fmt.Sprintf("%v", hostID))
+
+	hc := controller.Host(hostID)
+
+	hostTpl := generateHostTemplate(d)
+	if len(hostTpl.Elements) > 0 {
+		err = hc.Update(hostTpl.String(), parameters.Merge)
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to update content",
+				Detail:   fmt.Sprintf("host (ID: %s): %s", d.Id(), err),
+			})
+			return diags
+		}
+	}
+
+	status := d.Get("status").(string)
+	if status != "ENABLED" {
+		err = hc.Status(hostStatusToInt(status))
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to change status",
+				Detail:   fmt.Sprintf("host (ID: %s): %s", d.Id(), err),
+			})
+			return diags
+		}
+	}
+
+	_, err = waitForHostState(ctx, hc, d.Timeout(schema.TimeoutCreate), hostTargetStates(status)...)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  fmt.Sprintf("Failed to wait host to be in %s state", strings.Join(hostTargetStates(status), ", ")),
+			Detail:   fmt.Sprintf("host (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
+	return resourceOpennebulaHostRead(ctx, d, meta)
+}
+
+func waitForHostState(ctx context.Context, hc *goca.HostController, timeout time.Duration, target ...string) (interface{}, error) {
+
+	stateConf := &resource.StateChangeConf{
+		Pending: []string{"anythingelse"},
+		Target:  target,
+		Refresh: func() (interface{}, string, error) {
+
+			log.Println("Refreshing Host state...")
+
+			hostInfos, err := hc.Info(false)
+			if err != nil {
+				if NoExists(err) {
+					return hostInfos, "notfound", nil
+				}
+				return hostInfos, "", err
+			}
+			state, err := hostInfos.State()
+			if err != nil {
+				return hostInfos, "", err
+			}
+
+			log.Printf("Host (ID:%d, name:%s) is currently in state %v", hostInfos.ID, hostInfos.Name, state.String())
+
+			switch state {
+			case hostSc.Monitored, hostSc.Disabled, hostSc.Offline:
+				// a status change may not be applied yet, keep waiting
+				if inArray(state.String(), target) < 0 {
+					return hostInfos, "anythingelse", nil
+				}
+				return hostInfos, state.String(), nil
+			case hostSc.Error:
+				return hostInfos, state.String(), fmt.Errorf("Host (ID:%d) entered error state.", hostInfos.ID)
+			default:
+				return hostInfos, "anythingelse", nil
+			}
+		},
+		Timeout:    timeout,
+		Delay:      10 * time.Second,
+		MinTimeout: 3 * time.Second,
+	}
+
+	return stateConf.WaitForStateContext(ctx)
+}
+
+func resourceOpennebulaHostRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+
+	var diags diag.Diagnostics
+
+	hc, err := getHostController(d, meta)
+	if err != nil {
+		if NoExists(err) {
+			log.Printf("[WARN] Removing host %s from state because it no longer exists in", d.Get("name"))
+			d.SetId("")
+			return nil
+		}
+
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to get the host controller",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+
+	host, err := hc.Info(false)
+	if err != nil {
+		if NoExists(err) {
+			log.Printf("[WARN] Removing host %s from state because it no longer exists in", d.Get("name"))
+			d.SetId("")
+			return nil
+		}
+
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to retrieve informations",
+			Detail:   fmt.Sprintf("host (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
+	d.SetId(fmt.Sprintf("%v", host.ID))
+	d.Set("name", host.Name)
+	d.Set("im_mad", host.IMMAD)
+	d.Set("vm_mad", host.VMMAD)
+	d.Set("cluster_id", host.ClusterID)
+
+	state, err := host.State()
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to retrieve state",
+			Detail:   fmt.Sprintf("host (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+	d.Set("state", state.String())
+	d.Set("status", hostStatusFromState(state))
+
+	err = flattenHostTemplate(d, &host.Template)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to flatten template",
+			Detail:   fmt.Sprintf("host (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
+	err = flattenTagsAll(d, meta, &host.Template.Template)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to set attribute",
+			Detail:   fmt.Sprintf("host (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
+	return nil
+}
+
+func flattenHostTemplate(d *schema.ResourceData, hostTpl *hostSc.Template) error {
+
+	overcommit := make(map[string]interface{})
+	tags := make(map[string]interface{})
+	tagsInterface, tagsOk := d.GetOk("tags")
+
+	for i, _ := range hostTpl.Elements {
+		pair, ok := hostTpl.Elements[i].(*dyn.Pair)
+		if !ok {
+			continue
+		}
+
+		switch pair.Key() {
+		case string(hostk.ReservedCPU):
+			cpu, err := hostTpl.GetI(hostk.ReservedCPU)
+			if err == nil {
+				overcommit["reserved_cpu"] = cpu
+			}
+		case string(hostk.ReservedMem):
+			mem, err := hostTpl.GetI(hostk.ReservedMem)
+			if err == nil {
+				overcommit["reserved_memory"] = mem
+			}
+		default:
+			// Get only tags described in the configuration
+			if tagsOk {
+				for k, _ := range tagsInterface.(map[string]interface{}) {
+					if strings.ToUpper(k) == pair.Key() {
+						tags[k] = pair.Value
+					}
+				}
+			}
+		}
+	}
+
+	if _, ok := d.GetOk("overcommit"); ok && len(overcommit) > 0 {
+		err := d.Set("overcommit", []interface{}{overcommit})
+		if err != nil {
+			return err
+		}
+	}
+
+	if tagsOk {
+		err := d.Set("tags", tags)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func resourceOpennebulaHostExists(d *schema.ResourceData, meta interface{}) (bool, error) {
+	config := meta.(*Configuration)
+	controller := config.Controller
+
+	hostID, err := strconv.ParseInt(d.Id(), 10, 0)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = controller.Host(int(hostID)).Info(false)
+	if NoExists(err) {
+		return false, err
+	}
+
+	return true, err
+}
+
+func resourceOpennebulaHostUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	config := meta.(*Configuration)
+	controller := config.Controller
+
+	var diags diag.Diagnostics
+
+	setTagsAll(d, meta)
+
+	hc, err := getHostController(d, meta)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to get the host controller",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+
+	host, err := hc.Info(false)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to retrieve informations",
+			Detail:   fmt.Sprintf("host (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
+	if d.HasChange("name") {
+		err := hc.Rename(d.Get("name").(string))
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to rename",
+				Detail:   fmt.Sprintf("host (ID: %s): %s", d.Id(), err),
+			})
+			return diags
+		}
+		log.Printf("[INFO] Successfully updated name for Host %s\n", host.Name)
+	}
+
+	if d.HasChange("cluster_id") {
+		clusterID := d.Get("cluster_id").(int)
+		err := controller.Cluster(clusterID).AddHost(host.ID)
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to change cluster",
+				Detail:   fmt.Sprintf("host (ID: %s): %s", d.Id(), err),
+			})
+			return diags
+		}
+		log.Printf("[INFO] Successfully moved Host %s to cluster %d\n", host.Name, clusterID)
+	}
+
+	update := false
+	tpl := host.Template
+
+	if d.HasChange("overcommit") {
+		tpl.Del(string(hostk.ReservedCPU))
+		tpl.Del(string(hostk.ReservedMem))
+
+		overcommit := d.Get("overcommit").([]interface{})
+		if len(overcommit) > 0 && overcommit[0] != nil {
+			overcommitMap := overcommit[0].(map[string]interface{})
+			tpl.Add(hostk.ReservedCPU, overcommitMap["reserved_cpu"].(int))
+			tpl.Add(hostk.ReservedMem, overcommitMap["reserved_memory"].(int))
+		}
+
+		update = true
+	}
+
+	if d.HasChange("tags_all") {
+
+		oldTags, newTags := tagsAllChange(d)
+
+		// delete tags
+		for k, _ := range oldTags {
+			_, ok := newTags[k]
+			if ok {
+				continue
+			}
+			tpl.Del(strings.ToUpper(k))
+		}
+
+		// add/update tags
+		for k, v := range newTags {
+			tpl.Del(strings.ToUpper(k))
+			tpl.AddPair(strings.ToUpper(k), v)
+		}
+
+		update = true
+	}
+
+	if update {
+		err = hc.Update(tpl.String(), parameters.Replace)
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to update content",
+				Detail:   fmt.Sprintf("host (ID: %s): %s", d.Id(), err),
+			})
+			return diags
+		}
+		log.Printf("[INFO] Successfully updated template for Host %s\n", host.Name)
+	}
+
+	if d.HasChange("status") {
+		status := d.Get("status").(string)
+		err = hc.Status(hostStatusToInt(status))
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to change status",
+				Detail:   fmt.Sprintf("host (ID: %s): %s", d.Id(), err),
+			})
+			return diags
+		}
+
+		_, err = waitForHostState(ctx, hc, d.Timeout(schema.TimeoutUpdate), hostTargetStates(status)...)
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  fmt.Sprintf("Failed to wait host to be in %s state", strings.Join(hostTargetStates(status), ", ")),
+				Detail:   fmt.Sprintf("host (ID: %s): %s", d.Id(), err),
+			})
+			return diags
+		}
+		log.Printf("[INFO] Successfully updated status for Host %s\n", host.Name)
+	}
+
+	return resourceOpennebulaHostRead(ctx, d, meta)
+}
+
+func resourceOpennebulaHostDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+
+	var diags diag.Diagnostics
+
+	hc, err := getHostController(d, meta)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to get the host controller",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+
+	err = hc.Delete()
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to delete",
+			Detail:   fmt.Sprintf("host (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
+	log.Printf("[INFO] Successfully deleted Host ID %s\n", d.Id())
+	return nil
+}
+
+func generateHostTemplate(d *schema.ResourceData) *hostSc.Template {
+
+	tpl := hostSc.NewTemplate()
+
+	overcommit := d.Get("overcommit").([]interface{})
+	if len(overcommit) > 0 && overcommit[0] != nil {
+		overcommitMap := overcommit[0].(map[string]interface{})
+		tpl.Add(hostk.ReservedCPU, overcommitMap["reserved_cpu"].(int))
+		tpl.Add(hostk.ReservedMem, overcommitMap["reserved_memory"].(int))
+	}
+
+	tagsInterface := d.Get("tags_all").(map[string]interface{})
+	for k, v := range tagsInterface {
+		tpl.AddPair(strings.ToUpper(k), v)
+	}
+
+	log.Printf("[INFO] Host template: %s", tpl.String())
+
+	return tpl
+}
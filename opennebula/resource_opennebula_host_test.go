@@ -0,0 +1,109 @@
+package opennebula
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestAccHost(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckHostDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccHostConfigBasic,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("opennebula_host.test", "name", "test-host"),
+					resource.TestCheckResourceAttr("opennebula_host.test", "im_mad", "dummy"),
+					resource.TestCheckResourceAttr("opennebula_host.test", "vm_mad", "dummy"),
+					resource.TestCheckResourceAttr("opennebula_host.test", "cluster_id", "0"),
+					resource.TestCheckResourceAttr("opennebula_host.test", "status", "ENABLED"),
+					resource.TestCheckResourceAttr("opennebula_host.test", "state", "MONITORED"),
+					resource.TestCheckResourceAttr("opennebula_host.test", "overcommit.#", "1"),
+					resource.TestCheckResourceAttr("opennebula_host.test", "overcommit.0.reserved_cpu", "100"),
+					resource.TestCheckResourceAttr("opennebula_host.test", "overcommit.0.reserved_memory", "1048576"),
+					resource.TestCheckResourceAttr("opennebula_host.test", "tags.%", "2"),
+					resource.TestCheckResourceAttr("opennebula_host.test", "tags.env", "prod"),
+					resource.TestCheckResourceAttr("opennebula_host.test", "tags.customer", "test"),
+				),
+			},
+			{
+				Config: testAccHostConfigUpdate,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("opennebula_host.test", "name", "test-host-renamed"),
+					resource.TestCheckResourceAttr("opennebula_host.test", "status", "DISABLED"),
+					resource.TestCheckResourceAttr("opennebula_host.test", "state", "DISABLED"),
+					resource.TestCheckResourceAttr("opennebula_host.test", "overcommit.#", "1"),
+					resource.TestCheckResourceAttr("opennebula_host.test", "overcommit.0.reserved_cpu", "-100"),
+					resource.TestCheckResourceAttr("opennebula_host.test", "overcommit.0.reserved_memory", "0"),
+					resource.TestCheckResourceAttr("opennebula_host.test", "tags.%", "3"),
+					resource.TestCheckResourceAttr("opennebula_host.test", "tags.env", "dev"),
+					resource.TestCheckResourceAttr("opennebula_host.test", "tags.customer", "test"),
+					resource.TestCheckResourceAttr("opennebula_host.test", "tags.version", "2"),
+				),
+			},
+		},
+	})
+}
+
+func testAccCheckHostDestroy(s *terraform.State) error {
+	config := testAccProvider.Meta().(*Configuration)
+	controller := config.Controller
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "opennebula_host" {
+			continue
+		}
+		hostID, _ := strconv.ParseUint(rs.Primary.ID, 10, 0)
+		hc := controller.Host(int(hostID))
+		// Get Host Info
+		host, _ := hc.Info(false)
+		if host != nil {
+			return fmt.Errorf("Expected host %s to have been destroyed", rs.Primary.ID)
+		}
+	}
+
+	return nil
+}
+
+var testAccHostConfigBasic = `
+resource "opennebula_host" "test" {
+  name   = "test-host"
+  im_mad = "dummy"
+  vm_mad = "dummy"
+
+  overcommit {
+    reserved_cpu    = 100
+    reserved_memory = 1048576
+  }
+
+  tags = {
+    env      = "prod"
+    customer = "test"
+  }
+}
+`
+
+var testAccHostConfigUpdate = `
+resource "opennebula_host" "test" {
+  name   = "test-host-renamed"
+  im_mad = "dummy"
+  vm_mad = "dummy"
+  status = "DISABLED"
+
+  overcommit {
+    reserved_cpu = -100
+  }
+
+  tags = {
+    env      = "dev"
+    customer = "test"
+    version  = "2"
+  }
+}
+`
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 
@@ -39,6 +40,7 @@ func resourceOpennebulaImage() *schema.Resource {
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
+		CustomizeDiff: customdiff.All(resourceImageCustomizeDiff, tagsAllCustomizeDiff),
 
 		Schema: map[string]*schema.Schema{
 			"name": {
@@ -102,7 +104,7 @@ func resourceOpennebulaImage() *schema.Resource {
 				Optional:      true,
 				ForceNew:      true,
 				Description:   "ID or name of the Image to be cloned from",
-				ConflictsWith: []string{"path", "size", "type"},
+				ConflictsWith: []string{"path", "size", "type", "content", "source_file"},
 			},
 			"datastore_id": {
 				Type:        schema.TypeInt,
@@ -123,7 +125,32 @@ func resourceOpennebulaImage() *schema.Resource {
 				Computed:      true,
 				ForceNew:      true,
 				Description:   "Path to the new image (local path on the OpenNebula server or URL)",
-				ConflictsWith: []string{"clone_from_image"},
+				ConflictsWith: []string{"clone_from_image", "content", "source_file"},
+			},
+			"content": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ForceNew:      true,
+				Description:   "Inline content of the new image, served by the provider to OpenNebula",
+				ConflictsWith: []string{"clone_from_image", "path", "source_file"},
+			},
+			"source_file": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ForceNew:      true,
+				Description:   "Path to a local file on the provider host, served by the provider to OpenNebula",
+				ConflictsWith: []string{"clone_from_image", "path", "content"},
+			},
+			"source_address": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Address (host[:port]) on which content or source_file is served, it must be reachable from the OpenNebula frontend",
+			},
+			"source_checksum": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				ForceNew:    true,
+				Description: "SHA256 checksum of content or source_file, computed when the image is created",
 			},
 			"type": {
 				Type:          schema.TypeString,
@@ -173,6 +200,33 @@ func resourceOpennebulaImage() *schema.Resource {
 				Computed:    true,
 				Description: "Image format, normally 'raw' or 'qcow2'",
 			},
+			"md5": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"clone_from_image"},
+				StateFunc:     imageChecksumStateFunc,
+				Description:   "Expected MD5 checksum of the image content, verified once the image is downloaded",
+			},
+			"sha1": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"clone_from_image"},
+				StateFunc:     imageChecksumStateFunc,
+				Description:   "Expected SHA1 checksum of the image content, verified once the image is downloaded",
+			},
+			"sha256": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"clone_from_image"},
+				StateFunc:     imageChecksumStateFunc,
+				Description:   "Expected SHA256 checksum of the image content, verified once the image is downloaded",
+			},
 			"timeout": {
 				Type:        schema.TypeInt,
 				Optional:    true,
@@ -185,7 +239,8 @@ func resourceOpennebulaImage() *schema.Resource {
 				Optional:    true,
 				Description: "Name of the Group that onws the Image, If empty, it uses caller group",
 			},
-			"tags": tagsSchema(),
+			"tags":     tagsSchema(),
+			"tags_all": tagsAllSchema(),
 		},
 	}
 }
@@ -256,6 +311,8 @@ func resourceOpennebulaImageCreate(ctx context.Context, d *schema.ResourceData,
 	var err error
 	var diags diag.Diagnostics
 
+	setTagsAll(d, meta)
+
 	// Check if Image ID for cloning is set
 	if len(d.Get("clone_from_image").(string)) > 0 {
 		imageID, err = resourceOpennebulaImageClone(d, meta)
@@ -270,6 +327,35 @@ func resourceOpennebulaImageCreate(ctx context.Context, d *schema.ResourceData,
 	} else { //Otherwise allocate a new image
 		var err error
 
+		// Serve the local content until the image is READY
+		_, contentOk := d.GetOk("content")
+		_, sourceFileOk := d.GetOk("source_file")
+		if contentOk || sourceFileOk {
+			checksum, err := imageSourceChecksum(d.Get("content").(string), d.Get("source_file").(string))
+			if err != nil {
+				diags = append(diags, diag.Diagnostic{
+					Severity: diag.Error,
+					Summary:  "Failed to compute the image content checksum",
+					Detail:   err.Error(),
+				})
+				return diags
+			}
+			d.Set("source_checksum", checksum)
+
+			server, err := newImageSourceServer(d, d.Get("source_address").(string), config.Endpoint)
+			if err != nil {
+				diags = append(diags, diag.Diagnostic{
+					Severity: diag.Error,
+					Summary:  "Failed to serve the image content",
+					Detail:   err.Error(),
+				})
+				return diags
+			}
+			defer server.Close()
+
+			d.Set("path", server.URL)
+		}
+
 		imgDef, err := generateImage(d)
 		if err != nil {
 			diags = append(diags, diag.Diagnostic{
@@ -308,7 +394,7 @@ func resourceOpennebulaImageCreate(ctx context.Context, d *schema.ResourceData,
 		timeout = d.Timeout(schema.TimeoutCreate)
 	}
 
-	_, err = waitForImageState(ctx, ic, timeout, "READY")
+	imgInfos, err := waitForImageState(ctx, ic, timeout, "READY")
 	if err != nil {
 		diags = append(diags, diag.Diagnostic{
 			Severity: diag.Error,
@@ -318,6 +404,18 @@ func resourceOpennebulaImageCreate(ctx context.Context, d *schema.ResourceData,
 		return diags
 	}
 
+	err = checkImageChecksums(d, imgInfos.(*image.Image))
+	if err != nil {
+		// keep the image in the state to have it tainted
+		d.SetId(fmt.Sprintf("%v", imageID))
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Image checksum mismatch",
+			Detail:   fmt.Sprintf("image (ID: %d): %s", imageID, err),
+		})
+		return diags
+	}
+
 	// add template information into image
 	err = ic.Update(imgTpl, 1)
 	if err != nil {
@@ -513,8 +611,10 @@ func resourceOpennebulaImageRead(ctx context.Context, d *schema.ResourceData, me
 	}
 	d.Set("path", image.Path)
 
-	if inArray(image.Type, imagetypes) >= 0 {
-		d.Set("type", image.Type)
+	// OpenNebula returns the index of the type
+	imageType := imageTypeString(image.Type)
+	if inArray(imageType, imagetypes) >= 0 {
+		d.Set("type", imageType)
 	}
 
 	tags := make(map[string]interface{})
@@ -550,6 +650,15 @@ func resourceOpennebulaImageRead(ctx context.Context, d *schema.ResourceData, me
 				d.Set("description", desc)
 			}
 
+		case "MD5":
+			d.Set("md5", pair.Value)
+
+		case "SHA1":
+			d.Set("sha1", pair.Value)
+
+		case "SHA256":
+			d.Set("sha256", pair.Value)
+
 		default:
 			if tagsOk {
 				for k, _ := range tagsInterface.(map[string]interface{}) {
@@ -577,6 +686,16 @@ func resourceOpennebulaImageRead(ctx context.Context, d *schema.ResourceData, me
 		d.Set("lock", LockLevelToString(image.LockInfos.Locked))
 	}
 
+	err = flattenTagsAll(d, meta, &image.Template.Template)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to set attribute",
+			Detail:   fmt.Sprintf("image (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
 	return nil
 }
 
@@ -602,6 +721,8 @@ func resourceOpennebulaImageUpdate(ctx context.Context, d *schema.ResourceData,
 
 	var diags diag.Diagnostics
 
+	setTagsAll(d, meta)
+
 	//Get Image
 	ic, err := getImageController(d, meta)
 	if err != nil {
@@ -723,11 +844,9 @@ func resourceOpennebulaImageUpdate(ctx context.Context, d *schema.ResourceData,
 		update = true
 	}
 
-	if d.HasChange("tags") {
+	if d.HasChange("tags_all") {
 
-		oldTagsIf, newTagsIf := d.GetChange("tags")
-		oldTags := oldTagsIf.(map[string]interface{})
-		newTags := newTagsIf.(map[string]interface{})
+		oldTags, newTags := tagsAllChange(d)
 
 		// delete tags
 		for k, _ := range oldTags {
@@ -853,6 +972,19 @@ func generateImage(d *schema.ResourceData) (string, error) {
 		tpl.Add(imk.Path, val.(string))
 	}
 
+	// MD5 and SHA1 are verified by the datastore drivers while downloading
+	if val, ok := d.GetOk("md5"); ok {
+		tpl.Add(imk.Md5, strings.ToLower(val.(string)))
+	}
+
+	if val, ok := d.GetOk("sha1"); ok {
+		tpl.Add(imk.Sha1, strings.ToLower(val.(string)))
+	}
+
+	if val, ok := d.GetOk("sha256"); ok {
+		tpl.AddPair("SHA256", strings.ToLower(val.(string)))
+	}
+
 	tplStr := tpl.String()
 	log.Printf("[INFO] Image definition: %s", tplStr)
 
@@ -883,7 +1015,7 @@ func generateImageTemplate(d *schema.ResourceData) (string, error) {
 		tpl.Add(imk.Target, val.(string))
 	}
 
-	tagsInterface := d.Get("tags").(map[string]interface{})
+	tagsInterface := d.Get("tags_all").(map[string]interface{})
 	for k, v := range tagsInterface {
 		tpl.AddPair(strings.ToUpper(k), v)
 	}
@@ -893,3 +1025,88 @@ func generateImageTemplate(d *schema.ResourceData) (string, error) {
 
 	return str, nil
 }
+
+// imageTypeString returns the name of an image type, OpenNebula returning
+// the index of the type in imagetypes
+func imageTypeString(imageType string) string {
+
+	i, err := strconv.Atoi(imageType)
+	if err != nil || i < 0 || i >= len(imagetypes) {
+		return imageType
+	}
+
+	return imagetypes[i]
+}
+
+func imageChecksumStateFunc(v interface{}) string {
+	return strings.ToLower(v.(string))
+}
+
+// checkImageChecksums compares the expected checksums with the values reported
+// by OpenNebula in the image template
+func checkImageChecksums(d *schema.ResourceData, imgInfos *image.Image) error {
+
+	checksums := map[string]string{
+		"md5":    "MD5",
+		"sha1":   "SHA1",
+		"sha256": "SHA256",
+	}
+
+	for attr, key := range checksums {
+		expected, ok := d.GetOk(attr)
+		if !ok {
+			continue
+		}
+
+		reported, err := imgInfos.Template.GetStr(key)
+		if err != nil {
+			return fmt.Errorf("no %s checksum reported by OpenNebula", attr)
+		}
+
+		if !strings.EqualFold(expected.(string), reported) {
+			return fmt.Errorf("expected %s checksum %s, OpenNebula reported %s", attr, expected.(string), reported)
+		}
+	}
+
+	return nil
+}
+
+func resourceImageCustomizeDiff(ctx context.Context, diff *schema.ResourceDiff, v interface{}) error {
+
+	content := diff.Get("content").(string)
+	sourceFile := diff.Get("source_file").(string)
+
+	if len(content) == 0 && len(sourceFile) == 0 {
+		return nil
+	}
+
+	// The local file is only read when the image is created or its source
+	// changes, it may be missing for an existing image, i.e. on another runner
+	if diff.Id() != "" && !diff.HasChange("content") && !diff.HasChange("source_file") {
+		return nil
+	}
+
+	checksum, err := imageSourceChecksum(content, sourceFile)
+	if err != nil {
+		// The file may be generated during the apply, the checksum is then
+		// computed on creation
+		log.Printf("[WARN] Failed to compute the image content checksum, keeping %q: %s", diff.Get("source_checksum").(string), err)
+		return nil
+	}
+
+	sha256, sha256Ok := diff.GetOk("sha256")
+
+	// The local content is verified before being uploaded, the checksums
+	// reported by OpenNebula are verified once the image is READY
+	if sha256Ok && !strings.EqualFold(sha256.(string), checksum) {
+		return fmt.Errorf("Image content checksum mismatch: expected sha256 %s, got %s", sha256.(string), checksum)
+	}
+
+	if diff.Get("source_checksum").(string) == checksum {
+		return nil
+	}
+
+	log.Printf("[INFO] Image content checksum changed to %s", checksum)
+
+	return diff.SetNew("source_checksum", checksum)
+}
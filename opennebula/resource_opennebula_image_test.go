@@ -2,13 +2,19 @@ package opennebula
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
+	"regexp"
 	"strconv"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 
+	"github.com/OpenNebula/one/src/oca/go/src/goca/schemas/image"
 	"github.com/OpenNebula/one/src/oca/go/src/goca/schemas/shared"
 )
 
@@ -46,6 +52,17 @@ func TestAccImage(t *testing.T) {
 					}, "test-image-datablock"),
 				),
 			},
+			{
+				// the type is read from OpenNebula
+				ResourceName: "opennebula_image.testimage",
+				ImportState:  true,
+				ImportStateCheck: func(states []*terraform.InstanceState) error {
+					if len(states) != 1 || states[0].Attributes["type"] != "DATABLOCK" {
+						return fmt.Errorf("Expected the imported image type to be DATABLOCK")
+					}
+					return nil
+				},
+			},
 			{
 				Config: testAccImageConfigDatablockLocked,
 				Check: resource.ComposeTestCheckFunc(
@@ -109,6 +126,124 @@ func TestAccImage(t *testing.T) {
 	})
 }
 
+func TestImageTypeString(t *testing.T) {
+
+	cases := []struct {
+		imageType string
+		expected  string
+	}{
+		{"0", "OS"},
+		{"2", "DATABLOCK"},
+		{"5", "CONTEXT"},
+		{"DATABLOCK", "DATABLOCK"},
+		{"42", "42"},
+	}
+
+	for _, c := range cases {
+		if imageType := imageTypeString(c.imageType); imageType != c.expected {
+			t.Errorf("%s: expected %s, got %s", c.imageType, c.expected, imageType)
+		}
+	}
+}
+
+func TestCheckImageChecksums(t *testing.T) {
+
+	imgInfos := &image.Image{}
+	imgInfos.Template.AddPair("MD5", "d604a220708aa59433ba410986cd4ffa")
+	imgInfos.Template.AddPair("SHA256", "bfdeaeb08cffb6a36438bcd12dda25417e3cdd36f1e7e482a2849d539225288b")
+
+	cases := []struct {
+		name string
+		raw  map[string]interface{}
+		ok   bool
+	}{
+		{"no checksum", map[string]interface{}{}, true},
+		{"md5", map[string]interface{}{"md5": "D604A220708AA59433BA410986CD4FFA"}, true},
+		{"sha256", map[string]interface{}{"sha256": "bfdeaeb08cffb6a36438bcd12dda25417e3cdd36f1e7e482a2849d539225288b"}, true},
+		{"md5 mismatch", map[string]interface{}{"md5": "9db6f074fca0a903137b91c7c866b21d"}, false},
+		{"sha1 not reported", map[string]interface{}{"sha1": "9db6f074fca0a903137b91c7c866b21d4e7205a7"}, false},
+	}
+
+	for _, c := range cases {
+		d := schema.TestResourceDataRaw(t, resourceOpennebulaImage().Schema, c.raw)
+		err := checkImageChecksums(d, imgInfos)
+		if (err == nil) != c.ok {
+			t.Errorf("%s: unexpected result: %v", c.name, err)
+		}
+	}
+}
+
+func TestAccImageContent(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckImageDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccImageConfigContent,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("opennebula_image.testcontent", "name", "test-image-content"),
+					resource.TestCheckResourceAttr("opennebula_image.testcontent", "datastore_id", "2"),
+					resource.TestCheckResourceAttr("opennebula_image.testcontent", "type", "CONTEXT"),
+					resource.TestCheckResourceAttr("opennebula_image.testcontent", "source_checksum", "bfdeaeb08cffb6a36438bcd12dda25417e3cdd36f1e7e482a2849d539225288b"),
+					resource.TestCheckResourceAttrSet("opennebula_image.testcontent", "path"),
+					resource.TestCheckResourceAttr("opennebula_image.testcontent", "md5", "d604a220708aa59433ba410986cd4ffa"),
+					resource.TestCheckResourceAttr("opennebula_image.testcontent", "sha1", "9db6f074fca0a903137b91c7c866b21d4e7205a7"),
+					resource.TestCheckResourceAttr("opennebula_image.testcontent", "sha256", "bfdeaeb08cffb6a36438bcd12dda25417e3cdd36f1e7e482a2849d539225288b"),
+				),
+			},
+			{
+				Config:      testAccImageConfigContentBadChecksum,
+				ExpectError: regexp.MustCompile("Image content checksum mismatch"),
+			},
+			{
+				Config: testAccImageConfigContentUpdate,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("opennebula_image.testcontent", "name", "test-image-content"),
+					resource.TestCheckResourceAttr("opennebula_image.testcontent", "type", "CONTEXT"),
+					resource.TestCheckResourceAttr("opennebula_image.testcontent", "source_checksum", "9a2bff7288ac2a72fe3a2a8c420f9a1a348b229ecdc16453605f82278ff4fc55"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccImageSourceFile(t *testing.T) {
+
+	dir, cleanup := testTempDir(t)
+	defer cleanup()
+	sourceFile := filepath.Join(dir, "user-data")
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckImageDestroy,
+		Steps: []resource.TestStep{
+			{
+				PreConfig: func() {
+					err := ioutil.WriteFile(sourceFile, []byte("#!/bin/sh\necho hello\n"), 0600)
+					if err != nil {
+						t.Fatal(err)
+					}
+				},
+				Config: fmt.Sprintf(testAccImageConfigSourceFile, sourceFile),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("opennebula_image.testsourcefile", "source_file", sourceFile),
+					resource.TestCheckResourceAttr("opennebula_image.testsourcefile", "source_checksum", "bfdeaeb08cffb6a36438bcd12dda25417e3cdd36f1e7e482a2849d539225288b"),
+				),
+			},
+			{
+				// the local file isn't needed anymore once the image is created
+				PreConfig: func() {
+					os.Remove(sourceFile)
+				},
+				Config:   fmt.Sprintf(testAccImageConfigSourceFile, sourceFile),
+				PlanOnly: true,
+			},
+		},
+	})
+}
+
 func testAccCheckImageDestroy(s *terraform.State) error {
 	config := testAccProvider.Meta().(*Configuration)
 	controller := config.Controller
@@ -220,3 +355,43 @@ resource "opennebula_image" "testimage" {
    lock = "UNLOCK"
 }
 `
+
+var testAccImageConfigContent = `
+resource "opennebula_image" "testcontent" {
+   name = "test-image-content"
+   datastore_id = 2
+   type = "CONTEXT"
+   content = "#!/bin/sh\necho hello\n"
+   md5 = "d604a220708aa59433ba410986cd4ffa"
+   sha1 = "9DB6F074FCA0A903137B91C7C866B21D4E7205A7"
+   sha256 = "BFDEAEB08CFFB6A36438BCD12DDA25417E3CDD36F1E7E482A2849D539225288B"
+}
+`
+
+var testAccImageConfigContentUpdate = `
+resource "opennebula_image" "testcontent" {
+   name = "test-image-content"
+   datastore_id = 2
+   type = "CONTEXT"
+   content = "#!/bin/sh\necho world\n"
+}
+`
+
+var testAccImageConfigContentBadChecksum = `
+resource "opennebula_image" "testcontent" {
+   name = "test-image-content"
+   datastore_id = 2
+   type = "CONTEXT"
+   content = "#!/bin/sh\necho hello\n"
+   sha256 = "9a2bff7288ac2a72fe3a2a8c420f9a1a348b229ecdc16453605f82278ff4fc55"
+}
+`
+
+var testAccImageConfigSourceFile = `
+resource "opennebula_image" "testsourcefile" {
+   name = "test-image-source-file"
+   datastore_id = 2
+   type = "CONTEXT"
+   source_file = "%s"
+}
+`
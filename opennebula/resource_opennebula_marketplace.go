@@ -0,0 +1,674 @@
+package opennebula
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/OpenNebula/one/src/oca/go/src/goca"
+	dyn "github.com/OpenNebula/one/src/oca/go/src/goca/dynamic"
+	"github.com/OpenNebula/one/src/oca/go/src/goca/parameters"
+	mp "github.com/OpenNebula/one/src/oca/go/src/goca/schemas/marketplace"
+	mpk "github.com/OpenNebula/one/src/oca/go/src/goca/schemas/marketplace/keys"
+)
+
+// marketplace template keys managed through the http section
+var marketplaceHTTPKeys = map[string]string{
+	"public_dir": string(mpk.PublicDir),
+	"base_url":   string(mpk.BaseUrl),
+}
+
+// marketplace template keys managed through the s3 section
+var marketplaceS3Keys = map[string]string{
+	"access_key_id":     "ACCESS_KEY_ID",
+	"secret_access_key": "SECRET_ACCESS_KEY",
+	"bucket":            "BUCKET",
+	"region":            "REGION",
+	"endpoint":          "ENDPOINT",
+	"signature_version": "SIGNATURE_VERSION",
+}
+
+func resourceOpennebulaMarketPlace() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceOpennebulaMarketPlaceCreate,
+		ReadContext:   resourceOpennebulaMarketPlaceRead,
+		Exists:        resourceOpennebulaMarketPlaceExists,
+		UpdateContext: resourceOpennebulaMarketPlaceUpdate,
+		DeleteContext: resourceOpennebulaMarketPlaceDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+		CustomizeDiff: tagsAllCustomizeDiff,
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Name of the Marketplace",
+			},
+			"market_mad": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Marketplace driver, deduced from the http or s3 section",
+			},
+			"http": {
+				Type:         schema.TypeList,
+				Optional:     true,
+				MaxItems:     1,
+				Description:  "HTTP marketplace specific attributes",
+				ExactlyOneOf: []string{"http", "s3"},
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"public_dir": {
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "Directory in the frontend where the appliances are stored",
+						},
+						"base_url": {
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "URL used to download the appliances from the public directory",
+						},
+						"bridge_list": {
+							Type:        schema.TypeList,
+							Optional:    true,
+							Description: "List of hosts used to perform the marketplace operations",
+							Elem: &schema.Schema{
+								Type: schema.TypeString,
+							},
+						},
+					},
+				},
+			},
+			"s3": {
+				Type:         schema.TypeList,
+				Optional:     true,
+				MaxItems:     1,
+				Description:  "S3 marketplace specific attributes",
+				ExactlyOneOf: []string{"http", "s3"},
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"access_key_id": {
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "Access key of the S3 user",
+						},
+						"secret_access_key": {
+							Type:        schema.TypeString,
+							Required:    true,
+							Sensitive:   true,
+							Description: "Secret key of the S3 user",
+						},
+						"bucket": {
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "Bucket where the appliances are stored",
+						},
+						"region": {
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "Region of the bucket",
+						},
+						"endpoint": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "URL of the S3 API, only required for non AWS endpoints",
+						},
+						"signature_version": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "Version of the signature of the requests, i.e. s3",
+						},
+						"force_path_style": {
+							Type:        schema.TypeBool,
+							Optional:    true,
+							Default:     false,
+							Description: "Use path style URLs instead of virtual hosted style URLs",
+						},
+						"total_mb": {
+							Type:        schema.TypeInt,
+							Optional:    true,
+							Description: "Size in MB advertised for the marketplace",
+						},
+						"read_length": {
+							Type:        schema.TypeInt,
+							Optional:    true,
+							Description: "Size in MB of the chunks used to upload the appliances",
+						},
+					},
+				},
+			},
+			"permissions": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "Permissions for the Marketplace (in Unix format, owner-group-other, use-manage-admin)",
+				ValidateFunc: func(v interface{}, k string) (ws []string, errors []error) {
+					value := v.(string)
+
+					if len(value) != 3 {
+						errors = append(errors, fmt.Errorf("%q has specify 3 permission sets: owner-group-other", k))
+					}
+
+					all := true
+					for _, c := range strings.Split(value, "") {
+						if c < "0" || c > "7" {
+							all = false
+						}
+					}
+					if !all {
+						errors = append(errors, fmt.Errorf("Each character in %q should specify a Unix-like permission set with a number from 0 to 7", k))
+					}
+
+					return
+				},
+			},
+			"uid": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "ID of the user that will own the Marketplace",
+			},
+			"gid": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "ID of the group that will own the Marketplace",
+			},
+			"uname": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Name of the user that will own the Marketplace",
+			},
+			"gname": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Name of the group that will own the Marketplace",
+			},
+			"group": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Name of the Group that onws the Marketplace, If empty, it uses caller group",
+			},
+			"tags":     tagsSchema(),
+			"tags_all": tagsAllSchema(),
+		},
+	}
+}
+
+func getMarketPlaceController(d *schema.ResourceData, meta interface{}) (*goca.MarketPlaceController, error) {
+	config := meta.(*Configuration)
+	controller := config.Controller
+	var mc *goca.MarketPlaceController
+
+	// Try to find the Marketplace by ID, if specified
+	if d.Id() != "" {
+		id, err := strconv.ParseUint(d.Id(), 10, 0)
+		if err != nil {
+			return nil, err
+		}
+		mc = controller.MarketPlace(int(id))
+	}
+
+	// Otherwise, try to find the Marketplace by name as the de facto compound primary key
+	if d.Id() == "" {
+		id, err := controller.MarketPlaces().ByName(d.Get("name").(string))
+		if err != nil {
+			return nil, err
+		}
+		mc = controller.MarketPlace(id)
+	}
+
+	return mc, nil
+}
+
+func changeMarketPlaceGroup(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Configuration)
+	controller := config.Controller
+	var gid int
+
+	mc, err := getMarketPlaceController(d, meta)
+	if err != nil {
+		return err
+	}
+
+	if d.Get("group") != "" {
+		group := d.Get("group").(string)
+		gid, err = controller.Groups().ByName(group)
+		if err != nil {
+			return fmt.Errorf("Can't find a group with name `%s`: %s", group, err)
+		}
+	}
+
+	err = mc.Chown(-1, gid)
+	if err != nil {
+		return fmt.Errorf("Can't find a group with ID `%d`: %s", gid, err)
+	}
+
+	return nil
+}
+
+func resourceOpennebulaMarketPlaceCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	config := meta.(*Configuration)
+	controller := config.Controller
+
+	var diags diag.Diagnostics
+
+	setTagsAll(d, meta)
+
+	mpDef := generateMarketPlace(d)
+
+	mpID, err := controller.MarketPlaces().Create(mpDef)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to create the marketplace",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+
+	d.SetId(fmt.Sprintf("%v", mpID))
+
+	mc := controller.MarketPlace(mpID)
+
+	if perms, ok := d.GetOk("permissions"); ok {
+		err = mc.Chmod(permissionUnix(perms.(string)))
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to change permissions",
+				Detail:   fmt.Sprintf("marketplace (ID: %s): %s", d.Id(), err),
+			})
+			return diags
+		}
+	}
+
+	if d.Get("group") != "" {
+		err = changeMarketPlaceGroup(d, meta)
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to change group",
+				Detail:   fmt.Sprintf("marketplace (ID: %s): %s", d.Id(), err),
+			})
+			return diags
+		}
+	}
+
+	return resourceOpennebulaMarketPlaceRead(ctx, d, meta)
+}
+
+func resourceOpennebulaMarketPlaceRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+
+	var diags diag.Diagnostics
+
+	mc, err := getMarketPlaceController(d, meta)
+	if err != nil {
+		if NoExists(err) {
+			log.Printf("[WARN] Removing marketplace %s from state because it no longer exists in", d.Get("name"))
+			d.SetId("")
+			return nil
+		}
+
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to get the marketplace controller",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+
+	marketplace, err := mc.Info(false)
+	if err != nil {
+		if NoExists(err) {
+			log.Printf("[WARN] Removing marketplace %s from state because it no longer exists in", d.Get("name"))
+			d.SetId("")
+			return nil
+		}
+
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to retrieve informations",
+			Detail:   fmt.Sprintf("marketplace (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
+	d.SetId(fmt.Sprintf("%v", marketplace.ID))
+	d.Set("name", marketplace.Name)
+	d.Set("market_mad", marketplace.MarketMad)
+	d.Set("uid", marketplace.UID)
+	d.Set("gid", marketplace.GID)
+	d.Set("uname", marketplace.UName)
+	d.Set("gname", marketplace.GName)
+	d.Set("permissions", permissionsUnixString(*marketplace.Permissions))
+
+	err = flattenMarketPlaceTemplate(d, &marketplace.Template)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to flatten template",
+			Detail:   fmt.Sprintf("marketplace (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
+	err = flattenTagsAll(d, meta, &marketplace.Template.Template)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to set attribute",
+			Detail:   fmt.Sprintf("marketplace (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
+	return nil
+}
+
+func flattenMarketPlaceTemplate(d *schema.ResourceData, mpTpl *mp.Template) error {
+
+	http := make(map[string]interface{})
+	s3 := make(map[string]interface{})
+	tags := make(map[string]interface{})
+	tagsInterface, tagsOk := d.GetOk("tags")
+
+	// the secret key can't be read back in clear text
+	if s3List := d.Get("s3").([]interface{}); len(s3List) > 0 && s3List[0] != nil {
+		s3["secret_access_key"] = s3List[0].(map[string]interface{})["secret_access_key"]
+	}
+
+	for i, _ := range mpTpl.Elements {
+		pair, ok := mpTpl.Elements[i].(*dyn.Pair)
+		if !ok {
+			continue
+		}
+
+		switch pair.Key() {
+		case string(mpk.MarketMAD), "SECRET_ACCESS_KEY":
+		case string(mpk.PublicDir), string(mpk.BaseUrl):
+			for k, key := range marketplaceHTTPKeys {
+				if key == pair.Key() {
+					http[k] = pair.Value
+				}
+			}
+		case string(mpk.BridgeList):
+			http["bridge_list"] = strings.Fields(pair.Value)
+		case "ACCESS_KEY_ID", "BUCKET", "REGION", "ENDPOINT", "SIGNATURE_VERSION":
+			for k, key := range marketplaceS3Keys {
+				if key == pair.Key() {
+					s3[k] = pair.Value
+				}
+			}
+		case "FORCE_PATH_STYLE":
+			s3["force_path_style"] = strings.ToUpper(pair.Value) == "YES"
+		case "TOTAL_MB", "READ_LENGTH":
+			value, err := strconv.Atoi(pair.Value)
+			if err == nil {
+				s3[strings.ToLower(pair.Key())] = value
+			}
+		default:
+			// Get only tags described in the configuration
+			if tagsOk {
+				for k, _ := range tagsInterface.(map[string]interface{}) {
+					if strings.ToUpper(k) == pair.Key() {
+						tags[k] = pair.Value
+					}
+				}
+			}
+		}
+	}
+
+	switch d.Get("market_mad").(string) {
+	case "http":
+		err := d.Set("http", []interface{}{http})
+		if err != nil {
+			return err
+		}
+	case "s3":
+		err := d.Set("s3", []interface{}{s3})
+		if err != nil {
+			return err
+		}
+	}
+
+	if tagsOk {
+		err := d.Set("tags", tags)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func resourceOpennebulaMarketPlaceExists(d *schema.ResourceData, meta interface{}) (bool, error) {
+	config := meta.(*Configuration)
+	controller := config.Controller
+
+	mpID, err := strconv.ParseInt(d.Id(), 10, 0)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = controller.MarketPlace(int(mpID)).Info(false)
+	if NoExists(err) {
+		return false, err
+	}
+
+	return true, err
+}
+
+func resourceOpennebulaMarketPlaceUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+
+	var diags diag.Diagnostics
+
+	setTagsAll(d, meta)
+
+	mc, err := getMarketPlaceController(d, meta)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to get the marketplace controller",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+
+	marketplace, err := mc.Info(false)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to retrieve informations",
+			Detail:   fmt.Sprintf("marketplace (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
+	if d.HasChange("name") {
+		err := mc.Rename(d.Get("name").(string))
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to rename",
+				Detail:   fmt.Sprintf("marketplace (ID: %s): %s", d.Id(), err),
+			})
+			return diags
+		}
+		log.Printf("[INFO] Successfully updated name for Marketplace %s\n", marketplace.Name)
+	}
+
+	update := false
+	tpl := marketplace.Template
+
+	if d.HasChanges("http", "s3") {
+		tpl.Del(string(mpk.MarketMAD))
+		tpl.Del(string(mpk.BridgeList))
+		for _, key := range marketplaceHTTPKeys {
+			tpl.Del(key)
+		}
+		for _, key := range marketplaceS3Keys {
+			tpl.Del(key)
+		}
+		tpl.Del("FORCE_PATH_STYLE")
+		tpl.Del("TOTAL_MB")
+		tpl.Del("READ_LENGTH")
+
+		addMarketPlaceDriver(d, &tpl)
+		update = true
+	}
+
+	if d.HasChange("tags_all") {
+
+		oldTags, newTags := tagsAllChange(d)
+
+		// delete tags
+		for k, _ := range oldTags {
+			_, ok := newTags[k]
+			if ok {
+				continue
+			}
+			tpl.Del(strings.ToUpper(k))
+		}
+
+		// add/update tags
+		for k, v := range newTags {
+			tpl.Del(strings.ToUpper(k))
+			tpl.AddPair(strings.ToUpper(k), v)
+		}
+
+		update = true
+	}
+
+	if update {
+		err = mc.Update(tpl.String(), parameters.Replace)
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to update content",
+				Detail:   fmt.Sprintf("marketplace (ID: %s): %s", d.Id(), err),
+			})
+			return diags
+		}
+		log.Printf("[INFO] Successfully updated template for Marketplace %s\n", marketplace.Name)
+	}
+
+	if d.HasChange("permissions") {
+		if perms, ok := d.GetOk("permissions"); ok {
+			err = mc.Chmod(permissionUnix(perms.(string)))
+			if err != nil {
+				diags = append(diags, diag.Diagnostic{
+					Severity: diag.Error,
+					Summary:  "Failed to change permissions",
+					Detail:   fmt.Sprintf("marketplace (ID: %s): %s", d.Id(), err),
+				})
+				return diags
+			}
+		}
+		log.Printf("[INFO] Successfully updated permissions for Marketplace %s\n", marketplace.Name)
+	}
+
+	if d.HasChange("group") {
+		err = changeMarketPlaceGroup(d, meta)
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to change group",
+				Detail:   fmt.Sprintf("marketplace (ID: %s): %s", d.Id(), err),
+			})
+			return diags
+		}
+		log.Printf("[INFO] Successfully updated group for Marketplace %s\n", marketplace.Name)
+	}
+
+	return resourceOpennebulaMarketPlaceRead(ctx, d, meta)
+}
+
+func resourceOpennebulaMarketPlaceDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+
+	var diags diag.Diagnostics
+
+	mc, err := getMarketPlaceController(d, meta)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to get the marketplace controller",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+
+	err = mc.Delete()
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to delete",
+			Detail:   fmt.Sprintf("marketplace (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
+	log.Printf("[INFO] Successfully deleted Marketplace ID %s\n", d.Id())
+	return nil
+}
+
+// addMarketPlaceDriver adds the driver and its attributes to the template
+func addMarketPlaceDriver(d *schema.ResourceData, tpl *mp.Template) {
+
+	if httpList := d.Get("http").([]interface{}); len(httpList) > 0 && httpList[0] != nil {
+		http := httpList[0].(map[string]interface{})
+
+		tpl.Add(mpk.MarketMAD, "http")
+		for k, key := range marketplaceHTTPKeys {
+			if v := http[k].(string); len(v) > 0 {
+				tpl.AddPair(key, v)
+			}
+		}
+		if hosts := http["bridge_list"].([]interface{}); len(hosts) > 0 {
+			tpl.Add(mpk.BridgeList, ArrayToString(hosts, " "))
+		}
+	}
+
+	if s3List := d.Get("s3").([]interface{}); len(s3List) > 0 && s3List[0] != nil {
+		s3 := s3List[0].(map[string]interface{})
+
+		tpl.Add(mpk.MarketMAD, "s3")
+		for k, key := range marketplaceS3Keys {
+			if v := s3[k].(string); len(v) > 0 {
+				tpl.AddPair(key, v)
+			}
+		}
+		if s3["force_path_style"].(bool) {
+			tpl.AddPair("FORCE_PATH_STYLE", "YES")
+		}
+		if v := s3["total_mb"].(int); v > 0 {
+			tpl.AddPair("TOTAL_MB", v)
+		}
+		if v := s3["read_length"].(int); v > 0 {
+			tpl.AddPair("READ_LENGTH", v)
+		}
+	}
+}
+
+func generateMarketPlace(d *schema.ResourceData) string {
+
+	tpl := mp.NewTemplate()
+
+	tpl.Add(mpk.Name, d.Get("name").(string))
+
+	addMarketPlaceDriver(d, tpl)
+
+	tagsInterface := d.Get("tags_all").(map[string]interface{})
+	for k, v := range tagsInterface {
+		tpl.AddPair(strings.ToUpper(k), v)
+	}
+
+	tplStr := tpl.String()
+	log.Printf("[INFO] Marketplace definition: %s", tplStr)
+
+	return tplStr
+}
@@ -0,0 +1,680 @@
+package opennebula
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/OpenNebula/one/src/oca/go/src/goca"
+	"github.com/OpenNebula/one/src/oca/go/src/goca/parameters"
+	app "github.com/OpenNebula/one/src/oca/go/src/goca/schemas/marketplaceapp"
+	appk "github.com/OpenNebula/one/src/oca/go/src/goca/schemas/marketplaceapp/keys"
+)
+
+// marketPlaceAppStates is ordered as the STATE values returned by OpenNebula
+var marketPlaceAppStates = []string{"INIT", "READY", "LOCKED", "ERROR", "DISABLED"}
+
+// marketPlaceAppTypes is ordered as the TYPE values returned by OpenNebula
+var marketPlaceAppTypes = []string{"UNKNOWN", "IMAGE", "VMTEMPLATE", "SERVICE_TEMPLATE"}
+
+var defaultMarketPlaceAppTimeout = time.Duration(20) * time.Minute
+
+func resourceOpennebulaMarketPlaceApp() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceOpennebulaMarketPlaceAppCreate,
+		ReadContext:   resourceOpennebulaMarketPlaceAppRead,
+		Exists:        resourceOpennebulaMarketPlaceAppExists,
+		UpdateContext: resourceOpennebulaMarketPlaceAppUpdate,
+		DeleteContext: resourceOpennebulaMarketPlaceAppDelete,
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(defaultMarketPlaceAppTimeout),
+		},
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+		CustomizeDiff: tagsAllCustomizeDiff,
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Name of the App",
+			},
+			"marketplace_id": {
+				Type:        schema.TypeInt,
+				Required:    true,
+				ForceNew:    true,
+				Description: "ID of the marketplace where the App is published",
+			},
+			"origin_id": {
+				Type:        schema.TypeInt,
+				Required:    true,
+				ForceNew:    true,
+				Description: "ID of the Image published as an App",
+			},
+			"description": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Description of the App",
+			},
+			"version": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "Version of the App",
+			},
+			"publisher": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "Publisher of the App",
+			},
+			"vm_template": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "VM template associated to the App, used to create a template when the App is exported",
+			},
+			"enabled": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				Description: "Flag which indicates if the App can be exported",
+			},
+			"type": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Type of the App",
+			},
+			"state": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "State of the App",
+			},
+			"size": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Size of the App in MB",
+			},
+			"format": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Format of the App image",
+			},
+			"md5": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "MD5 checksum of the App image",
+			},
+			"source": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Location of the App image in the marketplace",
+			},
+			"regtime": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Registration time of the App",
+			},
+			"permissions": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "Permissions for the App (in Unix format, owner-group-other, use-manage-admin)",
+				ValidateFunc: func(v interface{}, k string) (ws []string, errors []error) {
+					value := v.(string)
+
+					if len(value) != 3 {
+						errors = append(errors, fmt.Errorf("%q has specify 3 permission sets: owner-group-other", k))
+					}
+
+					all := true
+					for _, c := range strings.Split(value, "") {
+						if c < "0" || c > "7" {
+							all = false
+						}
+					}
+					if !all {
+						errors = append(errors, fmt.Errorf("Each character in %q should specify a Unix-like permission set with a number from 0 to 7", k))
+					}
+
+					return
+				},
+			},
+			"uid": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "ID of the user that will own the App",
+			},
+			"gid": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "ID of the group that will own the App",
+			},
+			"uname": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Name of the user that will own the App",
+			},
+			"gname": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Name of the group that will own the App",
+			},
+			"group": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Name of the Group that onws the App, If empty, it uses caller group",
+			},
+			"tags":     tagsSchema(),
+			"tags_all": tagsAllSchema(),
+		},
+	}
+}
+
+func getMarketPlaceAppController(d *schema.ResourceData, meta interface{}) (*goca.MarketPlaceAppController, error) {
+	config := meta.(*Configuration)
+	controller := config.Controller
+	var mc *goca.MarketPlaceAppController
+
+	// Try to find the App by ID, if specified
+	if d.Id() != "" {
+		id, err := strconv.ParseUint(d.Id(), 10, 0)
+		if err != nil {
+			return nil, err
+		}
+		mc = controller.MarketPlaceApp(int(id))
+	}
+
+	// Otherwise, try to find the App by name as the de facto compound primary key
+	if d.Id() == "" {
+		id, err := controller.MarketPlaceApps().ByName(d.Get("name").(string))
+		if err != nil {
+			return nil, err
+		}
+		mc = controller.MarketPlaceApp(id)
+	}
+
+	return mc, nil
+}
+
+func changeMarketPlaceAppGroup(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Configuration)
+	controller := config.Controller
+	var gid int
+
+	mc, err := getMarketPlaceAppController(d, meta)
+	if err != nil {
+		return err
+	}
+
+	if d.Get("group") != "" {
+		group := d.Get("group").(string)
+		gid, err = controller.Groups().ByName(group)
+		if err != nil {
+			return fmt.Errorf("Can't find a group with name `%s`: %s", group, err)
+		}
+	}
+
+	err = mc.Chown(-1, gid)
+	if err != nil {
+		return fmt.Errorf("Can't find a group with ID `%d`: %s", gid, err)
+	}
+
+	return nil
+}
+
+func resourceOpennebulaMarketPlaceAppCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	config := meta.(*Configuration)
+	controller := config.Controller
+
+	var diags diag.Diagnostics
+
+	setTagsAll(d, meta)
+
+	appDef := generateMarketPlaceApp(d)
+
+	appID, err := controller.MarketPlaceApps().Create(appDef, d.Get("marketplace_id").(int))
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to create the marketplace app",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+
+	d.SetId(fmt.Sprintf("%v", appID))
+
+	mc := controller.MarketPlaceApp(appID)
+
+	// the image is copied to the marketplace before the app is usable
+	_, err = waitForMarketPlaceAppState(ctx, mc, d.Timeout(schema.TimeoutCreate), "READY")
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to wait marketplace app to be in READY state",
+			Detail:   fmt.Sprintf("marketplace app (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
+	if !d.Get("enabled").(bool) {
+		err = mc.Enable(false)
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to disable",
+				Detail:   fmt.Sprintf("marketplace app (ID: %s): %s", d.Id(), err),
+			})
+			return diags
+		}
+	}
+
+	if perms, ok := d.GetOk("permissions"); ok {
+		err = mc.Chmod(permissionUnix(perms.(string)))
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to change permissions",
+				Detail:   fmt.Sprintf("marketplace app (ID: %s): %s", d.Id(), err),
+			})
+			return diags
+		}
+	}
+
+	if d.Get("group") != "" {
+		err = changeMarketPlaceAppGroup(d, meta)
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to change group",
+				Detail:   fmt.Sprintf("marketplace app (ID: %s): %s", d.Id(), err),
+			})
+			return diags
+		}
+	}
+
+	return resourceOpennebulaMarketPlaceAppRead(ctx, d, meta)
+}
+
+func waitForMarketPlaceAppState(ctx context.Context, mc *goca.MarketPlaceAppController, timeout time.Duration, state ...string) (interface{}, error) {
+
+	stateConf := &resource.StateChangeConf{
+		Pending: []string{"anythingelse"},
+		Target:  state,
+		Refresh: func() (interface{}, string, error) {
+
+			log.Println("Refreshing marketplace app state...")
+
+			appInfos, err := mc.Info(false)
+			if err != nil {
+				if NoExists(err) {
+					return appInfos, "notfound", nil
+				}
+				return appInfos, "", err
+			}
+
+			state := marketPlaceAppStateString(appInfos.State)
+
+			log.Printf("Marketplace app (ID:%d, name:%s) is currently in state %s", appInfos.ID, appInfos.Name, state)
+
+			switch state {
+			case "READY", "DISABLED":
+				return appInfos, state, nil
+			case "ERROR":
+				return appInfos, state, fmt.Errorf("Marketplace app (ID:%d) entered error state.", appInfos.ID)
+			default:
+				return appInfos, "anythingelse", nil
+			}
+		},
+		Timeout:    timeout,
+		Delay:      10 * time.Second,
+		MinTimeout: 3 * time.Second,
+	}
+
+	return stateConf.WaitForStateContext(ctx)
+}
+
+func marketPlaceAppStateString(state int) string {
+	if state < 0 || state >= len(marketPlaceAppStates) {
+		return ""
+	}
+	return marketPlaceAppStates[state]
+}
+
+func marketPlaceAppTypeString(appType int) string {
+	if appType < 0 || appType >= len(marketPlaceAppTypes) {
+		return ""
+	}
+	return marketPlaceAppTypes[appType]
+}
+
+func resourceOpennebulaMarketPlaceAppRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+
+	var diags diag.Diagnostics
+
+	mc, err := getMarketPlaceAppController(d, meta)
+	if err != nil {
+		if NoExists(err) {
+			log.Printf("[WARN] Removing marketplace app %s from state because it no longer exists in", d.Get("name"))
+			d.SetId("")
+			return nil
+		}
+
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to get the marketplace app controller",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+
+	marketApp, err := mc.Info(false)
+	if err != nil {
+		if NoExists(err) {
+			log.Printf("[WARN] Removing marketplace app %s from state because it no longer exists in", d.Get("name"))
+			d.SetId("")
+			return nil
+		}
+
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to retrieve informations",
+			Detail:   fmt.Sprintf("marketplace app (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
+	d.SetId(fmt.Sprintf("%v", marketApp.ID))
+	d.Set("name", marketApp.Name)
+	d.Set("origin_id", marketApp.OriginID)
+	d.Set("description", marketApp.Description)
+	d.Set("version", marketApp.Version)
+	d.Set("type", marketPlaceAppTypeString(marketApp.Type))
+	d.Set("size", marketApp.Size)
+	d.Set("format", marketApp.Format)
+	d.Set("md5", marketApp.MD5)
+	d.Set("source", marketApp.Source)
+	d.Set("regtime", marketApp.RegTime)
+	d.Set("uid", marketApp.UID)
+	d.Set("gid", marketApp.GID)
+	d.Set("uname", marketApp.UName)
+	d.Set("gname", marketApp.GName)
+	d.Set("permissions", permissionsUnixString(*marketApp.Permissions))
+
+	state := marketPlaceAppStateString(marketApp.State)
+	d.Set("state", state)
+	d.Set("enabled", state != "DISABLED")
+
+	if marketApp.MarketPlaceID != nil {
+		d.Set("marketplace_id", *marketApp.MarketPlaceID)
+	}
+
+	publisher, err := marketApp.Template.GetStr(string(appk.Publisher))
+	if err == nil {
+		d.Set("publisher", publisher)
+	}
+
+	vmTemplate64, err := marketApp.Template.GetStr(string(appk.VMTemplate64))
+	if err == nil {
+		vmTemplate, err := base64.StdEncoding.DecodeString(vmTemplate64)
+		if err == nil {
+			d.Set("vm_template", string(vmTemplate))
+		}
+	}
+
+	if tagsInterface, ok := d.GetOk("tags"); ok {
+		err = d.Set("tags", pairsToMapFilter(marketApp.Template, tagsInterface.(map[string]interface{})))
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to set attribute",
+				Detail:   fmt.Sprintf("marketplace app (ID: %s): %s", d.Id(), err),
+			})
+			return diags
+		}
+	}
+
+	err = flattenTagsAll(d, meta, &marketApp.Template)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to set attribute",
+			Detail:   fmt.Sprintf("marketplace app (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
+	return nil
+}
+
+func resourceOpennebulaMarketPlaceAppExists(d *schema.ResourceData, meta interface{}) (bool, error) {
+	config := meta.(*Configuration)
+	controller := config.Controller
+
+	appID, err := strconv.ParseInt(d.Id(), 10, 0)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = controller.MarketPlaceApp(int(appID)).Info(false)
+	if NoExists(err) {
+		return false, err
+	}
+
+	return true, err
+}
+
+func resourceOpennebulaMarketPlaceAppUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+
+	var diags diag.Diagnostics
+
+	setTagsAll(d, meta)
+
+	mc, err := getMarketPlaceAppController(d, meta)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to get the marketplace app controller",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+
+	marketApp, err := mc.Info(false)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to retrieve informations",
+			Detail:   fmt.Sprintf("marketplace app (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
+	if d.HasChange("name") {
+		err := mc.Rename(d.Get("name").(string))
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to rename",
+				Detail:   fmt.Sprintf("marketplace app (ID: %s): %s", d.Id(), err),
+			})
+			return diags
+		}
+		log.Printf("[INFO] Successfully updated name for marketplace app %s\n", marketApp.Name)
+	}
+
+	update := false
+	tpl := app.Template{Template: marketApp.Template}
+
+	if d.HasChange("description") {
+		tpl.Del(string(appk.Description))
+		if v, ok := d.GetOk("description"); ok {
+			tpl.Add(appk.Description, v.(string))
+		}
+		update = true
+	}
+
+	if d.HasChange("version") {
+		tpl.Del(string(appk.Version))
+		if v, ok := d.GetOk("version"); ok {
+			tpl.Add(appk.Version, v.(string))
+		}
+		update = true
+	}
+
+	if d.HasChange("publisher") {
+		tpl.Del(string(appk.Publisher))
+		if v, ok := d.GetOk("publisher"); ok {
+			tpl.Add(appk.Publisher, v.(string))
+		}
+		update = true
+	}
+
+	if d.HasChange("tags_all") {
+
+		oldTags, newTags := tagsAllChange(d)
+
+		// delete tags
+		for k, _ := range oldTags {
+			_, ok := newTags[k]
+			if ok {
+				continue
+			}
+			tpl.Del(strings.ToUpper(k))
+		}
+
+		// add/update tags
+		for k, v := range newTags {
+			tpl.Del(strings.ToUpper(k))
+			tpl.AddPair(strings.ToUpper(k), v)
+		}
+
+		update = true
+	}
+
+	if update {
+		err = mc.Update(tpl.String(), parameters.Replace)
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to update content",
+				Detail:   fmt.Sprintf("marketplace app (ID: %s): %s", d.Id(), err),
+			})
+			return diags
+		}
+		log.Printf("[INFO] Successfully updated template for marketplace app %s\n", marketApp.Name)
+	}
+
+	if d.HasChange("enabled") {
+		err = mc.Enable(d.Get("enabled").(bool))
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to change the enabled state",
+				Detail:   fmt.Sprintf("marketplace app (ID: %s): %s", d.Id(), err),
+			})
+			return diags
+		}
+		log.Printf("[INFO] Successfully updated enabled state for marketplace app %s\n", marketApp.Name)
+	}
+
+	if d.HasChange("permissions") {
+		if perms, ok := d.GetOk("permissions"); ok {
+			err = mc.Chmod(permissionUnix(perms.(string)))
+			if err != nil {
+				diags = append(diags, diag.Diagnostic{
+					Severity: diag.Error,
+					Summary:  "Failed to change permissions",
+					Detail:   fmt.Sprintf("marketplace app (ID: %s): %s", d.Id(), err),
+				})
+				return diags
+			}
+		}
+		log.Printf("[INFO] Successfully updated permissions for marketplace app %s\n", marketApp.Name)
+	}
+
+	if d.HasChange("group") {
+		err = changeMarketPlaceAppGroup(d, meta)
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to change group",
+				Detail:   fmt.Sprintf("marketplace app (ID: %s): %s", d.Id(), err),
+			})
+			return diags
+		}
+		log.Printf("[INFO] Successfully updated group for marketplace app %s\n", marketApp.Name)
+	}
+
+	return resourceOpennebulaMarketPlaceAppRead(ctx, d, meta)
+}
+
+func resourceOpennebulaMarketPlaceAppDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+
+	var diags diag.Diagnostics
+
+	mc, err := getMarketPlaceAppController(d, meta)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to get the marketplace app controller",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+
+	err = mc.Delete()
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to delete",
+			Detail:   fmt.Sprintf("marketplace app (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
+	log.Printf("[INFO] Successfully deleted marketplace app ID %s\n", d.Id())
+	return nil
+}
+
+func generateMarketPlaceApp(d *schema.ResourceData) string {
+
+	tpl := app.NewTemplate()
+
+	tpl.Add(appk.Name, d.Get("name").(string))
+	tpl.Add(appk.OriginID, d.Get("origin_id").(int))
+	tpl.SetType(app.Image)
+
+	if v, ok := d.GetOk("description"); ok {
+		tpl.Add(appk.Description, v.(string))
+	}
+	if v, ok := d.GetOk("version"); ok {
+		tpl.Add(appk.Version, v.(string))
+	}
+	if v, ok := d.GetOk("publisher"); ok {
+		tpl.Add(appk.Publisher, v.(string))
+	}
+	if v, ok := d.GetOk("vm_template"); ok {
+		tpl.Add(appk.VMTemplate64, base64.StdEncoding.EncodeToString([]byte(v.(string))))
+	}
+
+	tagsInterface := d.Get("tags_all").(map[string]interface{})
+	for k, v := range tagsInterface {
+		tpl.AddPair(strings.ToUpper(k), v)
+	}
+
+	tplStr := tpl.String()
+	log.Printf("[INFO] Marketplace app definition: %s", tplStr)
+
+	return tplStr
+}
@@ -0,0 +1,276 @@
+package opennebula
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	dyn "github.com/OpenNebula/one/src/oca/go/src/goca/dynamic"
+	imk "github.com/OpenNebula/one/src/oca/go/src/goca/schemas/image/keys"
+	appk "github.com/OpenNebula/one/src/oca/go/src/goca/schemas/marketplaceapp/keys"
+	"github.com/OpenNebula/one/src/oca/go/src/goca/schemas/shared"
+	"github.com/OpenNebula/one/src/oca/go/src/goca/schemas/vm"
+	vmk "github.com/OpenNebula/one/src/oca/go/src/goca/schemas/vm/keys"
+)
+
+func resourceOpennebulaMarketPlaceAppExport() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceOpennebulaMarketPlaceAppExportCreate,
+		ReadContext:   resourceOpennebulaMarketPlaceAppExportRead,
+		DeleteContext: resourceOpennebulaMarketPlaceAppExportDelete,
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(defaultMarketPlaceAppTimeout),
+			Delete: schema.DefaultTimeout(defaultMarketPlaceAppTimeout),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"app_id": {
+				Type:        schema.TypeInt,
+				Required:    true,
+				ForceNew:    true,
+				Description: "ID of the marketplace App to export",
+			},
+			"datastore_id": {
+				Type:        schema.TypeInt,
+				Required:    true,
+				ForceNew:    true,
+				Description: "ID of the datastore where the Image is created",
+			},
+			"name": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				ForceNew:    true,
+				Description: "Name of the Image and of the template, defaults to the name of the App",
+			},
+			"create_template": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				ForceNew:    true,
+				Description: "Create a template from the VM template of the App",
+			},
+			"image_id": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "ID of the Image created from the App",
+			},
+			"template_id": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "ID of the template created from the App, -1 if none",
+			},
+		},
+	}
+}
+
+func resourceOpennebulaMarketPlaceAppExportCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	config := meta.(*Configuration)
+	controller := config.Controller
+
+	var diags diag.Diagnostics
+
+	appID := d.Get("app_id").(int)
+
+	marketApp, err := controller.MarketPlaceApp(appID).Info(false)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to retrieve informations",
+			Detail:   fmt.Sprintf("marketplace app (ID: %d): %s", appID, err),
+		})
+		return diags
+	}
+
+	appType := marketPlaceAppTypeString(marketApp.Type)
+	if appType != "IMAGE" {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Unsupported marketplace app type",
+			Detail:   fmt.Sprintf("marketplace app (ID: %d): only IMAGE apps can be exported, got: %s", appID, appType),
+		})
+		return diags
+	}
+
+	name := marketApp.Name
+	if v, ok := d.GetOk("name"); ok {
+		name = v.(string)
+	}
+
+	// the image is created from the template of the app, the image driver
+	// downloads the content of the app referenced by FROM_APP
+	appTemplate, err := base64.StdEncoding.DecodeString(marketApp.AppTemplate64)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to decode the image template",
+			Detail:   fmt.Sprintf("marketplace app (ID: %d): %s", appID, err),
+		})
+		return diags
+	}
+
+	imgTpl := dyn.Template{}
+	imgTpl.AddPair(string(imk.Name), name)
+	imgTpl.AddPair("FROM_APP", appID)
+	imgTplStr := fmt.Sprintf("%s\n%s", appTemplate, imgTpl.String())
+
+	log.Printf("[INFO] Marketplace app image definition: %s", imgTplStr)
+
+	imageID, err := controller.Images().Create(imgTplStr, uint(d.Get("datastore_id").(int)))
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to export the image",
+			Detail:   fmt.Sprintf("marketplace app (ID: %d): %s", appID, err),
+		})
+		return diags
+	}
+
+	d.SetId(fmt.Sprintf("%v", imageID))
+	d.Set("name", name)
+	d.Set("image_id", imageID)
+	d.Set("template_id", -1)
+
+	vmTemplate64, err := marketApp.Template.GetStr(string(appk.VMTemplate64))
+	if d.Get("create_template").(bool) && err == nil {
+
+		vmTemplate, err := base64.StdEncoding.DecodeString(vmTemplate64)
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to decode the VM template",
+				Detail:   fmt.Sprintf("marketplace app (ID: %d): %s", appID, err),
+			})
+			return diags
+		}
+
+		tpl := vm.NewTemplate()
+		tpl.Add(vmk.Name, name)
+		disk := tpl.AddDisk()
+		disk.Add(shared.ImageID, imageID)
+		tplStr := fmt.Sprintf("%s\n%s", vmTemplate, tpl.String())
+
+		log.Printf("[INFO] Marketplace app template definition: %s", tplStr)
+
+		templateID, err := controller.Templates().Create(tplStr)
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to export the template",
+				Detail:   fmt.Sprintf("marketplace app (ID: %d): %s", appID, err),
+			})
+			return diags
+		}
+		d.Set("template_id", templateID)
+	}
+
+	_, err = waitForImageState(ctx, controller.Image(imageID), d.Timeout(schema.TimeoutCreate), "READY")
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to wait image to be in READY state",
+			Detail:   fmt.Sprintf("image (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
+	return resourceOpennebulaMarketPlaceAppExportRead(ctx, d, meta)
+}
+
+func resourceOpennebulaMarketPlaceAppExportRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	config := meta.(*Configuration)
+	controller := config.Controller
+
+	var diags diag.Diagnostics
+
+	imageID, err := strconv.ParseUint(d.Id(), 10, 0)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to parse the image ID",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+
+	_, err = controller.Image(int(imageID)).Info(false)
+	if err != nil {
+		if NoExists(err) {
+			log.Printf("[WARN] Removing marketplace app export %s from state because the image no longer exists", d.Id())
+			d.SetId("")
+			return nil
+		}
+
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to retrieve informations",
+			Detail:   fmt.Sprintf("image (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
+	d.Set("image_id", int(imageID))
+
+	templateID := d.Get("template_id").(int)
+	if templateID >= 0 {
+		_, err = controller.Template(templateID).Info(false, false)
+		if NoExists(err) {
+			log.Printf("[WARN] Template %d created from the marketplace app no longer exists", templateID)
+			d.Set("template_id", -1)
+		}
+	}
+
+	return nil
+}
+
+func resourceOpennebulaMarketPlaceAppExportDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	config := meta.(*Configuration)
+	controller := config.Controller
+
+	var diags diag.Diagnostics
+
+	templateID := d.Get("template_id").(int)
+	if templateID >= 0 {
+		err := controller.Template(templateID).Delete()
+		if err != nil && !NoExists(err) {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to delete the template",
+				Detail:   fmt.Sprintf("template (ID: %d): %s", templateID, err),
+			})
+			return diags
+		}
+		log.Printf("[INFO] Successfully deleted template ID %d\n", templateID)
+	}
+
+	imageID := d.Get("image_id").(int)
+	ic := controller.Image(imageID)
+
+	err := ic.Delete()
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to delete the image",
+			Detail:   fmt.Sprintf("image (ID: %d): %s", imageID, err),
+		})
+		return diags
+	}
+
+	_, err = waitForImageState(ctx, ic, d.Timeout(schema.TimeoutDelete), "notfound")
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to wait image to be in NOTFOUND state",
+			Detail:   fmt.Sprintf("image (ID: %d): %s", imageID, err),
+		})
+		return diags
+	}
+
+	log.Printf("[INFO] Successfully deleted image ID %d\n", imageID)
+	return nil
+}
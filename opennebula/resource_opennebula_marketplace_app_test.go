@@ -0,0 +1,141 @@
+package opennebula
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestAccMarketPlaceApp(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckMarketPlaceAppDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccMarketPlaceAppConfigBasic,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("opennebula_marketplace_app.test", "name", "test-app"),
+					resource.TestCheckResourceAttrPair("opennebula_marketplace_app.test", "marketplace_id", "opennebula_marketplace.test", "id"),
+					resource.TestCheckResourceAttrPair("opennebula_marketplace_app.test", "origin_id", "opennebula_image.test", "id"),
+					resource.TestCheckResourceAttr("opennebula_marketplace_app.test", "description", "Terraform app"),
+					resource.TestCheckResourceAttr("opennebula_marketplace_app.test", "version", "1.0"),
+					resource.TestCheckResourceAttr("opennebula_marketplace_app.test", "type", "IMAGE"),
+					resource.TestCheckResourceAttr("opennebula_marketplace_app.test", "state", "READY"),
+					resource.TestCheckResourceAttr("opennebula_marketplace_app.test", "enabled", "true"),
+					resource.TestCheckResourceAttr("opennebula_marketplace_app.test", "permissions", "642"),
+					resource.TestCheckResourceAttr("opennebula_marketplace_app.test", "tags.%", "1"),
+					resource.TestCheckResourceAttr("opennebula_marketplace_app.test", "tags.env", "prod"),
+					resource.TestCheckResourceAttrSet("opennebula_marketplace_app.test", "uid"),
+					resource.TestCheckResourceAttrSet("opennebula_marketplace_app.test", "gid"),
+					resource.TestCheckResourceAttrPair("data.opennebula_marketplace_app.test", "id", "opennebula_marketplace_app.test", "id"),
+					resource.TestCheckResourceAttr("data.opennebula_marketplace_app.test", "type", "IMAGE"),
+					resource.TestCheckResourceAttrSet("opennebula_marketplace_app_export.test", "image_id"),
+					resource.TestCheckResourceAttr("opennebula_marketplace_app_export.test", "name", "test-app-export"),
+					resource.TestCheckResourceAttr("opennebula_marketplace_app_export.test", "template_id", "-1"),
+				),
+			},
+			{
+				Config: testAccMarketPlaceAppConfigUpdate,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("opennebula_marketplace_app.test", "name", "test-app-renamed"),
+					resource.TestCheckResourceAttr("opennebula_marketplace_app.test", "description", "Terraform app updated"),
+					resource.TestCheckResourceAttr("opennebula_marketplace_app.test", "version", "1.1"),
+					resource.TestCheckResourceAttr("opennebula_marketplace_app.test", "state", "DISABLED"),
+					resource.TestCheckResourceAttr("opennebula_marketplace_app.test", "enabled", "false"),
+					resource.TestCheckResourceAttr("opennebula_marketplace_app.test", "tags.%", "2"),
+					resource.TestCheckResourceAttr("opennebula_marketplace_app.test", "tags.env", "dev"),
+					resource.TestCheckResourceAttr("opennebula_marketplace_app.test", "tags.customer", "test"),
+				),
+			},
+		},
+	})
+}
+
+func testAccCheckMarketPlaceAppDestroy(s *terraform.State) error {
+	config := testAccProvider.Meta().(*Configuration)
+	controller := config.Controller
+
+	for _, rs := range s.RootModule().Resources {
+		switch rs.Type {
+		case "opennebula_marketplace_app":
+			appID, _ := strconv.ParseUint(rs.Primary.ID, 10, 0)
+			marketApp, _ := controller.MarketPlaceApp(int(appID)).Info(false)
+			if marketApp != nil {
+				return fmt.Errorf("Expected marketplace app %s to have been destroyed", rs.Primary.ID)
+			}
+		case "opennebula_marketplace_app_export":
+			imageID, _ := strconv.ParseUint(rs.Primary.ID, 10, 0)
+			image, _ := controller.Image(int(imageID)).Info(false)
+			if image != nil {
+				return fmt.Errorf("Expected image %s exported from the marketplace app to have been destroyed", rs.Primary.ID)
+			}
+		}
+	}
+
+	return nil
+}
+
+var testAccMarketPlaceAppConfigResources = `
+resource "opennebula_marketplace" "test" {
+  name = "test-app-marketplace"
+
+  http {
+    public_dir = "/var/tmp/test-app-marketplace"
+    base_url   = "http://localhost/test-app-marketplace"
+  }
+}
+
+resource "opennebula_image" "test" {
+  name         = "test-app-image"
+  datastore_id = 1
+  type         = "DATABLOCK"
+  size         = "16"
+}
+`
+
+var testAccMarketPlaceAppConfigBasic = testAccMarketPlaceAppConfigResources + `
+resource "opennebula_marketplace_app" "test" {
+  name           = "test-app"
+  marketplace_id = opennebula_marketplace.test.id
+  origin_id      = opennebula_image.test.id
+  description    = "Terraform app"
+  version        = "1.0"
+  permissions    = "642"
+
+  tags = {
+    env = "prod"
+  }
+}
+
+data "opennebula_marketplace_app" "test" {
+  name    = opennebula_marketplace_app.test.name
+  version = "1.0"
+}
+
+resource "opennebula_marketplace_app_export" "test" {
+  app_id       = opennebula_marketplace_app.test.id
+  datastore_id = 1
+  name         = "test-app-export"
+}
+`
+
+var testAccMarketPlaceAppConfigUpdate = testAccMarketPlaceAppConfigResources + `
+resource "opennebula_marketplace_app" "test" {
+  name           = "test-app-renamed"
+  marketplace_id = opennebula_marketplace.test.id
+  origin_id      = opennebula_image.test.id
+  description    = "Terraform app updated"
+  version        = "1.1"
+  permissions    = "642"
+  enabled        = false
+
+  tags = {
+    env      = "dev"
+    customer = "test"
+  }
+}
+`
@@ -0,0 +1,115 @@
+package opennebula
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestAccMarketPlace(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckMarketPlaceDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccMarketPlaceConfigBasic,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("opennebula_marketplace.test", "name", "test-marketplace"),
+					resource.TestCheckResourceAttr("opennebula_marketplace.test", "market_mad", "http"),
+					resource.TestCheckResourceAttr("opennebula_marketplace.test", "http.#", "1"),
+					resource.TestCheckResourceAttr("opennebula_marketplace.test", "http.0.public_dir", "/var/tmp/test-marketplace"),
+					resource.TestCheckResourceAttr("opennebula_marketplace.test", "http.0.base_url", "http://localhost/test-marketplace"),
+					resource.TestCheckResourceAttr("opennebula_marketplace.test", "permissions", "642"),
+					resource.TestCheckResourceAttr("opennebula_marketplace.test", "tags.%", "2"),
+					resource.TestCheckResourceAttr("opennebula_marketplace.test", "tags.env", "prod"),
+					resource.TestCheckResourceAttr("opennebula_marketplace.test", "tags.customer", "test"),
+					resource.TestCheckResourceAttrSet("opennebula_marketplace.test", "uid"),
+					resource.TestCheckResourceAttrSet("opennebula_marketplace.test", "gid"),
+					resource.TestCheckResourceAttrSet("opennebula_marketplace.test", "uname"),
+					resource.TestCheckResourceAttrSet("opennebula_marketplace.test", "gname"),
+					resource.TestCheckResourceAttrPair("data.opennebula_marketplace.test", "id", "opennebula_marketplace.test", "id"),
+					resource.TestCheckResourceAttr("data.opennebula_marketplace.test", "market_mad", "http"),
+				),
+			},
+			{
+				Config: testAccMarketPlaceConfigUpdate,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("opennebula_marketplace.test", "name", "test-marketplace-renamed"),
+					resource.TestCheckResourceAttr("opennebula_marketplace.test", "http.0.base_url", "http://localhost/marketplace"),
+					resource.TestCheckResourceAttr("opennebula_marketplace.test", "http.0.bridge_list.#", "2"),
+					resource.TestCheckResourceAttr("opennebula_marketplace.test", "http.0.bridge_list.0", "host1"),
+					resource.TestCheckResourceAttr("opennebula_marketplace.test", "http.0.bridge_list.1", "host2"),
+					resource.TestCheckResourceAttr("opennebula_marketplace.test", "permissions", "660"),
+					resource.TestCheckResourceAttr("opennebula_marketplace.test", "tags.%", "3"),
+					resource.TestCheckResourceAttr("opennebula_marketplace.test", "tags.env", "dev"),
+					resource.TestCheckResourceAttr("opennebula_marketplace.test", "tags.customer", "test"),
+					resource.TestCheckResourceAttr("opennebula_marketplace.test", "tags.version", "2"),
+				),
+			},
+		},
+	})
+}
+
+func testAccCheckMarketPlaceDestroy(s *terraform.State) error {
+	config := testAccProvider.Meta().(*Configuration)
+	controller := config.Controller
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "opennebula_marketplace" {
+			continue
+		}
+		mpID, _ := strconv.ParseUint(rs.Primary.ID, 10, 0)
+		mc := controller.MarketPlace(int(mpID))
+		// Get Marketplace Info
+		marketplace, _ := mc.Info(false)
+		if marketplace != nil {
+			return fmt.Errorf("Expected marketplace %s to have been destroyed", rs.Primary.ID)
+		}
+	}
+
+	return nil
+}
+
+var testAccMarketPlaceConfigBasic = `
+resource "opennebula_marketplace" "test" {
+  name        = "test-marketplace"
+  permissions = "642"
+
+  http {
+    public_dir = "/var/tmp/test-marketplace"
+    base_url   = "http://localhost/test-marketplace"
+  }
+
+  tags = {
+    env      = "prod"
+    customer = "test"
+  }
+}
+
+data "opennebula_marketplace" "test" {
+  name = opennebula_marketplace.test.name
+}
+`
+
+var testAccMarketPlaceConfigUpdate = `
+resource "opennebula_marketplace" "test" {
+  name        = "test-marketplace-renamed"
+  permissions = "660"
+
+  http {
+    public_dir  = "/var/tmp/test-marketplace"
+    base_url    = "http://localhost/marketplace"
+    bridge_list = ["host1", "host2"]
+  }
+
+  tags = {
+    env      = "dev"
+    customer = "test"
+    version  = "2"
+  }
+}
+`
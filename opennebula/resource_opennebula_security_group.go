@@ -7,15 +7,21 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 
 	"github.com/OpenNebula/one/src/oca/go/src/goca"
+	dyn "github.com/OpenNebula/one/src/oca/go/src/goca/dynamic"
 	"github.com/OpenNebula/one/src/oca/go/src/goca/schemas/securitygroup"
 	sgk "github.com/OpenNebula/one/src/oca/go/src/goca/schemas/securitygroup/keys"
 )
 
+var defaultSecurityGroupTimeout = time.Duration(10) * time.Minute
+
 func resourceOpennebulaSecurityGroup() *schema.Resource {
 	return &schema.Resource{
 		CreateContext: resourceOpennebulaSecurityGroupCreate,
@@ -23,6 +29,10 @@ func resourceOpennebulaSecurityGroup() *schema.Resource {
 		Exists:        resourceOpennebulaSecurityGroupExists,
 		UpdateContext: resourceOpennebulaSecurityGroupUpdate,
 		DeleteContext: resourceOpennebulaSecurityGroupDelete,
+		CustomizeDiff: customdiff.All(resourceSecurityGroupCustomizeDiff, tagsAllCustomizeDiff),
+		Timeouts: &schema.ResourceTimeout{
+			Update: schema.DefaultTimeout(defaultSecurityGroupTimeout),
+		},
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
@@ -86,9 +96,8 @@ func resourceOpennebulaSecurityGroup() *schema.Resource {
 			},
 			"rule": {
 				Type:        schema.TypeList,
-				Required:    true,
-				MinItems:    1,
-				Description: "List of rules to be in the Security Group",
+				Optional:    true,
+				Description: "List of rules to be in the Security Group, the rules added by opennebula_security_group_rule resources are ignored",
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
 						"protocol": {
@@ -122,28 +131,33 @@ func resourceOpennebulaSecurityGroup() *schema.Resource {
 							},
 						},
 						"ip": {
-							Type:        schema.TypeString,
-							Description: "IP (or starting IP if used with 'size') to apply the rule to",
-							Optional:    true,
-							Computed:    true,
+							Type:         schema.TypeString,
+							Description:  "IP (or starting IP if used with 'size') to apply the rule to",
+							Optional:     true,
+							Computed:     true,
+							ValidateFunc: validateSecurityGroupIP,
 						},
 						"size": {
-							Type:        schema.TypeString,
-							Description: "Number of IPs to apply the rule from, starting with 'ip'",
-							Optional:    true,
-							Computed:    true,
+							Type:         schema.TypeString,
+							Description:  "Number of IPs to apply the rule from, starting with 'ip'",
+							Optional:     true,
+							Computed:     true,
+							ValidateFunc: validateSecurityGroupSize,
 						},
 						"range": {
-							Type:        schema.TypeString,
-							Description: "Comma separated list of ports and port ranges",
-							Optional:    true,
-							Computed:    true,
+							Type:             schema.TypeString,
+							Description:      "Comma separated list of ports and port ranges",
+							Optional:         true,
+							Computed:         true,
+							ValidateFunc:     validateSecurityGroupRange,
+							DiffSuppressFunc: suppressSecurityGroupRangeDiff,
 						},
 						"icmp_type": {
-							Type:        schema.TypeString,
-							Description: "Type of ICMP traffic to apply to when 'protocol' is ICMP",
-							Optional:    true,
-							Computed:    true,
+							Type:         schema.TypeString,
+							Description:  "Type of ICMP traffic to apply to when 'protocol' is ICMP",
+							Optional:     true,
+							Computed:     true,
+							ValidateFunc: validateSecurityGroupICMPType,
 						},
 						"network_id": {
 							Type:        schema.TypeString,
@@ -160,13 +174,52 @@ func resourceOpennebulaSecurityGroup() *schema.Resource {
 				Optional:    true,
 				Default:     true,
 			},
+			"wait_for_commit": {
+				Type:        schema.TypeBool,
+				Description: "Wait for the rules to be applied on all the Virtual Machines after a commit",
+				Optional:    true,
+				Default:     false,
+			},
+			"updated_vms": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "List of Virtual Machine IDs with the up to date rules",
+				Elem: &schema.Schema{
+					Type: schema.TypeInt,
+				},
+			},
+			"outdated_vms": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "List of Virtual Machine IDs waiting for the rules to be updated",
+				Elem: &schema.Schema{
+					Type: schema.TypeInt,
+				},
+			},
+			"updating_vms": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "List of Virtual Machine IDs with the rules being updated",
+				Elem: &schema.Schema{
+					Type: schema.TypeInt,
+				},
+			},
+			"error_vms": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "List of Virtual Machine IDs with an error while updating the rules",
+				Elem: &schema.Schema{
+					Type: schema.TypeInt,
+				},
+			},
 			"group": {
 				Type:          schema.TypeString,
 				Optional:      true,
 				ConflictsWith: []string{"gid"},
 				Description:   "Name of the Group that onws the Security Group, If empty, it uses caller group",
 			},
-			"tags": tagsSchema(),
+			"tags":     tagsSchema(),
+			"tags_all": tagsAllSchema(),
 		},
 	}
 }
@@ -265,10 +318,15 @@ func resourceOpennebulaSecurityGroupRead(ctx context.Context, d *schema.Resource
 	d.Set("gname", securitygroup.GName)
 	d.Set("permissions", permissionsUnixString(*securitygroup.Permissions))
 
+	d.Set("updated_vms", securitygroup.UpdatedVMs.ID)
+	d.Set("outdated_vms", securitygroup.OutdatedVMs.ID)
+	d.Set("updating_vms", securitygroup.UpdatingVMs.ID)
+	d.Set("error_vms", securitygroup.ErrorVMs.ID)
+
 	description, _ := securitygroup.Template.Get(sgk.Description)
 	d.Set("description", description)
 
-	if err := d.Set("rule", generateSecurityGroupMapFromStructs(securitygroup.Template.GetRules())); err != nil {
+	if err := d.Set("rule", generateSecurityGroupMapFromStructs(securityGroupInlineRules(&securitygroup.Template))); err != nil {
 		log.Printf("[WARN] Error setting rule for Security Group %x, error: %s", securitygroup.ID, err)
 	}
 
@@ -282,6 +340,16 @@ func resourceOpennebulaSecurityGroupRead(ctx context.Context, d *schema.Resource
 		return diags
 	}
 
+	err = flattenTagsAll(d, meta, &securitygroup.Template.Template)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to set attribute",
+			Detail:   fmt.Sprintf("security group (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
 	return nil
 }
 
@@ -310,6 +378,36 @@ func flattenSecurityGroupTags(d *schema.ResourceData, sgTpl *securitygroup.Templ
 	return nil
 }
 
+// securityGroupInlineRules returns the rules of the rule blocks, skipping the
+// ones owned by opennebula_security_group_rule resources
+func securityGroupInlineRules(tpl *securitygroup.Template) []securitygroup.Rule {
+
+	rules := make([]securitygroup.Rule, 0)
+	for _, rule := range tpl.GetRules() {
+		if isSecurityGroupRuleManaged(&rule.Vector) {
+			continue
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules
+}
+
+// delSecurityGroupInlineRules deletes the rules of the rule blocks from the
+// template, keeping the ones owned by opennebula_security_group_rule resources
+func delSecurityGroupInlineRules(tpl *securitygroup.Template) {
+
+	elements := tpl.Elements[:0]
+	for _, e := range tpl.Elements {
+		vec, ok := e.(*dyn.Vector)
+		if ok && vec.Key() == string(sgk.RuleVec) && !isSecurityGroupRuleManaged(vec) {
+			continue
+		}
+		elements = append(elements, e)
+	}
+	tpl.Elements = elements
+}
+
 func generateSecurityGroupMapFromStructs(rulesVectors []securitygroup.Rule) []map[string]interface{} {
 
 	rules := make([]map[string]interface{}, 0, len(rulesVectors))
@@ -347,6 +445,8 @@ func resourceOpennebulaSecurityGroupCreate(ctx context.Context, d *schema.Resour
 
 	var diags diag.Diagnostics
 
+	setTagsAll(d, meta)
+
 	secGroupDef, err := generateSecurityGroup(d)
 	if err != nil {
 		diags = append(diags, diag.Diagnostic{
@@ -416,6 +516,10 @@ func resourceOpennebulaSecurityGroupUpdate(ctx context.Context, d *schema.Resour
 
 	var diags diag.Diagnostics
 
+	setTagsAll(d, meta)
+
+	config := meta.(*Configuration)
+
 	//Get Security Group
 	sgc, err := getSecurityGroupController(d, meta)
 	if err != nil {
@@ -426,6 +530,11 @@ func resourceOpennebulaSecurityGroupUpdate(ctx context.Context, d *schema.Resour
 		})
 		return diags
 	}
+
+	// serialize with opennebula_security_group_rule resources
+	sgKey := securityGroupKey(sgc.ID)
+	config.mutex.Lock(sgKey)
+	defer config.mutex.Unlock(sgKey)
 	// TODO: fix it after 5.10 release
 	// Force the "decrypt" bool to false to keep ONE 5.8 behavior
 	securitygroup, err := sgc.Info(false)
@@ -441,18 +550,17 @@ func resourceOpennebulaSecurityGroupUpdate(ctx context.Context, d *schema.Resour
 	update := false
 	rulesUpdate := false
 
-	if d.HasChange("rule") && d.Get("rule") != "" {
+	if d.HasChange("rule") {
 
-		tpl.Del((string(sgk.RuleVec)))
+		delSecurityGroupInlineRules(tpl)
 		generateSecurityGroupRules(d, tpl)
 		rulesUpdate = true
+		update = true
 	}
 
-	if d.HasChange("tags") {
+	if d.HasChange("tags_all") {
 
-		oldTagsIf, newTagsIf := d.GetChange("tags")
-		oldTags := oldTagsIf.(map[string]interface{})
-		newTags := newTagsIf.(map[string]interface{})
+		oldTags, newTags := tagsAllChange(d)
 
 		// delete tags
 		for k, _ := range oldTags {
@@ -499,6 +607,19 @@ func resourceOpennebulaSecurityGroupUpdate(ctx context.Context, d *schema.Resour
 			}
 
 			log.Printf("[INFO] Successfully commited Security Group %s changes to outdated Virtual Machines\n", securitygroup.Name)
+
+			if d.Get("wait_for_commit").(bool) {
+				timeout := d.Timeout(schema.TimeoutUpdate)
+				_, err = waitForSecurityGroupCommit(ctx, sgc, timeout, securitygroup.ErrorVMs.ID)
+				if err != nil {
+					diags = append(diags, diag.Diagnostic{
+						Severity: diag.Error,
+						Summary:  "Failed to wait for the rules to be applied",
+						Detail:   fmt.Sprintf("security group (ID: %s): %s", d.Id(), err),
+					})
+					return diags
+				}
+			}
 		}
 
 	}
@@ -547,6 +668,57 @@ func resourceOpennebulaSecurityGroupUpdate(ctx context.Context, d *schema.Resour
 	return resourceOpennebulaSecurityGroupRead(ctx, d, meta)
 }
 
+// waitForSecurityGroupCommit waits until the rules are applied on all the virtual machines.
+// It fails only on the virtual machines entering the error state, the ones
+// in errorVMs being already in error before the commit.
+func waitForSecurityGroupCommit(ctx context.Context, sgc *goca.SecurityGroupController, timeout time.Duration, errorVMs []int) (interface{}, error) {
+
+	previousErrors := make(map[int]bool, len(errorVMs))
+	for _, id := range errorVMs {
+		previousErrors[id] = true
+	}
+
+	stateConf := &resource.StateChangeConf{
+		Pending: []string{"outdated", "updating"},
+		Target:  []string{"updated"},
+		Refresh: func() (interface{}, string, error) {
+
+			log.Println("Refreshing security group VMs update state...")
+
+			sgInfos, err := sgc.Info(false)
+			if err != nil {
+				return sgInfos, "", err
+			}
+
+			log.Printf("security group (ID:%d, name:%s) has %d outdated, %d updating and %d error VMs",
+				sgInfos.ID, sgInfos.Name, len(sgInfos.OutdatedVMs.ID), len(sgInfos.UpdatingVMs.ID), len(sgInfos.ErrorVMs.ID))
+
+			newErrors := make([]int, 0)
+			for _, id := range sgInfos.ErrorVMs.ID {
+				if !previousErrors[id] {
+					newErrors = append(newErrors, id)
+				}
+			}
+
+			switch {
+			case len(newErrors) > 0:
+				return sgInfos, "error", fmt.Errorf("security group (ID:%d) failed to update the rules of VMs: %v", sgInfos.ID, newErrors)
+			case len(sgInfos.OutdatedVMs.ID) > 0:
+				return sgInfos, "outdated", nil
+			case len(sgInfos.UpdatingVMs.ID) > 0:
+				return sgInfos, "updating", nil
+			default:
+				return sgInfos, "updated", nil
+			}
+		},
+		Timeout:    timeout,
+		Delay:      3 * time.Second,
+		MinTimeout: 3 * time.Second,
+	}
+
+	return stateConf.WaitForStateContext(ctx)
+}
+
 func resourceOpennebulaSecurityGroupDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 
 	var diags diag.Diagnostics
@@ -633,7 +805,7 @@ func generateSecurityGroupTemplate(d *schema.ResourceData) string {
 		tpl.Add(sgk.Description, description)
 	}
 
-	tagsInterface := d.Get("tags").(map[string]interface{})
+	tagsInterface := d.Get("tags_all").(map[string]interface{})
 	for k, v := range tagsInterface {
 		tpl.AddPair(strings.ToUpper(k), v)
 	}
@@ -0,0 +1,480 @@
+package opennebula
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/OpenNebula/one/src/oca/go/src/goca"
+	dyn "github.com/OpenNebula/one/src/oca/go/src/goca/dynamic"
+	"github.com/OpenNebula/one/src/oca/go/src/goca/schemas/securitygroup"
+	sgk "github.com/OpenNebula/one/src/oca/go/src/goca/schemas/securitygroup/keys"
+)
+
+// securityGroupRuleKeys maps the rule attributes to the rule vector keys
+var securityGroupRuleKeys = map[string]sgk.Rule{
+	"protocol":   sgk.Protocol,
+	"rule_type":  sgk.RuleType,
+	"ip":         sgk.IP,
+	"size":       sgk.Size,
+	"range":      sgk.Range,
+	"icmp_type":  sgk.IcmpType,
+	"network_id": sgk.NetworkID,
+}
+
+// securityGroupRuleManagedKey marks the rules added by opennebula_security_group_rule,
+// opennebula_security_group only manages the rules without this pair
+const (
+	securityGroupRuleManagedKey   = "MANAGED_BY"
+	securityGroupRuleManagedValue = "opennebula_security_group_rule"
+)
+
+func resourceOpennebulaSecurityGroupRule() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceOpennebulaSecurityGroupRuleCreate,
+		ReadContext:   resourceOpennebulaSecurityGroupRuleRead,
+		UpdateContext: resourceOpennebulaSecurityGroupRuleUpdate,
+		DeleteContext: resourceOpennebulaSecurityGroupRuleDelete,
+		CustomizeDiff: resourceSecurityGroupRuleCustomizeDiff,
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceOpennebulaSecurityGroupRuleImport,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"security_group_id": {
+				Type:        schema.TypeInt,
+				Required:    true,
+				ForceNew:    true,
+				Description: "ID of the security group",
+			},
+			"protocol": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Protocol for the rule, must be one of: ALL, TCP, UDP, ICMP or IPSEC",
+				ValidateFunc: func(v interface{}, k string) (ws []string, errors []error) {
+					validprotos := []string{"ALL", "TCP", "UDP", "ICMP", "IPSEC"}
+					value := v.(string)
+
+					if inArray(value, validprotos) < 0 {
+						errors = append(errors, fmt.Errorf("Protocol %q must be one of: %s", k, strings.Join(validprotos, ",")))
+					}
+
+					return
+				},
+			},
+			"rule_type": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Direction of the traffic flow to allow, must be INBOUND or OUTBOUND",
+				ValidateFunc: func(v interface{}, k string) (ws []string, errors []error) {
+					validtypes := []string{"INBOUND", "OUTBOUND"}
+					value := v.(string)
+
+					if inArray(value, validtypes) < 0 {
+						errors = append(errors, fmt.Errorf("Rule type %q must be one of: %s", k, strings.Join(validtypes, ",")))
+					}
+
+					return
+				},
+			},
+			"ip": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				Description:  "IP (or starting IP if used with 'size') to apply the rule to",
+				ValidateFunc: validateSecurityGroupIP,
+			},
+			"size": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				Description:  "Number of IPs to apply the rule from, starting with 'ip'",
+				ValidateFunc: validateSecurityGroupSize,
+			},
+			"range": {
+				Type:             schema.TypeString,
+				Optional:         true,
+				ForceNew:         true,
+				Description:      "Comma separated list of ports and port ranges",
+				ValidateFunc:     validateSecurityGroupRange,
+				DiffSuppressFunc: suppressSecurityGroupRangeDiff,
+			},
+			"icmp_type": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				Description:  "Type of ICMP traffic to apply to when 'protocol' is ICMP",
+				ValidateFunc: validateSecurityGroupICMPType,
+			},
+			"network_id": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "VNET ID to be used as the source/destination IP addresses",
+			},
+			"commit": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				Description: "Should changes to the Security Group rules be commited to running Virtual Machines?",
+			},
+		},
+	}
+}
+
+// securityGroupKey is the key used to serialize the updates of the rules of a security group
+func securityGroupKey(sgID int) *ResourceKey {
+	return &ResourceKey{
+		Type: "security_group",
+		ID:   sgID,
+	}
+}
+
+// getSecurityGroupRuleAttributes returns the non empty rule attributes, indexed by rule vector keys
+func getSecurityGroupRuleAttributes(d *schema.ResourceData) map[string]string {
+
+	attrs := make(map[string]string)
+	for k, key := range securityGroupRuleKeys {
+		v := d.Get(k).(string)
+		if len(v) == 0 {
+			continue
+		}
+		attrs[string(key)] = v
+	}
+
+	return attrs
+}
+
+// securityGroupRuleHash returns a hash of the rule attributes, the rule vector
+// doesn't have any ID in OpenNebula
+func securityGroupRuleHash(attrs map[string]string) int {
+
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	for _, k := range keys {
+		v := attrs[k]
+		if k == string(sgk.Range) {
+			v = normalizeSecurityGroupRange(v)
+		}
+		sb.WriteString(fmt.Sprintf("%s=%s;", k, strings.ToUpper(v)))
+	}
+
+	return schema.HashString(sb.String())
+}
+
+// getSecurityGroupRuleVectorAttributes returns the non empty rule attributes of
+// the rule vector, the other pairs that OpenNebula may add are ignored
+func getSecurityGroupRuleVectorAttributes(rule *dyn.Vector) map[string]string {
+
+	attrs := make(map[string]string)
+	for _, key := range securityGroupRuleKeys {
+		v, err := rule.GetStr(string(key))
+		if err != nil || len(v) == 0 {
+			continue
+		}
+		attrs[string(key)] = v
+	}
+
+	return attrs
+}
+
+// securityGroupRuleMatch checks that the rule vector has the same rule attributes
+func securityGroupRuleMatch(rule *dyn.Vector, attrs map[string]string) bool {
+
+	ruleAttrs := getSecurityGroupRuleVectorAttributes(rule)
+	if len(ruleAttrs) != len(attrs) {
+		return false
+	}
+
+	for k, v := range attrs {
+		value, ok := ruleAttrs[k]
+		if !ok {
+			return false
+		}
+
+		if k == string(sgk.Range) {
+			v = normalizeSecurityGroupRange(v)
+			value = normalizeSecurityGroupRange(value)
+		}
+
+		if !strings.EqualFold(v, value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isSecurityGroupRuleManaged returns true when the rule vector has been added
+// by an opennebula_security_group_rule resource
+func isSecurityGroupRuleManaged(rule *dyn.Vector) bool {
+	value, err := rule.GetStr(securityGroupRuleManagedKey)
+	return err == nil && value == securityGroupRuleManagedValue
+}
+
+// findSecurityGroupRule returns the index of the matching rule in the template elements, -1 if not found
+func findSecurityGroupRule(tpl *securitygroup.Template, attrs map[string]string) int {
+
+	for i, e := range tpl.Elements {
+		vec, ok := e.(*dyn.Vector)
+		if !ok || vec.Key() != string(sgk.RuleVec) {
+			continue
+		}
+
+		if securityGroupRuleMatch(vec, attrs) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// parseSecurityGroupRuleID parses an ID in the format <security_group_id>:<rule_hash>
+func parseSecurityGroupRuleID(id string) (int, int, error) {
+
+	parts := strings.SplitN(id, ":", 2)
+	if len(parts) != 2 {
+		return -1, -1, fmt.Errorf("Invalid ID %q, expected format: <security_group_id>:<rule_hash>", id)
+	}
+
+	sgID, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return -1, -1, fmt.Errorf("Invalid security group ID %q: %s", parts[0], err)
+	}
+
+	hash, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return -1, -1, fmt.Errorf("Invalid rule hash %q: %s", parts[1], err)
+	}
+
+	return sgID, hash, nil
+}
+
+// commitSecurityGroupRules updates the security group template then commit the changes to the virtual machines if required
+func commitSecurityGroupRules(sgc *goca.SecurityGroupController, tpl *securitygroup.Template, commit bool) error {
+
+	err := sgc.Update(tpl.String(), 0)
+	if err != nil {
+		return err
+	}
+
+	if commit {
+		// Only update outdated VMs not all
+		err = sgc.Commit(true)
+		if err != nil {
+			return fmt.Errorf("Failed to commit rules: %s", err)
+		}
+	}
+
+	return nil
+}
+
+func resourceOpennebulaSecurityGroupRuleCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	config := meta.(*Configuration)
+	controller := config.Controller
+
+	sgID := d.Get("security_group_id").(int)
+
+	// serialize the updates of the security group template
+	sgKey := securityGroupKey(sgID)
+	config.mutex.Lock(sgKey)
+	defer config.mutex.Unlock(sgKey)
+
+	sgc := controller.SecurityGroup(sgID)
+
+	securityGroup, err := sgc.Info(false)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to retrieve informations",
+			Detail:   fmt.Sprintf("security group (ID: %d): %s", sgID, err),
+		})
+		return diags
+	}
+
+	attrs := getSecurityGroupRuleAttributes(d)
+	tpl := &securityGroup.Template
+
+	if findSecurityGroupRule(tpl, attrs) >= 0 {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to add the rule",
+			Detail:   fmt.Sprintf("security group (ID: %d): the rule already exists", sgID),
+		})
+		return diags
+	}
+
+	rule := tpl.AddRule()
+	for k, v := range attrs {
+		rule.AddPair(k, v)
+	}
+	rule.AddPair(securityGroupRuleManagedKey, securityGroupRuleManagedValue)
+
+	err = commitSecurityGroupRules(sgc, tpl, d.Get("commit").(bool))
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to add the rule",
+			Detail:   fmt.Sprintf("security group (ID: %d): %s", sgID, err),
+		})
+		return diags
+	}
+
+	d.SetId(fmt.Sprintf("%d:%d", sgID, securityGroupRuleHash(attrs)))
+
+	log.Printf("[INFO] Successfully added rule %s\n", d.Id())
+
+	return resourceOpennebulaSecurityGroupRuleRead(ctx, d, meta)
+}
+
+func resourceOpennebulaSecurityGroupRuleRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	config := meta.(*Configuration)
+	controller := config.Controller
+
+	sgID, _, err := parseSecurityGroupRuleID(d.Id())
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to parse security group rule ID",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+
+	securityGroup, err := controller.SecurityGroup(sgID).Info(false)
+	if err != nil {
+		if NoExists(err) {
+			log.Printf("[WARN] Removing security group rule %s from state because the security group no longer exists", d.Id())
+			d.SetId("")
+			return nil
+		}
+
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to retrieve informations",
+			Detail:   fmt.Sprintf("security group rule (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
+	if findSecurityGroupRule(&securityGroup.Template, getSecurityGroupRuleAttributes(d)) < 0 {
+		log.Printf("[WARN] Removing security group rule %s from state because it no longer exists", d.Id())
+		d.SetId("")
+		return nil
+	}
+
+	d.Set("security_group_id", sgID)
+
+	return nil
+}
+
+func resourceOpennebulaSecurityGroupRuleUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+
+	// Only "commit" could be updated, it's only used on rule addition or deletion
+
+	return resourceOpennebulaSecurityGroupRuleRead(ctx, d, meta)
+}
+
+func resourceOpennebulaSecurityGroupRuleDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	config := meta.(*Configuration)
+	controller := config.Controller
+
+	sgID := d.Get("security_group_id").(int)
+
+	sgKey := securityGroupKey(sgID)
+	config.mutex.Lock(sgKey)
+	defer config.mutex.Unlock(sgKey)
+
+	sgc := controller.SecurityGroup(sgID)
+
+	securityGroup, err := sgc.Info(false)
+	if err != nil {
+		if NoExists(err) {
+			return nil
+		}
+
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to retrieve informations",
+			Detail:   fmt.Sprintf("security group rule (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
+	tpl := &securityGroup.Template
+
+	idx := findSecurityGroupRule(tpl, getSecurityGroupRuleAttributes(d))
+	if idx < 0 {
+		log.Printf("[WARN] Security group rule %s already removed", d.Id())
+		return nil
+	}
+	tpl.Elements = append(tpl.Elements[:idx], tpl.Elements[idx+1:]...)
+
+	err = commitSecurityGroupRules(sgc, tpl, d.Get("commit").(bool))
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to remove the rule",
+			Detail:   fmt.Sprintf("security group rule (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
+	log.Printf("[INFO] Successfully removed rule %s\n", d.Id())
+
+	return nil
+}
+
+// resourceOpennebulaSecurityGroupRuleImport parses an ID in the format <security_group_id>:<rule_hash>
+// and retrieves the attributes of the rule having this hash
+func resourceOpennebulaSecurityGroupRuleImport(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+
+	config := meta.(*Configuration)
+	controller := config.Controller
+
+	sgID, hash, err := parseSecurityGroupRuleID(d.Id())
+	if err != nil {
+		return nil, err
+	}
+
+	securityGroup, err := controller.SecurityGroup(sgID).Info(false)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to retrieve security group (ID: %d): %s", sgID, err)
+	}
+
+	for _, rule := range securityGroup.Template.GetRules() {
+		attrs := getSecurityGroupRuleVectorAttributes(&rule.Vector)
+		if securityGroupRuleHash(attrs) != hash {
+			continue
+		}
+
+		d.Set("security_group_id", sgID)
+		for k, key := range securityGroupRuleKeys {
+			d.Set(k, attrs[string(key)])
+		}
+		d.Set("commit", true)
+
+		return []*schema.ResourceData{d}, nil
+	}
+
+	return nil, fmt.Errorf("No rule with hash %d in security group (ID: %d)", hash, sgID)
+}
@@ -0,0 +1,332 @@
+package opennebula
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+
+	dyn "github.com/OpenNebula/one/src/oca/go/src/goca/dynamic"
+	"github.com/OpenNebula/one/src/oca/go/src/goca/schemas/securitygroup"
+)
+
+func TestAccSecurityGroupRule(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckSecurityGroupRuleDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccSecurityGroupRuleConfigBasic,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair("opennebula_security_group_rule.ssh", "security_group_id", "opennebula_security_group.shared", "id"),
+					resource.TestCheckResourceAttr("opennebula_security_group_rule.ssh", "protocol", "TCP"),
+					resource.TestCheckResourceAttr("opennebula_security_group_rule.ssh", "rule_type", "INBOUND"),
+					resource.TestCheckResourceAttr("opennebula_security_group_rule.ssh", "range", "22"),
+					resource.TestCheckResourceAttr("opennebula_security_group_rule.http", "range", "80,443"),
+					testAccCheckSecurityGroupRulesCount("opennebula_security_group.shared", 3),
+				),
+			},
+			{
+				ResourceName:      "opennebula_security_group_rule.ssh",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			{
+				// equivalent port ranges spelling doesn't produce any diff
+				Config:   strings.Replace(testAccSecurityGroupRuleConfigBasic, `"80,443"`, `"443,80"`, 1),
+				PlanOnly: true,
+			},
+			{
+				Config: testAccSecurityGroupRuleConfigUpdate,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("opennebula_security_group_rule.ssh", "range", "2222"),
+					testAccCheckSecurityGroupRulesCount("opennebula_security_group.shared", 2),
+				),
+			},
+		},
+	})
+}
+
+func TestAccSecurityGroupRuleConcurrent(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckSecurityGroupRuleDestroy,
+		Steps: []resource.TestStep{
+			{
+				// both rules are added at the same time to the same group
+				Config: testAccSecurityGroupRuleConfigConcurrent,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("opennebula_security_group_rule.port.0", "range", "8000"),
+					resource.TestCheckResourceAttr("opennebula_security_group_rule.port.1", "range", "8001"),
+					testAccCheckSecurityGroupRulesCount("opennebula_security_group.concurrent", 2),
+				),
+			},
+		},
+	})
+}
+
+func TestAccSecurityGroupRuleInline(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckSecurityGroupRuleDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(testAccSecurityGroupRuleConfigInline, "22"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("opennebula_security_group.inline", "rule.#", "1"),
+					resource.TestCheckResourceAttr("opennebula_security_group.inline", "rule.0.range", "22"),
+					testAccCheckSecurityGroupRulesCount("opennebula_security_group.inline", 2),
+				),
+			},
+			{
+				// the rule of the rule resource is kept when the inline rules change
+				Config: fmt.Sprintf(testAccSecurityGroupRuleConfigInline, "2222"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("opennebula_security_group.inline", "rule.#", "1"),
+					resource.TestCheckResourceAttr("opennebula_security_group.inline", "rule.0.range", "2222"),
+					resource.TestCheckResourceAttr("opennebula_security_group_rule.http", "range", "80"),
+					testAccCheckSecurityGroupRulesCount("opennebula_security_group.inline", 2),
+				),
+			},
+		},
+	})
+}
+
+func TestSecurityGroupInlineRules(t *testing.T) {
+
+	tpl := securitygroup.NewTemplate()
+	tpl.AddPair("DESCRIPTION", "test")
+
+	inline := tpl.AddVector("RULE")
+	inline.AddPair("PROTOCOL", "TCP")
+	inline.AddPair("RULE_TYPE", "INBOUND")
+	inline.AddPair("RANGE", "22")
+
+	managed := tpl.AddVector("RULE")
+	managed.AddPair("PROTOCOL", "TCP")
+	managed.AddPair("RULE_TYPE", "INBOUND")
+	managed.AddPair("RANGE", "80")
+	managed.AddPair(securityGroupRuleManagedKey, securityGroupRuleManagedValue)
+
+	rules := securityGroupInlineRules(tpl)
+	if len(rules) != 1 {
+		t.Fatalf("expected 1 inline rule, got %d", len(rules))
+	}
+	if rng, _ := rules[0].GetStr("RANGE"); rng != "22" {
+		t.Errorf("expected the inline rule range 22, got %s", rng)
+	}
+
+	delSecurityGroupInlineRules(tpl)
+
+	rules = tpl.GetRules()
+	if len(rules) != 1 || !isSecurityGroupRuleManaged(&rules[0].Vector) {
+		t.Errorf("expected only the managed rule to be kept, got %s", tpl.String())
+	}
+	if _, err := tpl.GetStr("DESCRIPTION"); err != nil {
+		t.Errorf("expected the other pairs to be kept, got %s", tpl.String())
+	}
+}
+
+func TestSecurityGroupRuleMatch(t *testing.T) {
+
+	rule := dyn.NewVector("RULE")
+	rule.AddPair("PROTOCOL", "TCP")
+	rule.AddPair("RULE_TYPE", "inbound")
+	rule.AddPair("RANGE", "443,80")
+	// pairs added by OpenNebula are ignored
+	rule.AddPair("SECURITY_GROUP_ID", "100")
+
+	cases := []struct {
+		attrs  map[string]string
+		expect bool
+	}{
+		{map[string]string{"PROTOCOL": "TCP", "RULE_TYPE": "INBOUND", "RANGE": "80,443"}, true},
+		{map[string]string{"PROTOCOL": "TCP", "RULE_TYPE": "INBOUND", "RANGE": "80"}, false},
+		{map[string]string{"PROTOCOL": "TCP", "RULE_TYPE": "INBOUND"}, false},
+		{map[string]string{"PROTOCOL": "TCP", "RULE_TYPE": "INBOUND", "RANGE": "80,443", "ICMP_TYPE": "8"}, false},
+	}
+
+	for _, c := range cases {
+		if securityGroupRuleMatch(rule, c.attrs) != c.expect {
+			t.Errorf("expected match %t for %v", c.expect, c.attrs)
+		}
+	}
+
+	// the hash of the rule vector is the one of the equivalent attributes
+	hash := securityGroupRuleHash(getSecurityGroupRuleVectorAttributes(rule))
+	if hash != securityGroupRuleHash(cases[0].attrs) {
+		t.Errorf("expected the rule vector hash to match the attributes hash")
+	}
+}
+
+func TestAccSecurityGroupRuleValidation(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config:      fmt.Sprintf(testAccSecurityGroupRuleConfigInvalid, "TCP", `range = "80:22"`),
+				ExpectError: regexp.MustCompile("the first port must be lower than the last one"),
+			},
+			{
+				Config:      fmt.Sprintf(testAccSecurityGroupRuleConfigInvalid, "ICMP", `range = "22"`),
+				ExpectError: regexp.MustCompile("\"range\" can only be defined for the TCP and UDP protocols"),
+			},
+			{
+				Config:      fmt.Sprintf(testAccSecurityGroupRuleConfigInvalid, "ICMP", `icmp_type = "echo"`),
+				ExpectError: regexp.MustCompile("must be an integer between 0 and 255"),
+			},
+			{
+				Config:      fmt.Sprintf(testAccSecurityGroupRuleConfigInvalid, "ALL", `ip = "10.0.0.1"`),
+				ExpectError: regexp.MustCompile("\"size\" is required when \"ip\" is defined"),
+			},
+			{
+				Config:      fmt.Sprintf(testAccSecurityGroupRuleConfigInvalid, "ALL", "ip = \"255.255.255.250\"\n  size = \"10\""),
+				ExpectError: regexp.MustCompile("exceeds the address space"),
+			},
+		},
+	})
+}
+
+func testAccCheckSecurityGroupRuleDestroy(s *terraform.State) error {
+	config := testAccProvider.Meta().(*Configuration)
+	controller := config.Controller
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "opennebula_security_group" {
+			continue
+		}
+
+		sgID, _ := strconv.ParseUint(rs.Primary.ID, 10, 0)
+		sg, _ := controller.SecurityGroup(int(sgID)).Info(false)
+		if sg != nil {
+			return fmt.Errorf("Expected security group %s to have been destroyed", rs.Primary.ID)
+		}
+	}
+
+	return nil
+}
+
+func testAccCheckSecurityGroupRulesCount(resourceName string, count int) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		config := testAccProvider.Meta().(*Configuration)
+		controller := config.Controller
+
+		rs, ok := s.RootModule().Resources[resourceName]
+		if !ok {
+			return fmt.Errorf("Resource %s not found", resourceName)
+		}
+
+		sgID, _ := strconv.ParseUint(rs.Primary.ID, 10, 0)
+		sg, err := controller.SecurityGroup(int(sgID)).Info(false)
+		if err != nil {
+			return err
+		}
+
+		rules := sg.Template.GetRules()
+		if len(rules) != count {
+			return fmt.Errorf("Expected %d rules in security group %s, got %d", count, rs.Primary.ID, len(rules))
+		}
+
+		return nil
+	}
+}
+
+var testAccSecurityGroupRuleConfigBasic = `
+resource "opennebula_security_group" "shared" {
+  name        = "test-sg-rules"
+  description = "Shared security group"
+}
+
+resource "opennebula_security_group_rule" "outbound" {
+  security_group_id = opennebula_security_group.shared.id
+  protocol          = "ALL"
+  rule_type         = "OUTBOUND"
+}
+
+resource "opennebula_security_group_rule" "ssh" {
+  security_group_id = opennebula_security_group.shared.id
+  protocol          = "TCP"
+  rule_type         = "INBOUND"
+  range             = "22"
+}
+
+resource "opennebula_security_group_rule" "http" {
+  security_group_id = opennebula_security_group.shared.id
+  protocol          = "TCP"
+  rule_type         = "INBOUND"
+  range             = "80,443"
+  commit            = false
+}
+`
+
+var testAccSecurityGroupRuleConfigUpdate = `
+resource "opennebula_security_group" "shared" {
+  name        = "test-sg-rules"
+  description = "Shared security group"
+}
+
+resource "opennebula_security_group_rule" "outbound" {
+  security_group_id = opennebula_security_group.shared.id
+  protocol          = "ALL"
+  rule_type         = "OUTBOUND"
+}
+
+resource "opennebula_security_group_rule" "ssh" {
+  security_group_id = opennebula_security_group.shared.id
+  protocol          = "TCP"
+  rule_type         = "INBOUND"
+  range             = "2222"
+}
+`
+
+var testAccSecurityGroupRuleConfigInline = `
+resource "opennebula_security_group" "inline" {
+  name        = "test-sg-rules-inline"
+  description = "Security group with inline rules"
+
+  rule {
+    protocol  = "TCP"
+    rule_type = "INBOUND"
+    range     = "%s"
+  }
+}
+
+resource "opennebula_security_group_rule" "http" {
+  security_group_id = opennebula_security_group.inline.id
+  protocol          = "TCP"
+  rule_type         = "INBOUND"
+  range             = "80"
+}
+`
+
+var testAccSecurityGroupRuleConfigConcurrent = `
+resource "opennebula_security_group" "concurrent" {
+  name        = "test-sg-rules-concurrent"
+  description = "Security group with concurrent rules"
+}
+
+resource "opennebula_security_group_rule" "port" {
+  count             = 2
+  security_group_id = opennebula_security_group.concurrent.id
+  protocol          = "TCP"
+  rule_type         = "INBOUND"
+  range             = tostring(8000 + count.index)
+}
+`
+
+var testAccSecurityGroupRuleConfigInvalid = `
+resource "opennebula_security_group_rule" "invalid" {
+  security_group_id = 0
+  protocol          = "%s"
+  rule_type         = "INBOUND"
+  %s
+}
+`
@@ -50,6 +50,42 @@ func TestAccSecurityGroup(t *testing.T) {
 					resource.TestCheckResourceAttr("opennebula_security_group.mysecgroup", "tags.env", "dev"),
 					resource.TestCheckResourceAttr("opennebula_security_group.mysecgroup", "tags.customer", "test"),
 					resource.TestCheckResourceAttr("opennebula_security_group.mysecgroup", "tags.version", "2"),
+					resource.TestCheckResourceAttr("opennebula_security_group.mysecgroup", "wait_for_commit", "true"),
+					resource.TestCheckResourceAttr("opennebula_security_group.mysecgroup", "outdated_vms.#", "0"),
+					resource.TestCheckResourceAttr("opennebula_security_group.mysecgroup", "updating_vms.#", "0"),
+					resource.TestCheckResourceAttr("opennebula_security_group.mysecgroup", "error_vms.#", "0"),
+				),
+			},
+			{
+				Config: testAccSecurityGroupConfigDefaultTags,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("opennebula_security_group.mysecgroup", "tags.%", "3"),
+					resource.TestCheckResourceAttr("opennebula_security_group.mysecgroup", "tags_all.%", "5"),
+					resource.TestCheckResourceAttr("opennebula_security_group.mysecgroup", "tags_all.env", "dev"),
+					resource.TestCheckResourceAttr("opennebula_security_group.mysecgroup", "tags_all.owner", "ops"),
+					resource.TestCheckResourceAttr("opennebula_security_group.mysecgroup", "tags_all.team", "infra"),
+					testAccCheckSecurityGroupTag("ENV", "dev"),
+					testAccCheckSecurityGroupTag("TEAM", "infra"),
+				),
+			},
+			{
+				Config: testAccSecurityGroupConfigDefaultTagsRemoved,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("opennebula_security_group.mysecgroup", "tags.%", "3"),
+					resource.TestCheckResourceAttr("opennebula_security_group.mysecgroup", "tags_all.%", "4"),
+					resource.TestCheckResourceAttr("opennebula_security_group.mysecgroup", "tags_all.owner", "ops"),
+					resource.TestCheckNoResourceAttr("opennebula_security_group.mysecgroup", "tags_all.team"),
+					resource.TestCheckResourceAttr("opennebula_security_group.mysecgroup", "rule.#", "3"),
+					testAccCheckSecurityGroupTag("OWNER", "ops"),
+					testAccCheckSecurityGroupTag("TEAM", ""),
+				),
+			},
+			{
+				// removing all the rule blocks removes the rules
+				Config: testAccSecurityGroupConfigNoRules,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("opennebula_security_group.mysecgroup", "rule.#", "0"),
+					testAccCheckSecurityGroupRulesCount("opennebula_security_group.mysecgroup", 0),
 				),
 			},
 		},
@@ -75,6 +111,39 @@ func testAccCheckSecurityGroupDestroy(s *terraform.State) error {
 	return nil
 }
 
+// testAccCheckSecurityGroupTag checks the value of a tag in the template of
+// the security group, an empty value meaning that the tag has been deleted
+func testAccCheckSecurityGroupTag(key, value string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		config := testAccProvider.Meta().(*Configuration)
+		controller := config.Controller
+
+		for _, rs := range s.RootModule().Resources {
+			if rs.Type != "opennebula_security_group" {
+				continue
+			}
+			sgID, _ := strconv.ParseUint(rs.Primary.ID, 10, 0)
+			sg, err := controller.SecurityGroup(int(sgID)).Info(false)
+			if err != nil {
+				return fmt.Errorf("Expected security group %s to exist when checking tags: %s", rs.Primary.ID, err)
+			}
+
+			tagValue, err := sg.Template.GetStr(key)
+			if value == "" {
+				if err == nil {
+					return fmt.Errorf("Expected tag %s of security group %s to be deleted, got %s", key, rs.Primary.ID, tagValue)
+				}
+				continue
+			}
+			if err != nil || tagValue != value {
+				return fmt.Errorf("Expected tag %s = %s for security group %s, got %s", key, value, rs.Primary.ID, tagValue)
+			}
+		}
+
+		return nil
+	}
+}
+
 func testAccSecurityGroupRule(ruleidx int, key, value string) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 		config := testAccProvider.Meta().(*Configuration)
@@ -142,6 +211,7 @@ var testAccSecurityGroupConfigUpdate = `
 resource "opennebula_security_group" "mysecgroup" {
     name = "renamedsg"
     description = "Terraform security group"
+    wait_for_commit = true
     permissions = "660"
     rule {
         protocol = "ALL"
@@ -163,3 +233,38 @@ resource "opennebula_security_group" "mysecgroup" {
     }
 }
 `
+
+var testAccSecurityGroupConfigDefaultTags = `
+provider "opennebula" {
+  default_tags {
+    tags = {
+      env   = "default"
+      owner = "ops"
+      team  = "infra"
+    }
+  }
+}
+` + testAccSecurityGroupConfigUpdate
+
+var testAccSecurityGroupConfigDefaultTagsRemoved = `
+provider "opennebula" {
+  default_tags {
+    tags = {
+      owner = "ops"
+    }
+  }
+}
+` + testAccSecurityGroupConfigUpdate
+
+var testAccSecurityGroupConfigNoRules = `
+resource "opennebula_security_group" "mysecgroup" {
+    name = "renamedsg"
+    description = "Terraform security group"
+    permissions = "660"
+    tags = {
+      env = "dev"
+      customer = "test"
+      version = "2"
+    }
+}
+`
@@ -29,6 +29,7 @@ func resourceOpennebulaTemplate() *schema.Resource {
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
+		CustomizeDiff: tagsAllCustomizeDiff,
 		Schema: mergeSchemas(
 			commonTemplateSchemas(),
 			map[string]*schema.Schema{
@@ -278,6 +279,8 @@ func resourceOpennebulaTemplateCreateCustom(ctx context.Context, d *schema.Resou
 
 	var diags diag.Diagnostics
 
+	setTagsAll(d, meta)
+
 	tpl, err := generateTemplate(d)
 	if err != nil {
 		diags = append(diags, diag.Diagnostic{
@@ -482,6 +485,16 @@ func resourceOpennebulaTemplateReadCustom(ctx context.Context, d *schema.Resourc
 		return diags
 	}
 
+	err = flattenTagsAll(d, meta, &tpl.Template.Template)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to set attribute",
+			Detail:   fmt.Sprintf("template (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
 	rawVec, _ := tpl.Template.GetVector("RAW")
 	if rawVec != nil {
 
@@ -571,6 +584,8 @@ func resourceOpennebulaTemplateUpdateCustom(ctx context.Context, d *schema.Resou
 
 	var diags diag.Diagnostics
 
+	setTagsAll(d, meta)
+
 	//Get Template
 	tc, err := getTemplateController(d, meta)
 	if err != nil {
@@ -734,6 +749,14 @@ func resourceOpennebulaTemplateUpdateCustom(ctx context.Context, d *schema.Resou
 		update = true
 	}
 
+	if d.HasChange("sched_action") {
+		newTpl.Del(vmk.SchedActionVec)
+
+		addSchedActions(&newTpl, d.Get("sched_action").([]interface{}))
+
+		update = true
+	}
+
 	if d.HasChange("cpumodel") {
 		newTpl.Del("CPU_MODEL")
 		cpumodel := d.Get("cpumodel").([]interface{})
@@ -786,11 +809,9 @@ func resourceOpennebulaTemplateUpdateCustom(ctx context.Context, d *schema.Resou
 		update = true
 	}
 
-	if d.HasChange("tags") {
+	if d.HasChange("tags_all") {
 
-		oldTagsIf, newTagsIf := d.GetChange("tags")
-		oldTags := oldTagsIf.(map[string]interface{})
-		newTags := newTagsIf.(map[string]interface{})
+		oldTags, newTags := tagsAllChange(d)
 
 		// delete tags
 		for k, _ := range oldTags {
@@ -112,6 +112,15 @@ func TestAccTemplate(t *testing.T) {
 					}),
 					resource.TestCheckResourceAttr("opennebula_template.template", "sched_requirements", "CLUSTER_ID!=\"123\""),
 					resource.TestCheckResourceAttr("opennebula_template.template", "description", "Template created for provider acceptance tests - updated"),
+					resource.TestCheckResourceAttr("opennebula_template.template", "sched_action.#", "2"),
+					resource.TestCheckResourceAttr("opennebula_template.template", "sched_action.0.action", "poweroff"),
+					resource.TestCheckResourceAttr("opennebula_template.template", "sched_action.0.time", "+3600"),
+					resource.TestCheckResourceAttr("opennebula_template.template", "sched_action.0.repeat", "WEEKLY"),
+					resource.TestCheckResourceAttr("opennebula_template.template", "sched_action.0.days", "1,5"),
+					resource.TestCheckResourceAttr("opennebula_template.template", "sched_action.0.end_type", "TIMES"),
+					resource.TestCheckResourceAttr("opennebula_template.template", "sched_action.0.end_value", "10"),
+					resource.TestCheckResourceAttr("opennebula_template.template", "sched_action.1.action", "snapshot-create"),
+					resource.TestCheckResourceAttr("opennebula_template.template", "sched_action.1.args", "nightly"),
 					resource.TestCheckResourceAttrSet("opennebula_template.template", "uid"),
 					resource.TestCheckResourceAttrSet("opennebula_template.template", "gid"),
 					resource.TestCheckResourceAttrSet("opennebula_template.template", "uname"),
@@ -142,6 +151,7 @@ func TestAccTemplate(t *testing.T) {
 					resource.TestCheckResourceAttr("opennebula_template.template", "tags.env", "dev"),
 					resource.TestCheckResourceAttr("opennebula_template.template", "tags.customer", "test"),
 					resource.TestCheckNoResourceAttr("opennebula_template.template", "tags.version"),
+					resource.TestCheckResourceAttr("opennebula_template.template", "sched_action.#", "0"),
 					resource.TestCheckResourceAttrSet("opennebula_template.template", "uid"),
 					resource.TestCheckResourceAttrSet("opennebula_template.template", "gid"),
 					resource.TestCheckResourceAttrSet("opennebula_template.template", "uname"),
@@ -341,6 +351,20 @@ resource "opennebula_template" "template" {
 
   sched_requirements = "CLUSTER_ID!=\"123\""
 
+  sched_action {
+    action    = "poweroff"
+    time      = "+3600"
+    repeat    = "WEEKLY"
+    days      = "1,5"
+    end_type  = "TIMES"
+    end_value = 10
+  }
+
+  sched_action {
+    action = "snapshot-create"
+    time   = "+7200"
+    args   = "nightly"
+  }
 }
 `
 
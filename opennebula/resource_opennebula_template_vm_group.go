@@ -25,6 +25,7 @@ func resourceOpennebulaVMGroup() *schema.Resource {
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
+		CustomizeDiff: tagsAllCustomizeDiff,
 
 		Schema: map[string]*schema.Schema{
 			"name": {
@@ -134,7 +135,8 @@ func resourceOpennebulaVMGroup() *schema.Resource {
 				Optional:    true,
 				Description: "Name of the Group that onws the Template VM Group, If empty, it uses caller group",
 			},
-			"tags": tagsSchema(),
+			"tags":     tagsSchema(),
+			"tags_all": tagsAllSchema(),
 		},
 	}
 }
@@ -197,6 +199,8 @@ func resourceOpennebulaVMGroupCreate(ctx context.Context, d *schema.ResourceData
 
 	var diags diag.Diagnostics
 
+	setTagsAll(d, meta)
+
 	vmg, err := generateVMGroup(d)
 	if err != nil {
 		diags = append(diags, diag.Diagnostic{
@@ -309,6 +313,16 @@ func resourceOpennebulaVMGroupRead(ctx context.Context, d *schema.ResourceData,
 		return diags
 	}
 
+	err = flattenTagsAll(d, meta, &vmg.Template)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to set attribute",
+			Detail:   fmt.Sprintf("VM group (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
 	return nil
 }
 
@@ -392,6 +406,8 @@ func resourceOpennebulaVMGroupUpdate(ctx context.Context, d *schema.ResourceData
 
 	var diags diag.Diagnostics
 
+	setTagsAll(d, meta)
+
 	//Get VMGroup
 	vmgc, err := getVMGroupController(d, meta)
 	if err != nil {
@@ -462,14 +478,25 @@ func resourceOpennebulaVMGroupUpdate(ctx context.Context, d *schema.ResourceData
 		log.Printf("[INFO] Successfully updated group for VMGroup %s\n", vmg.Name)
 	}
 
-	if d.HasChange("tags") {
-		tagsInterface := d.Get("tags").(map[string]interface{})
-		for k, v := range tagsInterface {
+	if d.HasChange("tags_all") {
+		oldTags, newTags := tagsAllChange(d)
+
+		// delete tags
+		for k, _ := range oldTags {
+			_, ok := newTags[k]
+			if ok {
+				continue
+			}
+			vmg.Template.Del(strings.ToUpper(k))
+		}
+
+		// add/update tags
+		for k, v := range newTags {
 			vmg.Template.Del(strings.ToUpper(k))
 			vmg.Template.AddPair(strings.ToUpper(k), v.(string))
 		}
 
-		err = vmgc.Update(vmg.Template.String(), 1)
+		err = vmgc.Update(vmg.Template.String(), 0)
 		if err != nil {
 			diags = append(diags, diag.Diagnostic{
 				Severity: diag.Error,
@@ -559,7 +586,7 @@ func generateVMGroup(d *schema.ResourceData) (string, error) {
 
 	}
 
-	tagsInterface := d.Get("tags").(map[string]interface{})
+	tagsInterface := d.Get("tags_all").(map[string]interface{})
 	for k, v := range tagsInterface {
 		tpl.AddPair(strings.ToUpper(k), v)
 	}
@@ -28,6 +28,7 @@ func resourceOpennebulaUser() *schema.Resource {
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
+		CustomizeDiff: tagsAllCustomizeDiff,
 
 		Schema: map[string]*schema.Schema{
 			"name": {
@@ -69,8 +70,9 @@ func resourceOpennebulaUser() *schema.Resource {
 					Type: schema.TypeInt,
 				},
 			},
-			"quotas": quotasSchema(),
-			"tags":   tagsSchema(),
+			"quotas":   quotasSchema(),
+			"tags":     tagsSchema(),
+			"tags_all": tagsAllSchema(),
 		},
 	}
 }
@@ -107,6 +109,8 @@ func resourceOpennebulaUserCreate(ctx context.Context, d *schema.ResourceData, m
 
 	var diags diag.Diagnostics
 
+	setTagsAll(d, meta)
+
 	userName := d.Get("name").(string)
 	userAuthDriver := d.Get("auth_driver").(string)
 	var userPassword string
@@ -169,7 +173,7 @@ func resourceOpennebulaUserCreate(ctx context.Context, d *schema.ResourceData, m
 
 	tpl := dyn.NewTemplate()
 
-	tagsInterface := d.Get("tags").(map[string]interface{})
+	tagsInterface := d.Get("tags_all").(map[string]interface{})
 	for k, v := range tagsInterface {
 		tpl.AddPair(strings.ToUpper(k), v)
 	}
@@ -275,6 +279,16 @@ func resourceOpennebulaUserRead(ctx context.Context, d *schema.ResourceData, met
 		return diags
 	}
 
+	err = flattenTagsAll(d, meta, &user.Template)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to set attribute",
+			Detail:   fmt.Sprintf("user (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
 	return nil
 }
 
@@ -329,6 +343,8 @@ func resourceOpennebulaUserUpdate(ctx context.Context, d *schema.ResourceData, m
 
 	var diags diag.Diagnostics
 
+	setTagsAll(d, meta)
+
 	uc, err := getUserController(d, meta)
 	if err != nil {
 		diags = append(diags, diag.Diagnostic{
@@ -451,11 +467,9 @@ func resourceOpennebulaUserUpdate(ctx context.Context, d *schema.ResourceData, m
 	update := false
 	newTpl := userInfos.Template
 
-	if d.HasChange("tags") {
+	if d.HasChange("tags_all") {
 
-		oldTagsIf, newTagsIf := d.GetChange("tags")
-		oldTags := oldTagsIf.(map[string]interface{})
-		newTags := newTagsIf.(map[string]interface{})
+		oldTags, newTags := tagsAllChange(d)
 
 		// delete tags
 		for k, _ := range oldTags {
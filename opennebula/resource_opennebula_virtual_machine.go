@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 
 	"github.com/OpenNebula/one/src/oca/go/src/goca"
@@ -21,6 +22,7 @@ import (
 
 var (
 	vmDiskOnChangeValues = []string{"RECREATE", "SWAP"}
+	vmDesiredStates      = []string{"RUNNING", "POWEROFF", "SUSPENDED", "UNDEPLOYED", "STOPPED"}
 
 	defaultVMTimeoutMin = 20
 	defaultVMTimeout    = time.Duration(defaultVMTimeoutMin) * time.Minute
@@ -33,7 +35,7 @@ func resourceOpennebulaVirtualMachine() *schema.Resource {
 		Exists:        resourceOpennebulaVirtualMachineExists,
 		UpdateContext: resourceOpennebulaVirtualMachineUpdate,
 		DeleteContext: resourceOpennebulaVirtualMachineDelete,
-		CustomizeDiff: resourceVMCustomizeDiff,
+		CustomizeDiff: customdiff.All(resourceVMCustomizeDiff, tagsAllCustomizeDiff),
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(defaultVMTimeout),
 			Update: schema.DefaultTimeout(defaultVMTimeout),
@@ -71,6 +73,27 @@ func resourceOpennebulaVirtualMachine() *schema.Resource {
 					Description: "Id of the VM template to use. Defaults to -1: no template used.",
 				},
 				"template_nic": templateNICVMSchema(),
+				"reschedule_on_requirements_change": {
+					Type:        schema.TypeBool,
+					Optional:    true,
+					Default:     false,
+					Description: "Reschedule the running VM when sched_requirements or sched_ds_requirements change",
+				},
+				"desired_state": {
+					Type:        schema.TypeString,
+					Optional:    true,
+					Computed:    true,
+					Description: "Power state of the VM: RUNNING, POWEROFF, SUSPENDED, UNDEPLOYED, STOPPED",
+					ValidateFunc: func(v interface{}, k string) (ws []string, errors []error) {
+						value := v.(string)
+
+						if inArray(value, vmDesiredStates) < 0 {
+							errors = append(errors, fmt.Errorf("%q must be one of: %s", k, strings.Join(vmDesiredStates, ",")))
+						}
+
+						return
+					},
+				},
 			},
 		),
 	}
@@ -87,6 +110,22 @@ func nicComputedVMFields() map[string]*schema.Schema {
 			Type:     schema.TypeString,
 			Computed: true,
 		},
+		"computed_ip6": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"computed_ip6_global": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"computed_ip6_ula": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"computed_ip6_link": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
 		"computed_mac": {
 			Type:     schema.TypeString,
 			Computed: true,
@@ -269,6 +308,8 @@ func resourceOpennebulaVirtualMachineCreate(ctx context.Context, d *schema.Resou
 
 	var diags diag.Diagnostics
 
+	setTagsAll(d, meta)
+
 	//Call one.template.instantiate only if template_id is defined
 	//otherwise use one.vm.allocate
 	var err error
@@ -417,6 +458,19 @@ func resourceOpennebulaVirtualMachineCreate(ctx context.Context, d *schema.Resou
 		}
 	}
 
+	// a pending VM is left on hold
+	if desiredState, ok := d.GetOk("desired_state"); ok && !d.Get("pending").(bool) {
+		err = vmSetDesiredState(ctx, vmc, timeout, desiredState.(string), d.Get("hard_shutdown").(bool))
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to change state",
+				Detail:   fmt.Sprintf("virtual machine (ID: %s): %s", d.Id(), err),
+			})
+			return diags
+		}
+	}
+
 	if lock, ok := d.GetOk("lock"); ok && lock.(string) != "UNLOCK" {
 
 		var level shared.LockLevel
@@ -483,6 +537,9 @@ func resourceOpennebulaVirtualMachineCreate(ctx context.Context, d *schema.Resou
 				})
 				return diags
 			}
+
+			flattenVMDesiredState(d, vmInfos)
+
 			return nil
 		})
 
@@ -514,9 +571,8 @@ func resourceOpennebulaVirtualMachineReadCustom(ctx context.Context, d *schema.R
 
 	}
 
-	// TODO: fix it after 5.10 release
-	// Force the "decrypt" bool to false to keep ONE 5.8 behavior
-	vm, err := vmc.Info(false)
+	config := meta.(*Configuration)
+	vm, historyReasons, err := vmInfoWithHistory(config.Controller, vmc.ID)
 	if err != nil {
 		diags = append(diags, diag.Diagnostic{
 			Severity: diag.Error,
@@ -533,6 +589,17 @@ func resourceOpennebulaVirtualMachineReadCustom(ctx context.Context, d *schema.R
 	d.Set("gname", vm.GName)
 	d.Set("state", vm.StateRaw)
 	d.Set("lcmstate", vm.LCMStateRaw)
+	d.Set("deploy_id", vm.DeployID)
+
+	err = flattenVMPlacement(d, vm, historyReasons)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to flatten placement",
+			Detail:   fmt.Sprintf("virtual machine (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
 	//TODO fix this:
 	err = d.Set("permissions", permissionsUnixString(*vm.Permissions))
 	if err != nil {
@@ -581,6 +648,16 @@ func resourceOpennebulaVirtualMachineReadCustom(ctx context.Context, d *schema.R
 		return diags
 	}
 
+	err = flattenTagsAll(d, meta, &vm.UserTemplate.Template)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to set attribute",
+			Detail:   fmt.Sprintf("virtual machine (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
 	if vm.LockInfos != nil {
 		d.Set("lock", LockLevelToString(vm.LockInfos.Locked))
 	}
@@ -612,10 +689,57 @@ func resourceOpennebulaVirtualMachineRead(ctx context.Context, d *schema.Resourc
 			return diags
 		}
 
+		flattenVMDesiredState(d, vmInfos)
+
 		return nil
 	})
 }
 
+// flattenVMPlacement sets the current placement of the VM and its history,
+// the current placement is the last history record
+func flattenVMPlacement(d *schema.ResourceData, vmInfos *vm.VM, historyReasons map[int]string) error {
+
+	hostID := -1
+	hostName := ""
+	clusterID := -1
+	dsID := -1
+
+	history := make([]map[string]interface{}, 0, len(vmInfos.HistoryRecords))
+	for _, record := range vmInfos.HistoryRecords {
+		history = append(history, map[string]interface{}{
+			"sequence":            record.SEQ,
+			"host_id":             record.HID,
+			"host_name":           record.Hostname,
+			"cluster_id":          record.CID,
+			"system_datastore_id": record.DSID,
+			"start_time":          record.STime,
+			"end_time":            record.ETime,
+			"reason":              historyReasons[record.SEQ],
+		})
+
+		hostID = record.HID
+		hostName = record.Hostname
+		clusterID = record.CID
+		dsID = record.DSID
+	}
+
+	d.Set("host_id", hostID)
+	d.Set("host_name", hostName)
+	d.Set("cluster_id", clusterID)
+	d.Set("system_datastore_id", dsID)
+
+	return d.Set("history", history)
+}
+
+// flattenVMDesiredState keeps the desired state unchanged while the VM is in
+// a transient state
+func flattenVMDesiredState(d *schema.ResourceData, vmInfos *vm.VM) {
+	desiredState := vmGetDesiredState(vmInfos)
+	if len(desiredState) > 0 {
+		d.Set("desired_state", desiredState)
+	}
+}
+
 func flattenVMDiskComputed(diskConfig map[string]interface{}, disk shared.Disk) map[string]interface{} {
 
 	diskMap := flattenDiskComputed(disk)
@@ -777,6 +901,10 @@ func flattenNICComputed(nic shared.NIC) map[string]interface{} {
 	nicID, _ := nic.ID()
 	sg := make([]int, 0)
 	ip, _ := nic.Get(shared.IP)
+	ip6, _ := nic.GetStr("IP6")
+	ip6Global, _ := nic.GetStr("IP6_GLOBAL")
+	ip6ULA, _ := nic.GetStr("IP6_ULA")
+	ip6Link, _ := nic.GetStr("IP6_LINK")
 	mac, _ := nic.Get(shared.MAC)
 	physicalDevice, _ := nic.GetStr("PHYDEV")
 	network, _ := nic.Get(shared.Network)
@@ -795,6 +923,10 @@ func flattenNICComputed(nic shared.NIC) map[string]interface{} {
 		"nic_id":                   nicID,
 		"network":                  network,
 		"computed_ip":              ip,
+		"computed_ip6":             ip6,
+		"computed_ip6_global":      ip6Global,
+		"computed_ip6_ula":         ip6ULA,
+		"computed_ip6_link":        ip6Link,
 		"computed_mac":             mac,
 		"computed_physical_device": physicalDevice,
 		"computed_model":           model,
@@ -1038,6 +1170,15 @@ func customVirtualMachineUpdate(ctx context.Context, d *schema.ResourceData, met
 
 	var diags diag.Diagnostics
 
+	if d.Get("reschedule_on_requirements_change").(bool) &&
+		(d.HasChange("sched_requirements") || d.HasChange("sched_ds_requirements")) {
+
+		diags = rescheduleVM(ctx, d, meta)
+		if len(diags) > 0 {
+			return diags
+		}
+	}
+
 	if d.HasChange("nic") {
 		err := updateNIC(ctx, d, meta)
 		if err != nil {
@@ -1057,6 +1198,8 @@ func resourceOpennebulaVirtualMachineUpdateCustom(ctx context.Context, d *schema
 
 	var diags diag.Diagnostics
 
+	setTagsAll(d, meta)
+
 	//Get VM
 	vmc, err := getVirtualMachineController(d, meta)
 	if err != nil {
@@ -1146,6 +1289,44 @@ func resourceOpennebulaVirtualMachineUpdateCustom(ctx context.Context, d *schema
 		log.Printf("[INFO] Successfully updated group for VM %s\n", vmInfos.Name)
 	}
 
+	// The VM is resumed or powered off before the other updates to allow disk
+	// and NIC hotplug. It's suspended, undeployed or stopped after them.
+	desiredStateLast := false
+	if d.HasChange("desired_state") {
+		desiredState := d.Get("desired_state").(string)
+		if desiredState == "RUNNING" || desiredState == "POWEROFF" {
+			diags = updateDesiredState(ctx, d, vmc)
+			if len(diags) > 0 {
+				return diags
+			}
+
+			// refresh the state used by the resize step
+			vmInfos, err = vmc.Info(false)
+			if err != nil {
+				diags = append(diags, diag.Diagnostic{
+					Severity: diag.Error,
+					Summary:  "Failed to retrieve informations",
+					Detail:   fmt.Sprintf("virtual machine (ID: %s): %s", d.Id(), err),
+				})
+				return diags
+			}
+		} else {
+			desiredStateLast = true
+		}
+	}
+
+	if d.HasChange("sched_action") {
+		err := updateSchedActions(d, meta)
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to update scheduled actions",
+				Detail:   fmt.Sprintf("virtual machine (ID: %s): %s", d.Id(), err),
+			})
+			return diags
+		}
+	}
+
 	update := false
 	tpl := &vm.Template{
 		Template: dyn.Template{
@@ -1188,11 +1369,9 @@ func resourceOpennebulaVirtualMachineUpdateCustom(ctx context.Context, d *schema
 		update = true
 	}
 
-	if d.HasChange("tags") {
+	if d.HasChange("tags_all") {
 
-		oldTagsIf, newTagsIf := d.GetChange("tags")
-		oldTags := oldTagsIf.(map[string]interface{})
-		newTags := newTagsIf.(map[string]interface{})
+		oldTags, newTags := tagsAllChange(d)
 
 		// delete tags
 		for k, _ := range oldTags {
@@ -1228,7 +1407,9 @@ func resourceOpennebulaVirtualMachineUpdateCustom(ctx context.Context, d *schema
 			timeout = d.Timeout(schema.TimeoutCreate)
 		}
 
-		finalStrs := NewVMLCMState(vm.Running).ToStrings()
+		finalStrs := NewVMLCMState(vm.Running).
+			Append(vmParkedStates).
+			ToStrings()
 		stateConf := NewVMUpdateStateConf(timeout,
 			[]string{},
 			finalStrs,
@@ -1513,12 +1694,14 @@ func resourceOpennebulaVirtualMachineUpdateCustom(ctx context.Context, d *schema
 			timeout = d.Timeout(schema.TimeoutUpdate)
 		}
 
-		// wait for the VM to be RUNNING to avoid action failures
+		// wait for the VM to be RUNNING or parked to avoid action failures
 		// RUNNING state is added to transient one in case of slow cloud
 		transientStrs := NewVMLCMState(vm.Running).
 			Append(vmDiskTransientStates).
 			Append(vmNICTransientStates).ToStrings()
-		finalStrs := NewVMLCMState(vm.Running).ToStrings()
+		finalStrs := NewVMLCMState(vm.Running).
+			Append(vmParkedStates).
+			ToStrings()
 		stateConf := NewVMUpdateStateConf(timeout,
 			transientStrs,
 			finalStrs,
@@ -1528,7 +1711,7 @@ func resourceOpennebulaVirtualMachineUpdateCustom(ctx context.Context, d *schema
 		if err != nil {
 			diags = append(diags, diag.Diagnostic{
 				Severity: diag.Error,
-				Summary:  "Failed to wait virtual machine to be in RUNNING state",
+				Summary:  fmt.Sprintf("Failed to wait virtual machine to be in %s state", strings.Join(finalStrs, ",")),
 				Detail:   fmt.Sprintf("virtual machine (ID: %s): %s", d.Id(), err),
 			})
 			return diags
@@ -1546,7 +1729,7 @@ func resourceOpennebulaVirtualMachineUpdateCustom(ctx context.Context, d *schema
 			return diags
 		}
 
-		// wait for the VM to be RUNNING after update
+		// wait for the VM to be back in its state after update
 		stateConf = NewVMUpdateStateConf(timeout,
 			[]string{},
 			finalStrs,
@@ -1555,13 +1738,20 @@ func resourceOpennebulaVirtualMachineUpdateCustom(ctx context.Context, d *schema
 		if err != nil {
 			diags = append(diags, diag.Diagnostic{
 				Severity: diag.Error,
-				Summary:  "Failed to wait virtual machine to be in RUNNING state",
+				Summary:  fmt.Sprintf("Failed to wait virtual machine to be in %s state", strings.Join(finalStrs, ",")),
 				Detail:   fmt.Sprintf("virtual machine (ID: %s): %s", d.Id(), err),
 			})
 			return diags
 		}
 	}
 
+	if desiredStateLast {
+		diags = updateDesiredState(ctx, d, vmc)
+		if len(diags) > 0 {
+			return diags
+		}
+	}
+
 	if d.HasChange("lock") && lockOk && lock.(string) != "UNLOCK" {
 
 		var level shared.LockLevel
@@ -1873,6 +2063,158 @@ func updateNIC(ctx context.Context, d *schema.ResourceData, meta interface{}) er
 	return nil
 }
 
+// rescheduleVM migrates a running VM on requirements change, the requirements
+// of a parked VM are evaluated by the scheduler when it's resumed
+func rescheduleVM(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+
+	var diags diag.Diagnostics
+
+	//Get VM
+	vmc, err := getVirtualMachineController(d, meta)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to get the virtual machine controller",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+
+	vmInfos, err := vmc.Info(false)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to retrieve informations",
+			Detail:   fmt.Sprintf("virtual machine (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
+	vmState, vmLCMState, _ := vmInfos.State()
+	if vmState != vm.Active || vmLCMState != vm.Running {
+		log.Printf("[INFO] VM %s is not running, skip rescheduling\n", d.Id())
+		return nil
+	}
+
+	timeout := time.Duration(d.Get("timeout").(int)) * time.Minute
+	if timeout == defaultVMTimeout {
+		timeout = d.Timeout(schema.TimeoutUpdate)
+	}
+
+	err = vmReschedule(ctx, vmc, timeout)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to reschedule",
+			Detail:   fmt.Sprintf("virtual machine (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
+	log.Printf("[INFO] Successfully rescheduled VM %s\n", d.Id())
+
+	return nil
+}
+
+// updateDesiredState brings the VM to the desired power state
+func updateDesiredState(ctx context.Context, d *schema.ResourceData, vmc *goca.VMController) diag.Diagnostics {
+
+	var diags diag.Diagnostics
+
+	timeout := time.Duration(d.Get("timeout").(int)) * time.Minute
+	if timeout == defaultVMTimeout {
+		timeout = d.Timeout(schema.TimeoutUpdate)
+	}
+
+	desiredState := d.Get("desired_state").(string)
+
+	err := vmSetDesiredState(ctx, vmc, timeout, desiredState, d.Get("hard_shutdown").(bool))
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to change state",
+			Detail:   fmt.Sprintf("virtual machine (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
+	log.Printf("[INFO] Successfully changed state of VM %s to %s\n", d.Id(), desiredState)
+
+	return nil
+}
+
+// updateSchedActions compares the scheduled actions by position in the list
+// and updates, adds or deletes them on the running VM
+func updateSchedActions(d *schema.ResourceData, meta interface{}) error {
+
+	//Get VM
+	vmc, err := getVirtualMachineController(d, meta)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[INFO] Update scheduled actions")
+
+	old, new := d.GetChange("sched_action")
+	appliedActionsCfg := old.([]interface{})
+	newActionsCfg := new.([]interface{})
+
+	for i, actionIf := range newActionsCfg {
+
+		actionConfig := actionIf.(map[string]interface{})
+
+		tpl := vm.NewTemplate()
+		action := makeSchedActionVector(tpl, actionConfig)
+
+		// new action
+		if i >= len(appliedActionsCfg) {
+			err := vmc.AddSchedAction(action)
+			if err != nil {
+				return fmt.Errorf("vm sched action add: %s", err)
+			}
+			continue
+		}
+
+		appliedConfig := appliedActionsCfg[i].(map[string]interface{})
+		if schedActionEqual(appliedConfig, actionConfig) {
+			continue
+		}
+
+		action.Add(vmk.ActionID, appliedConfig["id"].(int))
+
+		err := vmc.UpdateSchedAction(action)
+		if err != nil {
+			return fmt.Errorf("vm sched action update: %s", err)
+		}
+	}
+
+	// delete remaining applied actions
+	for i := len(newActionsCfg); i < len(appliedActionsCfg); i++ {
+
+		appliedConfig := appliedActionsCfg[i].(map[string]interface{})
+
+		err := vmc.DeleteSchedAction(appliedConfig["id"].(int))
+		if err != nil {
+			return fmt.Errorf("vm sched action delete: %s", err)
+		}
+	}
+
+	return nil
+}
+
+// schedActionEqual compares two scheduled action configurations, ignoring the ID
+func schedActionEqual(appliedCfg, newCfg map[string]interface{}) bool {
+	for k, v := range newCfg {
+		if k == "id" {
+			continue
+		}
+		if appliedCfg[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
 // updateVMVec update a vector of an existing VM template
 func updateVMTemplateVec(tpl *vm.Template, vecName string, appliedCfg, newCfg map[string]interface{}) error {
 
@@ -0,0 +1,340 @@
+package opennebula
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/OpenNebula/one/src/oca/go/src/goca/schemas/shared"
+	vmk "github.com/OpenNebula/one/src/oca/go/src/goca/schemas/vm/keys"
+)
+
+func resourceOpennebulaVirtualMachineDiskSnapshot() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceOpennebulaVirtualMachineDiskSnapshotCreate,
+		ReadContext:   resourceOpennebulaVirtualMachineDiskSnapshotRead,
+		UpdateContext: resourceOpennebulaVirtualMachineDiskSnapshotUpdate,
+		DeleteContext: resourceOpennebulaVirtualMachineDiskSnapshotDelete,
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(defaultVMSnapshotTimeout),
+			Update: schema.DefaultTimeout(defaultVMSnapshotTimeout),
+			Delete: schema.DefaultTimeout(defaultVMSnapshotTimeout),
+		},
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceOpennebulaVirtualMachineDiskSnapshotImport,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"virtual_machine_id": {
+				Type:        schema.TypeInt,
+				Required:    true,
+				ForceNew:    true,
+				Description: "ID of the virtual machine",
+			},
+			"disk_id": {
+				Type:        schema.TypeInt,
+				Required:    true,
+				ForceNew:    true,
+				Description: "ID of the disk of the virtual machine",
+			},
+			"name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Name of the disk snapshot",
+			},
+			"revert": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Any change of this value reverts the disk to the snapshot",
+			},
+			"date": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Creation date of the disk snapshot",
+			},
+			"size": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Size of the disk snapshot in MB",
+			},
+			"parent": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "ID of the parent disk snapshot, -1 if none",
+			},
+			"active": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Flag which indicates if the disk is currently based on this snapshot",
+			},
+		},
+	}
+}
+
+func resourceOpennebulaVirtualMachineDiskSnapshotCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	config := meta.(*Configuration)
+	controller := config.Controller
+
+	vmID := d.Get("virtual_machine_id").(int)
+	diskID := d.Get("disk_id").(int)
+
+	// avoid creation of multiple snapshots at the same time
+	snapshotKey := &SubResourceKey{
+		Type:    "virtual_machine",
+		ID:      vmID,
+		SubType: "snapshot",
+	}
+	config.mutex.Lock(snapshotKey)
+	defer config.mutex.Unlock(snapshotKey)
+
+	snapshotID, err := vmDiskSnapshotCreate(ctx, controller, vmID, diskID, d.Timeout(schema.TimeoutCreate), d.Get("name").(string))
+	if snapshotID >= 0 {
+		// keep the snapshot in the state to have it tainted
+		d.SetId(fmt.Sprintf("%d", snapshotID))
+	}
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to create disk snapshot",
+			Detail:   fmt.Sprintf("virtual machine (ID: %d) disk %d: %s", vmID, diskID, err),
+		})
+		return diags
+	}
+
+	log.Printf("[INFO] Successfully created virtual machine disk snapshot\n")
+
+	return resourceOpennebulaVirtualMachineDiskSnapshotRead(ctx, d, meta)
+}
+
+func resourceOpennebulaVirtualMachineDiskSnapshotRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	config := meta.(*Configuration)
+	controller := config.Controller
+
+	vmID := d.Get("virtual_machine_id").(int)
+	diskID := d.Get("disk_id").(int)
+
+	snapshotID, err := strconv.Atoi(d.Id())
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to parse disk snapshot ID",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+
+	snapshots, err := vmGetDiskSnapshots(controller, vmID, diskID)
+	if err != nil {
+		if NoExists(err) {
+			log.Printf("[WARN] Removing virtual machine disk snapshot %s from state because the virtual machine no longer exists", d.Id())
+			d.SetId("")
+			return nil
+		}
+
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to retrieve informations",
+			Detail:   fmt.Sprintf("virtual machine disk snapshot (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
+	var snapshot *shared.Snapshot
+	for i, s := range snapshots {
+		if s.ID == snapshotID {
+			snapshot = &snapshots[i]
+			break
+		}
+	}
+
+	if snapshot == nil {
+		log.Printf("[WARN] Removing virtual machine disk snapshot %s from state because it no longer exists", d.Id())
+		d.SetId("")
+		return nil
+	}
+
+	d.Set("name", snapshot.Name)
+	d.Set("date", snapshot.Date)
+	d.Set("size", snapshot.Size)
+	d.Set("parent", snapshot.Parent)
+	d.Set("active", strings.ToUpper(snapshot.Active) == "YES")
+
+	return nil
+}
+
+func resourceOpennebulaVirtualMachineDiskSnapshotUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	config := meta.(*Configuration)
+	controller := config.Controller
+
+	vmID := d.Get("virtual_machine_id").(int)
+	diskID := d.Get("disk_id").(int)
+	snapshotID, _ := strconv.Atoi(d.Id())
+
+	snapshotKey := &SubResourceKey{
+		Type:    "virtual_machine",
+		ID:      vmID,
+		SubType: "snapshot",
+	}
+	config.mutex.Lock(snapshotKey)
+	defer config.mutex.Unlock(snapshotKey)
+
+	vmc := controller.VM(vmID)
+
+	if d.HasChange("name") {
+		err := vmc.Disk(diskID).SnapshotRename(snapshotID, d.Get("name").(string))
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to rename disk snapshot",
+				Detail:   fmt.Sprintf("virtual machine disk snapshot (ID: %s): %s", d.Id(), err),
+			})
+			return diags
+		}
+		log.Printf("[INFO] Successfully renamed virtual machine disk snapshot %s\n", d.Id())
+	}
+
+	if d.HasChange("revert") && len(d.Get("revert").(string)) > 0 {
+
+		log.Printf("[DEBUG] Revert disk %d to snapshot %d", diskID, snapshotID)
+
+		err := vmc.Disk(diskID).SnapshotRevert(snapshotID)
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to revert disk snapshot",
+				Detail:   fmt.Sprintf("virtual machine disk snapshot (ID: %s): %s", d.Id(), err),
+			})
+			return diags
+		}
+
+		err = vmDiskSnapshotWait(ctx, vmc, d.Timeout(schema.TimeoutUpdate))
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to wait disk snapshot revert",
+				Detail:   fmt.Sprintf("virtual machine disk snapshot (ID: %s): %s", d.Id(), err),
+			})
+			return diags
+		}
+		log.Printf("[INFO] Successfully reverted disk to snapshot %s\n", d.Id())
+	}
+
+	return resourceOpennebulaVirtualMachineDiskSnapshotRead(ctx, d, meta)
+}
+
+func resourceOpennebulaVirtualMachineDiskSnapshotDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	config := meta.(*Configuration)
+	controller := config.Controller
+
+	vmID := d.Get("virtual_machine_id").(int)
+	diskID := d.Get("disk_id").(int)
+	snapshotID, _ := strconv.Atoi(d.Id())
+
+	snapshotKey := &SubResourceKey{
+		Type:    "virtual_machine",
+		ID:      vmID,
+		SubType: "snapshot",
+	}
+	config.mutex.Lock(snapshotKey)
+	defer config.mutex.Unlock(snapshotKey)
+
+	vmc := controller.VM(vmID)
+
+	log.Printf("[DEBUG] Delete snapshot %d of disk %d", snapshotID, diskID)
+
+	err := vmc.Disk(diskID).SnapshotDelete(snapshotID)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to delete disk snapshot",
+			Detail:   fmt.Sprintf("virtual machine disk snapshot (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
+	err = vmDiskSnapshotWait(ctx, vmc, d.Timeout(schema.TimeoutDelete))
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to wait disk snapshot deletion",
+			Detail:   fmt.Sprintf("virtual machine disk snapshot (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
+	// Check that the snapshot is deleted
+	snapshots, err := vmGetDiskSnapshots(controller, vmID, diskID)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to retrieve informations",
+			Detail:   fmt.Sprintf("virtual machine disk snapshot (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
+	for _, s := range snapshots {
+		if s.ID == snapshotID {
+			// If snapshot still present, retrieve error message
+			vmInfos, _ := vmc.Info(false)
+			var vmerr string
+			if vmInfos != nil {
+				vmerr, _ = vmInfos.UserTemplate.Get(vmk.Error)
+			}
+
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to delete disk snapshot",
+				Detail:   fmt.Sprintf("virtual machine disk snapshot (ID: %s): %s", d.Id(), vmerr),
+			})
+			return diags
+		}
+	}
+
+	log.Printf("[INFO] Successfully deleted virtual machine disk snapshot %s\n", d.Id())
+
+	return nil
+}
+
+// resourceOpennebulaVirtualMachineDiskSnapshotImport parses an ID in the format <virtual_machine_id>:<disk_id>:<snapshot_id>
+func resourceOpennebulaVirtualMachineDiskSnapshotImport(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+
+	parts := strings.Split(d.Id(), ":")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("Invalid ID %q, expected format: <virtual_machine_id>:<disk_id>:<snapshot_id>", d.Id())
+	}
+
+	vmID, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("Invalid virtual machine ID %q: %s", parts[0], err)
+	}
+
+	diskID, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("Invalid disk ID %q: %s", parts[1], err)
+	}
+
+	_, err = strconv.Atoi(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("Invalid disk snapshot ID %q: %s", parts[2], err)
+	}
+
+	d.Set("virtual_machine_id", vmID)
+	d.Set("disk_id", diskID)
+	d.SetId(parts[2])
+
+	return []*schema.ResourceData{d}, nil
+}
@@ -0,0 +1,242 @@
+package opennebula
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+var defaultVMSnapshotTimeout = time.Duration(10) * time.Minute
+
+func resourceOpennebulaVirtualMachineSnapshot() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceOpennebulaVirtualMachineSnapshotCreate,
+		ReadContext:   resourceOpennebulaVirtualMachineSnapshotRead,
+		UpdateContext: resourceOpennebulaVirtualMachineSnapshotUpdate,
+		DeleteContext: resourceOpennebulaVirtualMachineSnapshotDelete,
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(defaultVMSnapshotTimeout),
+			Update: schema.DefaultTimeout(defaultVMSnapshotTimeout),
+			Delete: schema.DefaultTimeout(defaultVMSnapshotTimeout),
+		},
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceOpennebulaVirtualMachineSnapshotImport,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"virtual_machine_id": {
+				Type:        schema.TypeInt,
+				Required:    true,
+				ForceNew:    true,
+				Description: "ID of the virtual machine",
+			},
+			"name": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				ForceNew:    true,
+				Description: "Name of the snapshot",
+			},
+			"revert": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Any change of this value reverts the virtual machine to the snapshot",
+			},
+			"time": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Creation time of the snapshot",
+			},
+			"hypervisor_id": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "ID of the snapshot in the hypervisor",
+			},
+		},
+	}
+}
+
+func resourceOpennebulaVirtualMachineSnapshotCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	config := meta.(*Configuration)
+	controller := config.Controller
+
+	vmID := d.Get("virtual_machine_id").(int)
+
+	// avoid creation of multiple snapshots at the same time
+	snapshotKey := &SubResourceKey{
+		Type:    "virtual_machine",
+		ID:      vmID,
+		SubType: "snapshot",
+	}
+	config.mutex.Lock(snapshotKey)
+	defer config.mutex.Unlock(snapshotKey)
+
+	snapshotID, err := vmSnapshotCreate(ctx, controller.VM(vmID), d.Timeout(schema.TimeoutCreate), d.Get("name").(string))
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to create snapshot",
+			Detail:   fmt.Sprintf("virtual machine (ID: %d): %s", vmID, err),
+		})
+		return diags
+	}
+
+	d.SetId(fmt.Sprintf("%d", snapshotID))
+
+	log.Printf("[INFO] Successfully created virtual machine snapshot\n")
+
+	return resourceOpennebulaVirtualMachineSnapshotRead(ctx, d, meta)
+}
+
+func resourceOpennebulaVirtualMachineSnapshotRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	config := meta.(*Configuration)
+	controller := config.Controller
+
+	vmID := d.Get("virtual_machine_id").(int)
+
+	snapshotID, err := strconv.Atoi(d.Id())
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to parse snapshot ID",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+
+	vmInfos, err := controller.VM(vmID).Info(false)
+	if err != nil {
+		if NoExists(err) {
+			log.Printf("[WARN] Removing virtual machine snapshot %s from state because the virtual machine no longer exists", d.Id())
+			d.SetId("")
+			return nil
+		}
+
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to retrieve informations",
+			Detail:   fmt.Sprintf("virtual machine snapshot (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
+	snapshot := vmGetSnapshot(vmInfos, snapshotID)
+	if snapshot == nil {
+		log.Printf("[WARN] Removing virtual machine snapshot %s from state because it no longer exists", d.Id())
+		d.SetId("")
+		return nil
+	}
+
+	name, _ := snapshot.GetStr("NAME")
+	snapTime, _ := snapshot.GetInt("TIME")
+	hypervisorID, _ := snapshot.GetStr("HYPERVISOR_ID")
+
+	d.Set("name", name)
+	d.Set("time", snapTime)
+	d.Set("hypervisor_id", hypervisorID)
+
+	return nil
+}
+
+func resourceOpennebulaVirtualMachineSnapshotUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	config := meta.(*Configuration)
+	controller := config.Controller
+
+	vmID := d.Get("virtual_machine_id").(int)
+
+	if d.HasChange("revert") && len(d.Get("revert").(string)) > 0 {
+
+		snapshotKey := &SubResourceKey{
+			Type:    "virtual_machine",
+			ID:      vmID,
+			SubType: "snapshot",
+		}
+		config.mutex.Lock(snapshotKey)
+		defer config.mutex.Unlock(snapshotKey)
+
+		snapshotID, _ := strconv.Atoi(d.Id())
+
+		err := vmSnapshotRevert(ctx, controller.VM(vmID), d.Timeout(schema.TimeoutUpdate), snapshotID)
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to revert snapshot",
+				Detail:   fmt.Sprintf("virtual machine snapshot (ID: %s): %s", d.Id(), err),
+			})
+			return diags
+		}
+
+		log.Printf("[INFO] Successfully reverted virtual machine to snapshot %s\n", d.Id())
+	}
+
+	return resourceOpennebulaVirtualMachineSnapshotRead(ctx, d, meta)
+}
+
+func resourceOpennebulaVirtualMachineSnapshotDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	config := meta.(*Configuration)
+	controller := config.Controller
+
+	vmID := d.Get("virtual_machine_id").(int)
+
+	snapshotKey := &SubResourceKey{
+		Type:    "virtual_machine",
+		ID:      vmID,
+		SubType: "snapshot",
+	}
+	config.mutex.Lock(snapshotKey)
+	defer config.mutex.Unlock(snapshotKey)
+
+	snapshotID, _ := strconv.Atoi(d.Id())
+
+	err := vmSnapshotDelete(ctx, controller.VM(vmID), d.Timeout(schema.TimeoutDelete), snapshotID)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to delete snapshot",
+			Detail:   fmt.Sprintf("virtual machine snapshot (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
+	log.Printf("[INFO] Successfully deleted virtual machine snapshot %s\n", d.Id())
+
+	return nil
+}
+
+// resourceOpennebulaVirtualMachineSnapshotImport parses an ID in the format <virtual_machine_id>:<snapshot_id>
+func resourceOpennebulaVirtualMachineSnapshotImport(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+
+	parts := strings.Split(d.Id(), ":")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("Invalid ID %q, expected format: <virtual_machine_id>:<snapshot_id>", d.Id())
+	}
+
+	vmID, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("Invalid virtual machine ID %q: %s", parts[0], err)
+	}
+
+	_, err = strconv.Atoi(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("Invalid snapshot ID %q: %s", parts[1], err)
+	}
+
+	d.Set("virtual_machine_id", vmID)
+	d.SetId(parts[1])
+
+	return []*schema.ResourceData{d}, nil
+}
@@ -0,0 +1,96 @@
+package opennebula
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccVirtualMachineSnapshot(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVirtualMachineDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccVirtualMachineSnapshotConfigBasic,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("opennebula_virtual_machine_snapshot.test", "name", "test-snapshot"),
+					resource.TestCheckResourceAttrSet("opennebula_virtual_machine_snapshot.test", "time"),
+					resource.TestCheckResourceAttrPair("opennebula_virtual_machine_snapshot.test", "virtual_machine_id", "opennebula_virtual_machine.test", "id"),
+					resource.TestCheckResourceAttr("opennebula_virtual_machine_disk_snapshot.test", "name", "test-disk-snapshot"),
+					resource.TestCheckResourceAttr("opennebula_virtual_machine_disk_snapshot.test", "disk_id", "0"),
+					resource.TestCheckResourceAttrSet("opennebula_virtual_machine_disk_snapshot.test", "date"),
+					resource.TestCheckResourceAttrPair("opennebula_virtual_machine_disk_snapshot.test", "virtual_machine_id", "opennebula_virtual_machine.test", "id"),
+				),
+			},
+			{
+				Config: testAccVirtualMachineSnapshotConfigUpdate,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("opennebula_virtual_machine_snapshot.test", "name", "test-snapshot"),
+					resource.TestCheckResourceAttr("opennebula_virtual_machine_snapshot.test", "revert", "1"),
+					resource.TestCheckResourceAttr("opennebula_virtual_machine_disk_snapshot.test", "name", "test-disk-snapshot-renamed"),
+					resource.TestCheckResourceAttr("opennebula_virtual_machine_disk_snapshot.test", "revert", "1"),
+				),
+			},
+		},
+	})
+}
+
+var testAccVirtualMachineSnapshotVM = `
+resource "opennebula_image" "image" {
+	name         = "image-snapshot"
+	type         = "DATABLOCK"
+	size         = "16"
+	datastore_id = 1
+	persistent   = false
+	permissions  = "660"
+}
+
+resource "opennebula_virtual_machine" "test" {
+	name        = "test-virtual_machine-snapshot"
+	group       = "oneadmin"
+	permissions = "642"
+	memory      = 128
+	cpu         = 0.1
+
+	disk {
+		image_id = opennebula_image.image.id
+		target   = "vda"
+	}
+
+	timeout = 5
+}
+`
+
+var testAccVirtualMachineSnapshotConfigBasic = testAccVirtualMachineSnapshotVM + `
+resource "opennebula_virtual_machine_snapshot" "test" {
+	virtual_machine_id = opennebula_virtual_machine.test.id
+	name               = "test-snapshot"
+}
+
+resource "opennebula_virtual_machine_disk_snapshot" "test" {
+	virtual_machine_id = opennebula_virtual_machine.test.id
+	disk_id            = 0
+	name               = "test-disk-snapshot"
+
+	depends_on = [opennebula_virtual_machine_snapshot.test]
+}
+`
+
+var testAccVirtualMachineSnapshotConfigUpdate = testAccVirtualMachineSnapshotVM + `
+resource "opennebula_virtual_machine_snapshot" "test" {
+	virtual_machine_id = opennebula_virtual_machine.test.id
+	name               = "test-snapshot"
+	revert             = "1"
+}
+
+resource "opennebula_virtual_machine_disk_snapshot" "test" {
+	virtual_machine_id = opennebula_virtual_machine.test.id
+	disk_id            = 0
+	name               = "test-disk-snapshot-renamed"
+	revert             = "1"
+
+	depends_on = [opennebula_virtual_machine_snapshot.test]
+}
+`
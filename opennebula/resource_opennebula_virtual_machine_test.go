@@ -46,6 +46,14 @@ func TestAccVirtualMachine(t *testing.T) {
 					resource.TestCheckResourceAttr("opennebula_virtual_machine.test", "sched_requirements", "FREE_CPU > 50"),
 					resource.TestCheckResourceAttr("opennebula_virtual_machine.test", "description", "VM created for provider acceptance tests"),
 					resource.TestCheckResourceAttr("opennebula_virtual_machine.test", "timeout", "5"),
+					resource.TestCheckResourceAttr("opennebula_virtual_machine.test", "host_name", "dummy"),
+					resource.TestCheckResourceAttrSet("opennebula_virtual_machine.test", "host_id"),
+					resource.TestCheckResourceAttrSet("opennebula_virtual_machine.test", "cluster_id"),
+					resource.TestCheckResourceAttrSet("opennebula_virtual_machine.test", "system_datastore_id"),
+					resource.TestCheckResourceAttrSet("opennebula_virtual_machine.test", "deploy_id"),
+					resource.TestCheckResourceAttr("opennebula_virtual_machine.test", "history.#", "1"),
+					resource.TestCheckResourceAttr("opennebula_virtual_machine.test", "history.0.host_name", "dummy"),
+					resource.TestCheckResourceAttr("opennebula_virtual_machine.test", "history.0.end_time", "0"),
 					resource.TestCheckResourceAttrSet("opennebula_virtual_machine.test", "uid"),
 					resource.TestCheckResourceAttrSet("opennebula_virtual_machine.test", "gid"),
 					resource.TestCheckResourceAttrSet("opennebula_virtual_machine.test", "uname"),
@@ -729,6 +737,111 @@ func TestAccVirtualMachineCPUModel(t *testing.T) {
 	})
 }
 
+func TestAccVirtualMachineSchedActions(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVirtualMachineDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccVirtualMachineSchedAction,
+				Check: resource.ComposeTestCheckFunc(
+					testAccSetDSdummy(),
+					resource.TestCheckResourceAttr("opennebula_virtual_machine.test", "sched_action.#", "1"),
+					resource.TestCheckResourceAttr("opennebula_virtual_machine.test", "sched_action.0.action", "poweroff"),
+					resource.TestCheckResourceAttr("opennebula_virtual_machine.test", "sched_action.0.time", "1924988400"),
+					resource.TestCheckResourceAttr("opennebula_virtual_machine.test", "sched_action.0.repeat", "WEEKLY"),
+					resource.TestCheckResourceAttr("opennebula_virtual_machine.test", "sched_action.0.days", "1,5"),
+					resource.TestCheckResourceAttr("opennebula_virtual_machine.test", "sched_action.0.end_type", "NEVER"),
+					resource.TestCheckResourceAttrSet("opennebula_virtual_machine.test", "sched_action.0.id"),
+				),
+			},
+			{
+				Config: testAccVirtualMachineSchedActionUpdate,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("opennebula_virtual_machine.test", "sched_action.#", "2"),
+					resource.TestCheckResourceAttr("opennebula_virtual_machine.test", "sched_action.0.action", "poweroff"),
+					resource.TestCheckResourceAttr("opennebula_virtual_machine.test", "sched_action.0.days", "2,6"),
+					resource.TestCheckResourceAttr("opennebula_virtual_machine.test", "sched_action.1.action", "snapshot-create"),
+					resource.TestCheckResourceAttr("opennebula_virtual_machine.test", "sched_action.1.time", "1924988400"),
+					resource.TestCheckResourceAttr("opennebula_virtual_machine.test", "sched_action.1.args", "nightly"),
+				),
+			},
+			{
+				Config: testAccVirtualMachineSchedActionDelete,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("opennebula_virtual_machine.test", "sched_action.#", "1"),
+					resource.TestCheckResourceAttr("opennebula_virtual_machine.test", "sched_action.0.action", "poweroff"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccVirtualMachineDesiredState(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVirtualMachineDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccVirtualMachineDesiredStatePoweroff,
+				Check: resource.ComposeTestCheckFunc(
+					testAccSetDSdummy(),
+					resource.TestCheckResourceAttr("opennebula_virtual_machine.test", "desired_state", "POWEROFF"),
+					resource.TestCheckResourceAttr("opennebula_virtual_machine.test", "state", "8"),
+				),
+			},
+			{
+				Config: testAccVirtualMachineDesiredStateUndeployed,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("opennebula_virtual_machine.test", "desired_state", "UNDEPLOYED"),
+					resource.TestCheckResourceAttr("opennebula_virtual_machine.test", "state", "9"),
+				),
+			},
+			{
+				Config: testAccVirtualMachineDesiredStateRunning,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("opennebula_virtual_machine.test", "desired_state", "RUNNING"),
+					resource.TestCheckResourceAttr("opennebula_virtual_machine.test", "state", "3"),
+					resource.TestCheckResourceAttr("opennebula_virtual_machine.test", "lcmstate", "3"),
+				),
+			},
+			{
+				Config: testAccVirtualMachineDesiredStateSuspended,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("opennebula_virtual_machine.test", "desired_state", "SUSPENDED"),
+					resource.TestCheckResourceAttr("opennebula_virtual_machine.test", "state", "5"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccVirtualMachineReschedule(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVirtualMachineDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccVirtualMachineReschedule,
+				Check: resource.ComposeTestCheckFunc(
+					testAccSetDSdummy(),
+					resource.TestCheckResourceAttr("opennebula_virtual_machine.test", "reschedule_on_requirements_change", "true"),
+					resource.TestCheckResourceAttrPair("opennebula_virtual_machine.test", "host_id", "opennebula_host.host1", "id"),
+				),
+			},
+			{
+				Config: testAccVirtualMachineRescheduleUpdate,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair("opennebula_virtual_machine.test", "host_id", "opennebula_host.host2", "id"),
+				),
+			},
+		},
+	})
+}
+
 var testAccVirtualMachineTemplateConfigBasic = `
 resource "opennebula_virtual_machine" "test" {
   name        = "test-virtual_machine"
@@ -990,6 +1103,326 @@ resource "opennebula_virtual_machine" "test" {
 }
 `
 
+var testAccVirtualMachineSchedAction = `
+resource "opennebula_virtual_machine" "test" {
+  name        = "test-virtual_machine"
+  group       = "oneadmin"
+  permissions = "642"
+  memory = 128
+  cpu = 0.1
+
+  context = {
+    NETWORK  = "YES"
+    SET_HOSTNAME = "$NAME"
+  }
+
+  graphics {
+    type   = "VNC"
+    listen = "0.0.0.0"
+    keymap = "en-us"
+  }
+
+  os {
+    arch = "x86_64"
+    boot = ""
+  }
+
+  sched_action {
+    action   = "poweroff"
+    time     = "1924988400"
+    repeat   = "WEEKLY"
+    days     = "1,5"
+    end_type = "NEVER"
+  }
+
+  timeout = 5
+}
+`
+
+var testAccVirtualMachineSchedActionUpdate = `
+resource "opennebula_virtual_machine" "test" {
+  name        = "test-virtual_machine"
+  group       = "oneadmin"
+  permissions = "642"
+  memory = 128
+  cpu = 0.1
+
+  context = {
+    NETWORK  = "YES"
+    SET_HOSTNAME = "$NAME"
+  }
+
+  graphics {
+    type   = "VNC"
+    listen = "0.0.0.0"
+    keymap = "en-us"
+  }
+
+  os {
+    arch = "x86_64"
+    boot = ""
+  }
+
+  sched_action {
+    action   = "poweroff"
+    time     = "1924988400"
+    repeat   = "WEEKLY"
+    days     = "2,6"
+    end_type = "NEVER"
+  }
+
+  sched_action {
+    action = "snapshot-create"
+    time   = "1924988400"
+    args   = "nightly"
+  }
+
+  timeout = 5
+}
+`
+
+var testAccVirtualMachineSchedActionDelete = `
+resource "opennebula_virtual_machine" "test" {
+  name        = "test-virtual_machine"
+  group       = "oneadmin"
+  permissions = "642"
+  memory = 128
+  cpu = 0.1
+
+  context = {
+    NETWORK  = "YES"
+    SET_HOSTNAME = "$NAME"
+  }
+
+  graphics {
+    type   = "VNC"
+    listen = "0.0.0.0"
+    keymap = "en-us"
+  }
+
+  os {
+    arch = "x86_64"
+    boot = ""
+  }
+
+  sched_action {
+    action   = "poweroff"
+    time     = "1924988400"
+    repeat   = "WEEKLY"
+    days     = "2,6"
+    end_type = "NEVER"
+  }
+
+  timeout = 5
+}
+`
+
+var testAccVirtualMachineDesiredStatePoweroff = `
+resource "opennebula_virtual_machine" "test" {
+  name        = "test-virtual_machine"
+  group       = "oneadmin"
+  permissions = "642"
+  memory = 128
+  cpu = 0.1
+
+  context = {
+    NETWORK  = "YES"
+    SET_HOSTNAME = "$NAME"
+  }
+
+  graphics {
+    type   = "VNC"
+    listen = "0.0.0.0"
+    keymap = "en-us"
+  }
+
+  os {
+    arch = "x86_64"
+    boot = ""
+  }
+
+  desired_state = "POWEROFF"
+
+  timeout = 5
+}
+`
+
+var testAccVirtualMachineDesiredStateUndeployed = `
+resource "opennebula_virtual_machine" "test" {
+  name        = "test-virtual_machine"
+  group       = "oneadmin"
+  permissions = "642"
+  memory = 128
+  cpu = 0.1
+
+  context = {
+    NETWORK  = "YES"
+    SET_HOSTNAME = "$NAME"
+  }
+
+  graphics {
+    type   = "VNC"
+    listen = "0.0.0.0"
+    keymap = "en-us"
+  }
+
+  os {
+    arch = "x86_64"
+    boot = ""
+  }
+
+  desired_state = "UNDEPLOYED"
+
+  timeout = 5
+}
+`
+
+var testAccVirtualMachineDesiredStateRunning = `
+resource "opennebula_virtual_machine" "test" {
+  name        = "test-virtual_machine"
+  group       = "oneadmin"
+  permissions = "642"
+  memory = 128
+  cpu = 0.1
+
+  context = {
+    NETWORK  = "YES"
+    SET_HOSTNAME = "$NAME"
+  }
+
+  graphics {
+    type   = "VNC"
+    listen = "0.0.0.0"
+    keymap = "en-us"
+  }
+
+  os {
+    arch = "x86_64"
+    boot = ""
+  }
+
+  desired_state = "RUNNING"
+
+  timeout = 5
+}
+`
+
+var testAccVirtualMachineDesiredStateSuspended = `
+resource "opennebula_virtual_machine" "test" {
+  name        = "test-virtual_machine"
+  group       = "oneadmin"
+  permissions = "642"
+  memory = 128
+  cpu = 0.1
+
+  context = {
+    NETWORK  = "YES"
+    SET_HOSTNAME = "$NAME"
+  }
+
+  graphics {
+    type   = "VNC"
+    listen = "0.0.0.0"
+    keymap = "en-us"
+  }
+
+  os {
+    arch = "x86_64"
+    boot = ""
+  }
+
+  desired_state = "SUSPENDED"
+
+  timeout = 5
+}
+`
+
+var testAccVirtualMachineReschedule = `
+resource "opennebula_host" "host1" {
+  name   = "test-resched-host1"
+  im_mad = "dummy"
+  vm_mad = "dummy"
+}
+
+resource "opennebula_host" "host2" {
+  name   = "test-resched-host2"
+  im_mad = "dummy"
+  vm_mad = "dummy"
+}
+
+resource "opennebula_virtual_machine" "test" {
+  name        = "test-virtual_machine"
+  group       = "oneadmin"
+  permissions = "642"
+  memory = 128
+  cpu = 0.1
+
+  context = {
+    NETWORK  = "YES"
+    SET_HOSTNAME = "$NAME"
+  }
+
+  graphics {
+    type   = "VNC"
+    listen = "0.0.0.0"
+    keymap = "en-us"
+  }
+
+  os {
+    arch = "x86_64"
+    boot = ""
+  }
+
+  sched_requirements                = "ID=\"${opennebula_host.host1.id}\""
+  reschedule_on_requirements_change = true
+
+  timeout = 5
+}
+`
+
+var testAccVirtualMachineRescheduleUpdate = `
+resource "opennebula_host" "host1" {
+  name   = "test-resched-host1"
+  im_mad = "dummy"
+  vm_mad = "dummy"
+}
+
+resource "opennebula_host" "host2" {
+  name   = "test-resched-host2"
+  im_mad = "dummy"
+  vm_mad = "dummy"
+}
+
+resource "opennebula_virtual_machine" "test" {
+  name        = "test-virtual_machine"
+  group       = "oneadmin"
+  permissions = "642"
+  memory = 128
+  cpu = 0.1
+
+  context = {
+    NETWORK  = "YES"
+    SET_HOSTNAME = "$NAME"
+  }
+
+  graphics {
+    type   = "VNC"
+    listen = "0.0.0.0"
+    keymap = "en-us"
+  }
+
+  os {
+    arch = "x86_64"
+    boot = ""
+  }
+
+  sched_requirements                = "ID=\"${opennebula_host.host2.id}\""
+  reschedule_on_requirements_change = true
+
+  timeout = 5
+}
+`
+
 var testAccVirtualMachinePending = `
 resource "opennebula_virtual_machine" "test" {
   name        = "virtual_machine_pending"
@@ -11,6 +11,7 @@ import (
 
 	"github.com/hashicorp/go-version"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 
@@ -30,6 +31,7 @@ func resourceOpennebulaVirtualNetwork() *schema.Resource {
 		Exists:        resourceOpennebulaVirtualNetworkExists,
 		UpdateContext: resourceOpennebulaVirtualNetworkUpdate,
 		DeleteContext: resourceOpennebulaVirtualNetworkDelete,
+		CustomizeDiff: customdiff.All(vnetARsCustomizeDiff, tagsAllCustomizeDiff),
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(defaultVNetTimeout),
 		},
@@ -109,11 +111,12 @@ func resourceOpennebulaVirtualNetwork() *schema.Resource {
 				ConflictsWith: []string{"reservation_vnet", "reservation_size"},
 			},
 			"type": {
-				Type:          schema.TypeString,
-				Optional:      true,
-				Default:       "bridge",
-				Description:   "Type of the Virtual Network: dummy, bridge, fw, ebtables, 802.1Q, vxlan, ovswitch. Default is 'bridge'",
-				ConflictsWith: []string{"reservation_vnet", "reservation_size"},
+				Type:             schema.TypeString,
+				Optional:         true,
+				Default:          "bridge",
+				Description:      "Type of the Virtual Network: dummy, bridge, fw, ebtables, 802.1Q, vxlan, ovswitch. Default is 'bridge'",
+				DiffSuppressFunc: suppressInstantiatedVnetDiff,
+				ConflictsWith:    []string{"reservation_vnet", "reservation_size"},
 				ValidateFunc: func(v interface{}, k string) (ws []string, errors []error) {
 					validtypes := []string{"dummy", "bridge", "fw", "ebtables", "802.1Q", "vxlan", "ovswitch"}
 					value := v.(string)
@@ -150,11 +153,12 @@ func resourceOpennebulaVirtualNetwork() *schema.Resource {
 				ConflictsWith: []string{"reservation_vnet", "reservation_size", "vlan_id"},
 			},
 			"mtu": {
-				Type:          schema.TypeInt,
-				Optional:      true,
-				Description:   "MTU of the vnet (defaut: 1500)",
-				Default:       1500,
-				ConflictsWith: []string{"reservation_vnet", "reservation_size"},
+				Type:             schema.TypeInt,
+				Optional:         true,
+				Description:      "MTU of the vnet (defaut: 1500)",
+				Default:          1500,
+				DiffSuppressFunc: suppressInstantiatedVnetDiff,
+				ConflictsWith:    []string{"reservation_vnet", "reservation_size"},
 			},
 			"guest_mtu": {
 				Type:          schema.TypeInt,
@@ -233,6 +237,13 @@ func resourceOpennebulaVirtualNetwork() *schema.Resource {
 				Description:   "Reserve this many IPs from reservation_vnet",
 				ConflictsWith: []string{"bridge", "physical_device", "ar", "hold_ips", "hold_size", "ip_hold", "type", "vlan_id", "automatic_vlan_id", "mtu", "clusters", "dns", "gateway", "network_mask"},
 			},
+			"template_id": {
+				Type:          schema.TypeInt,
+				Optional:      true,
+				ForceNew:      true,
+				Description:   "Instantiate the VNET from this virtual network template ID",
+				ConflictsWith: []string{"reservation_vnet", "reservation_size", "bridge", "physical_device", "ar", "hold_ips", "hold_size", "ip_hold", "type", "vlan_id", "automatic_vlan_id", "mtu", "guest_mtu", "clusters"},
+			},
 			"security_groups": {
 				Type:        schema.TypeList,
 				Optional:    true,
@@ -247,8 +258,9 @@ func resourceOpennebulaVirtualNetwork() *schema.Resource {
 				Optional:    true,
 				Description: "Name of the Group that onws the Virtual Network, If empty, it uses caller group",
 			},
-			"tags": tagsSchema(),
-			"lock": lockSchema(),
+			"tags":     tagsSchema(),
+			"tags_all": tagsAllSchema(),
+			"lock":     lockSchema(),
 		},
 	}
 }
@@ -260,25 +272,17 @@ func ARFields() map[string]*schema.Schema {
 			Computed: true,
 		},
 		"ar_type": {
-			Type:        schema.TypeString,
-			Optional:    true,
-			Default:     "IP4",
-			Description: "Type of the Address Range: IP4, IP6. Default is 'IP4'",
-			ValidateFunc: func(v interface{}, k string) (ws []string, errors []error) {
-				validtypes := []string{"IP4", "IP6", "IP6_STATIC", "IP4_6", "IP4_6_STATIC", "ETHER"}
-				value := v.(string)
-
-				if inArray(value, validtypes) < 0 {
-					errors = append(errors, fmt.Errorf("Address Range type %q must be one of: %s", k, strings.Join(validtypes, ",")))
-				}
-
-				return
-			},
+			Type:         schema.TypeString,
+			Optional:     true,
+			Default:      "IP4",
+			Description:  "Type of the Address Range: IP4, IP6, IP6_STATIC, IP4_6, IP4_6_STATIC, ETHER. Default is 'IP4'",
+			ValidateFunc: validateARType,
 		},
 		"ip4": {
-			Type:        schema.TypeString,
-			Optional:    true,
-			Description: "Start IPv4 of the range to be allocated (Required if IP4 or IP4_6).",
+			Type:         schema.TypeString,
+			Optional:     true,
+			Description:  "Start IPv4 of the range to be allocated (Required if IP4 or IP4_6).",
+			ValidateFunc: validateIPv4,
 		},
 		"size": {
 			Type:        schema.TypeInt,
@@ -286,29 +290,34 @@ func ARFields() map[string]*schema.Schema {
 			Description: "Size (in number) of the ip range",
 		},
 		"ip6": {
-			Type:        schema.TypeString,
-			Optional:    true,
-			Description: "Start IPv6 of the range to be allocated (Required if IP6_STATIC or IP4_6_STATIC)",
+			Type:         schema.TypeString,
+			Optional:     true,
+			Description:  "Start IPv6 of the range to be allocated (Required if IP6_STATIC or IP4_6_STATIC)",
+			ValidateFunc: validateIPv6,
 		},
 		"mac": {
-			Type:        schema.TypeString,
-			Optional:    true,
-			Description: "Start MAC of the range to be allocated",
+			Type:         schema.TypeString,
+			Optional:     true,
+			Description:  "Start MAC of the range to be allocated",
+			ValidateFunc: validateMAC,
 		},
 		"global_prefix": {
-			Type:        schema.TypeString,
-			Optional:    true,
-			Description: "Global prefix for IP6 or IP4_6",
+			Type:         schema.TypeString,
+			Optional:     true,
+			Description:  "Global prefix for IP6 or IP4_6",
+			ValidateFunc: validateIPv6Prefix,
 		},
 		"ula_prefix": {
-			Type:        schema.TypeString,
-			Optional:    true,
-			Description: "ULA prefix for IP6 or IP4_6",
+			Type:         schema.TypeString,
+			Optional:     true,
+			Description:  "ULA prefix for IP6 or IP4_6",
+			ValidateFunc: validateIPv6Prefix,
 		},
 		"prefix_length": {
-			Type:        schema.TypeString,
-			Optional:    true,
-			Description: "Prefix lenght Only needed for IP6_STATIC or IP4_6_STATIC",
+			Type:         schema.TypeString,
+			Optional:     true,
+			Description:  "Prefix lenght Only needed for IP6_STATIC or IP4_6_STATIC",
+			ValidateFunc: validatePrefixLength,
 		},
 		"computed_ip6": {
 			Type:        schema.TypeString,
@@ -330,6 +339,16 @@ func ARFields() map[string]*schema.Schema {
 			Computed:    true,
 			Description: "ULA prefix for IP6 or IP4_6",
 		},
+		"computed_ip6_global": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "First global IPv6 of the range for IP6 or IP4_6",
+		},
+		"computed_ip6_ula": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "First ULA IPv6 of the range for IP6 or IP4_6",
+		},
 	}
 }
 
@@ -398,6 +417,8 @@ func resourceOpennebulaVirtualNetworkCreate(ctx context.Context, d *schema.Resou
 	var vnc *goca.VirtualNetworkController
 	var diags diag.Diagnostics
 
+	setTagsAll(d, meta)
+
 	// VNET reservation
 	if rvnet, ok := d.GetOk("reservation_vnet"); ok {
 		reservation_vnet := rvnet.(int)
@@ -466,6 +487,60 @@ func resourceOpennebulaVirtualNetworkCreate(ctx context.Context, d *schema.Resou
 
 		log.Printf("[DEBUG] New VNET reservation ID: %d", vnet.ID)
 
+	} else if tplID, ok := d.GetOkExists("template_id"); ok { // VNET template instantiation
+		templateID := tplID.(int)
+
+		extraTpl := vn.NewTemplate()
+
+		if dns, ok := d.GetOk("dns"); ok {
+			extraTpl.Add(vnk.DNS, dns.(string))
+		}
+		if gw, ok := d.GetOk("gateway"); ok {
+			extraTpl.Add(vnk.Gateway, gw.(string))
+		}
+		if netMask, ok := d.GetOk("network_mask"); ok {
+			extraTpl.Add(vnk.NetworkMask, netMask.(string))
+		}
+		if desc, ok := d.GetOk("description"); ok {
+			extraTpl.Add("DESCRIPTION", desc.(string))
+		}
+
+		tagsInterface := d.Get("tags_all").(map[string]interface{})
+		for k, v := range tagsInterface {
+			extraTpl.AddPair(strings.ToUpper(k), v)
+		}
+
+		vnetID, err := controller.VNTemplate(templateID).Instantiate(d.Get("name").(string), extraTpl.String())
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to instantiate the virtual network template",
+				Detail:   fmt.Sprintf("virtual network template (ID: %d): %s", templateID, err),
+			})
+			return diags
+		}
+		vnc = controller.VirtualNetwork(vnetID)
+
+		d.SetId(fmt.Sprintf("%v", vnetID))
+
+		// virtual network states were introduce with OpenNebula 6.4 release
+		requiredVersion, _ := version.NewVersion("6.4.0")
+
+		if config.OneVersion.GreaterThanOrEqual(requiredVersion) {
+			timeout := d.Timeout(schema.TimeoutCreate)
+			_, err = waitForVNetworkState(ctx, vnc, timeout, "READY")
+			if err != nil {
+				diags = append(diags, diag.Diagnostic{
+					Severity: diag.Error,
+					Summary:  "Failed to wait virtual network to be in READY state",
+					Detail:   fmt.Sprintf("virtual network (ID: %s): %s", d.Id(), err),
+				})
+				return diags
+			}
+		}
+
+		log.Printf("[DEBUG] New VNET ID: %d instantiated from template ID: %d", vnetID, templateID)
+
 	} else { //New VNET
 		vnDef, err := generateVn(d)
 		if err != nil {
@@ -478,7 +553,7 @@ func resourceOpennebulaVirtualNetworkCreate(ctx context.Context, d *schema.Resou
 		}
 
 		// Get Clusters list
-		clusters := getVnetClustersValue(d)
+		clusters := getClustersValue(d)
 
 		// Create VNet
 		vnetID, err := controller.VirtualNetworks().Create(vnDef, clusters[0])
@@ -731,11 +806,25 @@ func generateVnTemplate(d *schema.ResourceData) (string, error) {
 
 	tpl := vn.NewTemplate()
 
+	err := addVnTemplateAttributes(d, tpl)
+	if err != nil {
+		return "", err
+	}
+
+	tplStr := tpl.String()
+	log.Printf("[INFO] VNET template: %s", tplStr)
+
+	return tplStr, nil
+}
+
+// addVnTemplateAttributes adds the contextualization attributes, the description and the tags
+func addVnTemplateAttributes(d *schema.ResourceData, tpl *vn.Template) error {
+
 	mtu := d.Get("mtu").(int)
 	guestmtu := d.Get("guest_mtu").(int)
 
 	if guestmtu > mtu {
-		return "", fmt.Errorf("Invalid: Guest MTU (%v) is greater than MTU (%v)", guestmtu, mtu)
+		return fmt.Errorf("Invalid: Guest MTU (%v) is greater than MTU (%v)", guestmtu, mtu)
 	}
 
 	tpl.AddPair("MTU", mtu)
@@ -754,18 +843,31 @@ func generateVnTemplate(d *schema.ResourceData) (string, error) {
 		tpl.Add("DESCRIPTION", desc.(string))
 	}
 
-	tagsInterface := d.Get("tags").(map[string]interface{})
+	tagsInterface := d.Get("tags_all").(map[string]interface{})
 	for k, v := range tagsInterface {
 		tpl.AddPair(strings.ToUpper(k), v)
 	}
 
+	return nil
+}
+
+func generateVn(d *schema.ResourceData) (string, error) {
+
+	tpl := vn.NewTemplate()
+
+	err := addVnDefinition(d, tpl)
+	if err != nil {
+		return "", err
+	}
+
 	tplStr := tpl.String()
-	log.Printf("[INFO] VNET template: %s", tplStr)
+	log.Printf("[INFO] VNET definition: %s", tplStr)
 
 	return tplStr, nil
 }
 
-func generateVn(d *schema.ResourceData) (string, error) {
+// addVnDefinition adds the name, the driver and the physical network attributes
+func addVnDefinition(d *schema.ResourceData, tpl *vn.Template) error {
 	vnname := d.Get("name").(string)
 	vnmad := d.Get("type").(string)
 
@@ -773,8 +875,6 @@ func generateVn(d *schema.ResourceData) (string, error) {
 		vnmad = "bridge"
 	}
 
-	tpl := vn.NewTemplate()
-
 	tpl.Add(vnk.Name, vnname)
 	tpl.Add(vnk.VNMad, vnmad)
 
@@ -784,7 +884,7 @@ func generateVn(d *schema.ResourceData) (string, error) {
 		} else if vlanid, ok := d.GetOk("vlan_id"); ok {
 			tpl.Add(vnk.VlanID, vlanid.(string))
 		} else {
-			return "", fmt.Errorf("You must specify a 'vlan_id' or set the flag 'automatic_vlan_id'")
+			return fmt.Errorf("You must specify a 'vlan_id' or set the flag 'automatic_vlan_id'")
 		}
 	}
 	if vnbridge, ok := d.GetOk("bridge"); ok {
@@ -794,24 +894,14 @@ func generateVn(d *schema.ResourceData) (string, error) {
 		tpl.Add(vnk.PhyDev, vnphydev.(string))
 	}
 
-	tplStr := tpl.String()
-	log.Printf("[INFO] VNET definition: %s", tplStr)
-
-	return tplStr, nil
+	return nil
 }
 
-func getVnetClustersValue(d *schema.ResourceData) []int {
-	var result = make([]int, 0)
-
-	if clusters, ok := d.GetOk("clusters"); ok {
-		clusterList := clusters.([]interface{})
-		for i := 0; i < len(clusterList); i++ {
-			result = append(result, clusterList[i].(int))
-		}
-	} else {
-		result = append(result, -1)
-	}
-	return result
+// suppressInstantiatedVnetDiff ignores the attributes inherited from the
+// virtual network template when the VNET is instantiated from it
+func suppressInstantiatedVnetDiff(k, old, new string, d *schema.ResourceData) bool {
+	_, ok := d.GetOkExists("template_id")
+	return ok
 }
 
 func setVnetClusters(clusters []int, meta interface{}, id int) error {
@@ -839,13 +929,15 @@ func setVnetClusters(clusters []int, meta interface{}, id int) error {
 
 func matchARs(ARConfig map[string]interface{}, AR vn.AR) bool {
 
+	mac, _ := ARConfig["mac"].(string)
+
 	return AR.Type == ARConfig["ar_type"].(string) &&
 		AR.Size == ARConfig["size"].(int) &&
-		emptyOrEqual(ARConfig["ip4"], AR.IP) &&
-		emptyOrEqual(ARConfig["ip6"], AR.IP6) &&
-		emptyOrEqual(ARConfig["mac"], AR.MAC) &&
-		emptyOrEqual(ARConfig["global_prefix"], AR.GlobalPrefix) &&
-		emptyOrEqual(ARConfig["ula_prefix"], AR.ULAPrefix)
+		emptyOrEqualIP(ARConfig["ip4"], AR.IP) &&
+		emptyOrEqualIP(ARConfig["ip6"], AR.IP6) &&
+		(len(mac) == 0 || macEqual(mac, AR.MAC)) &&
+		emptyOrEqualIP(ARConfig["global_prefix"], AR.GlobalPrefix) &&
+		emptyOrEqualIP(ARConfig["ula_prefix"], AR.ULAPrefix)
 }
 
 func resourceOpennebulaVirtualNetworkRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
@@ -938,6 +1030,16 @@ func resourceOpennebulaVirtualNetworkRead(ctx context.Context, d *schema.Resourc
 		d.Set("lock", LockLevelToString(vn.Lock.Locked))
 	}
 
+	err = flattenTagsAll(d, meta, &vn.Template.Template)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to set attribute",
+			Detail:   fmt.Sprintf("virtual network (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
 	return nil
 }
 
@@ -1061,26 +1163,32 @@ func flattenAR(config map[string]interface{}, AR vn.AR) map[string]interface{} {
 	ARMap := map[string]interface{}{
 		"id":                     AR.ID,
 		"ar_type":                AR.Type,
-		"ip4":                    AR.IP,
+		"ip4":                    configOrRead(config["ip4"], AR.IP, ipEqual),
 		"size":                   AR.Size,
 		"computed_ip6":           AR.IP6,
 		"computed_mac":           AR.MAC,
 		"computed_global_prefix": AR.GlobalPrefix,
 		"computed_ula_prefix":    AR.ULAPrefix,
+		"computed_ip6_global":    AR.IP6Global,
+		"computed_ip6_ula":       AR.IP6ULA,
 	}
 
 	// if attribute set by the user, set read value
 	if len(config["ip6"].(string)) > 0 {
-		ARMap["ip6"] = AR.IP6
+		ARMap["ip6"] = configOrRead(config["ip6"], AR.IP6, ipEqual)
 	}
 	if len(config["mac"].(string)) > 0 {
-		ARMap["mac"] = AR.MAC
+		ARMap["mac"] = configOrRead(config["mac"], AR.MAC, macEqual)
 	}
 	if len(config["global_prefix"].(string)) > 0 {
-		ARMap["global_prefix"] = AR.GlobalPrefix
+		ARMap["global_prefix"] = configOrRead(config["global_prefix"], AR.GlobalPrefix, ipEqual)
 	}
 	if len(config["ula_prefix"].(string)) > 0 {
-		ARMap["ula_prefix"] = AR.ULAPrefix
+		ARMap["ula_prefix"] = configOrRead(config["ula_prefix"], AR.ULAPrefix, ipEqual)
+	}
+	// the prefix length is not part of the address range informations
+	if len(config["prefix_length"].(string)) > 0 {
+		ARMap["prefix_length"] = config["prefix_length"]
 	}
 
 	return ARMap
@@ -1108,6 +1216,8 @@ func resourceOpennebulaVirtualNetworkUpdate(ctx context.Context, d *schema.Resou
 
 	var diags diag.Diagnostics
 
+	setTagsAll(d, meta)
+
 	//Get Virtual Network Controller
 	vnc, err := getVirtualNetworkController(d, meta)
 	if err != nil {
@@ -1163,15 +1273,6 @@ func resourceOpennebulaVirtualNetworkUpdate(ctx context.Context, d *schema.Resou
 		changes = true
 	}
 
-	if d.HasChange("tags") {
-		tagsInterface := d.Get("tags").(map[string]interface{})
-		for k, v := range tagsInterface {
-			tpl.Del(strings.ToUpper(k))
-			tpl.AddPair(strings.ToUpper(k), v)
-		}
-		changes = true
-	}
-
 	if changes {
 		err := vnc.Update(tpl.String(), 1)
 		if err != nil {
@@ -1184,6 +1285,47 @@ func resourceOpennebulaVirtualNetworkUpdate(ctx context.Context, d *schema.Resou
 		}
 	}
 
+	// removed tags can't be deleted by merging, the whole template is replaced
+	if d.HasChange("tags_all") {
+		vnInfos, err := vnc.Info(false)
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to retrieve informations",
+				Detail:   fmt.Sprintf("virtual network (ID: %s): %s", d.Id(), err),
+			})
+			return diags
+		}
+
+		vnTpl := vnInfos.Template
+		oldTags, newTags := tagsAllChange(d)
+
+		// delete tags
+		for k, _ := range oldTags {
+			_, ok := newTags[k]
+			if ok {
+				continue
+			}
+			vnTpl.Del(strings.ToUpper(k))
+		}
+
+		// add/update tags
+		for k, v := range newTags {
+			vnTpl.Del(strings.ToUpper(k))
+			vnTpl.AddPair(strings.ToUpper(k), v)
+		}
+
+		err = vnc.Update(vnTpl.String(), 0)
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to update tags",
+				Detail:   fmt.Sprintf("virtual network (ID: %s): %s", d.Id(), err),
+			})
+			return diags
+		}
+	}
+
 	if d.HasChange("name") {
 		err := vnc.Rename(d.Get("name").(string))
 		if err != nil {
@@ -1268,6 +1410,16 @@ func resourceOpennebulaVirtualNetworkUpdate(ctx context.Context, d *schema.Resou
 
 	if d.HasChange("ar") {
 
+		// avoid concurrent changes with opennebula_virtual_network_address_range resources
+		config := meta.(*Configuration)
+		arKey := &SubResourceKey{
+			Type:    "virtual_network",
+			ID:      vnc.ID,
+			SubType: "address_range",
+		}
+		config.mutex.Lock(arKey)
+		defer config.mutex.Unlock(arKey)
+
 		old, new := d.GetChange("ar")
 		existingARsCfg := old.(*schema.Set).List()
 		newARsCfg := new.(*schema.Set).List()
@@ -0,0 +1,542 @@
+package opennebula
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/OpenNebula/one/src/oca/go/src/goca"
+	dyn "github.com/OpenNebula/one/src/oca/go/src/goca/dynamic"
+	vn "github.com/OpenNebula/one/src/oca/go/src/goca/schemas/virtualnetwork"
+	vnk "github.com/OpenNebula/one/src/oca/go/src/goca/schemas/virtualnetwork/keys"
+)
+
+func resourceOpennebulaVirtualNetworkAddressRange() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceOpennebulaVirtualNetworkAddressRangeCreate,
+		ReadContext:   resourceOpennebulaVirtualNetworkAddressRangeRead,
+		UpdateContext: resourceOpennebulaVirtualNetworkAddressRangeUpdate,
+		DeleteContext: resourceOpennebulaVirtualNetworkAddressRangeDelete,
+		CustomizeDiff: resourceVirtualNetworkAddressRangeCustomizeDiff,
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceOpennebulaVirtualNetworkAddressRangeImport,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"virtual_network_id": {
+				Type:        schema.TypeInt,
+				Required:    true,
+				ForceNew:    true,
+				Description: "ID of the virtual network",
+			},
+			"ar_id": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "ID of the address range in the virtual network",
+			},
+			"ar_type": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      "IP4",
+				Description:  "Type of the Address Range: IP4, IP6, IP6_STATIC, IP4_6, IP4_6_STATIC, ETHER. Default is 'IP4'",
+				ValidateFunc: validateARType,
+			},
+			"ip4": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				Description:  "Start IPv4 of the range to be allocated (Required if IP4 or IP4_6).",
+				ValidateFunc: validateIPv4,
+			},
+			"size": {
+				Type:        schema.TypeInt,
+				Required:    true,
+				Description: "Size (in number) of the ip range",
+			},
+			"ip6": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				Description:  "Start IPv6 of the range to be allocated (Required if IP6_STATIC or IP4_6_STATIC)",
+				ValidateFunc: validateIPv6,
+			},
+			"mac": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				Description:  "Start MAC of the range to be allocated",
+				ValidateFunc: validateMAC,
+			},
+			"global_prefix": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Description:  "Global prefix for IP6 or IP4_6",
+				ValidateFunc: validateIPv6Prefix,
+			},
+			"ula_prefix": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Description:  "ULA prefix for IP6 or IP4_6",
+				ValidateFunc: validateIPv6Prefix,
+			},
+			"prefix_length": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				Description:  "Prefix lenght Only needed for IP6_STATIC or IP4_6_STATIC",
+				ValidateFunc: validatePrefixLength,
+			},
+			"gateway": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Gateway IP of the address range",
+			},
+			"dns": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "DNS IPs of the address range",
+			},
+			"computed_ip6": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Start IPv6 of the range",
+			},
+			"computed_mac": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Start MAC of the range",
+			},
+			"computed_global_prefix": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Global prefix for IP6 or IP4_6",
+			},
+			"computed_ula_prefix": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "ULA prefix for IP6 or IP4_6",
+			},
+			"computed_ip6_global": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "First global IPv6 of the range for IP6 or IP4_6",
+			},
+			"computed_ip6_ula": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "First ULA IPv6 of the range for IP6 or IP4_6",
+			},
+			"tags": tagsSchema(),
+		},
+	}
+}
+
+// resourceVirtualNetworkAddressRangeCustomizeDiff checks the address range attributes depending on its type
+func resourceVirtualNetworkAddressRangeCustomizeDiff(ctx context.Context, diff *schema.ResourceDiff, v interface{}) error {
+
+	keys := []string{"ar_type", "ip4", "ip6", "global_prefix", "ula_prefix", "prefix_length"}
+
+	ar := make(map[string]interface{}, len(keys))
+	for _, k := range keys {
+		// values are not known yet
+		if !diff.NewValueKnown(k) {
+			return nil
+		}
+		ar[k] = diff.Get(k)
+	}
+
+	return validateARConfig(ar)
+}
+
+// vnetARPool retrieves the address ranges with all their attributes,
+// custom attributes are not part of the goca address range structure
+type vnetARPool struct {
+	XMLName         xml.Name     `xml:"VNET"`
+	ParentNetworkID string       `xml:"PARENT_NETWORK_ID"`
+	ARs             []dyn.Vector `xml:"AR_POOL>AR"`
+}
+
+func vnetARPoolInfo(controller *goca.Controller, vnetID int) (*vnetARPool, error) {
+
+	response, err := controller.Client.Call("one.vn.info", vnetID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := &vnetARPool{}
+	err = xml.Unmarshal([]byte(response.Body()), pool)
+	if err != nil {
+		return nil, err
+	}
+
+	return pool, nil
+}
+
+// getAR returns the address range with the given ID, or nil if it doesn't exists
+func (p *vnetARPool) getAR(arID int) *dyn.Vector {
+	for i := range p.ARs {
+		id, err := p.ARs[i].GetInt(string(vnk.ARID))
+		if err == nil && id == arID {
+			return &p.ARs[i]
+		}
+	}
+	return nil
+}
+
+// parseAddressRangeID parses an ID in the format <virtual_network_id>:<ar_id>
+func parseAddressRangeID(id string) (int, int, error) {
+
+	parts := strings.Split(id, ":")
+	if len(parts) != 2 {
+		return -1, -1, fmt.Errorf("Invalid ID %q, expected format: <virtual_network_id>:<ar_id>", id)
+	}
+
+	vnetID, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return -1, -1, fmt.Errorf("Invalid virtual network ID %q: %s", parts[0], err)
+	}
+
+	arID, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return -1, -1, fmt.Errorf("Invalid address range ID %q: %s", parts[1], err)
+	}
+
+	return vnetID, arID, nil
+}
+
+func generateVnetAddressRange(d *schema.ResourceData) *vn.AddressRange {
+
+	ar := generateAR(map[string]interface{}{
+		"ar_type":       d.Get("ar_type"),
+		"ip4":           d.Get("ip4"),
+		"ip6":           d.Get("ip6"),
+		"mac":           d.Get("mac"),
+		"size":          d.Get("size"),
+		"global_prefix": d.Get("global_prefix"),
+		"ula_prefix":    d.Get("ula_prefix"),
+		"prefix_length": d.Get("prefix_length"),
+	})
+
+	if gateway, ok := d.GetOk("gateway"); ok {
+		ar.AddPair(string(vnk.Gateway), gateway.(string))
+	}
+	if dns, ok := d.GetOk("dns"); ok {
+		ar.AddPair(string(vnk.DNS), dns.(string))
+	}
+
+	tagsInterface := d.Get("tags").(map[string]interface{})
+	for k, v := range tagsInterface {
+		ar.AddPair(strings.ToUpper(k), v)
+	}
+
+	return ar
+}
+
+func resourceOpennebulaVirtualNetworkAddressRangeCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	config := meta.(*Configuration)
+	controller := config.Controller
+
+	vnetID := d.Get("virtual_network_id").(int)
+
+	// avoid concurrent changes of the address ranges of the virtual network
+	arKey := &SubResourceKey{
+		Type:    "virtual_network",
+		ID:      vnetID,
+		SubType: "address_range",
+	}
+	config.mutex.Lock(arKey)
+	defer config.mutex.Unlock(arKey)
+
+	pool, err := vnetARPoolInfo(controller, vnetID)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to retrieve informations",
+			Detail:   fmt.Sprintf("virtual network (ID: %d): %s", vnetID, err),
+		})
+		return diags
+	}
+
+	existingIDs := make(map[int]bool, len(pool.ARs))
+	for _, ar := range pool.ARs {
+		id, err := ar.GetInt(string(vnk.ARID))
+		if err == nil {
+			existingIDs[id] = true
+		}
+	}
+
+	arStr := generateVnetAddressRange(d).String()
+	log.Printf("[INFO] Address range definition: %s", arStr)
+
+	err = controller.VirtualNetwork(vnetID).AddAR(arStr)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to add an address range",
+			Detail:   fmt.Sprintf("virtual network (ID: %d): %s", vnetID, err),
+		})
+		return diags
+	}
+
+	// the address range ID is not returned, retrieve it from the new address ranges list
+	pool, err = vnetARPoolInfo(controller, vnetID)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to retrieve informations",
+			Detail:   fmt.Sprintf("virtual network (ID: %d): %s", vnetID, err),
+		})
+		return diags
+	}
+
+	arID := -1
+	for _, ar := range pool.ARs {
+		id, err := ar.GetInt(string(vnk.ARID))
+		if err != nil || existingIDs[id] {
+			continue
+		}
+		if id > arID {
+			arID = id
+		}
+	}
+
+	if arID < 0 {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to retrieve the address range ID",
+			Detail:   fmt.Sprintf("virtual network (ID: %d): new address range not found", vnetID),
+		})
+		return diags
+	}
+
+	d.SetId(fmt.Sprintf("%d:%d", vnetID, arID))
+
+	log.Printf("[INFO] Successfully created address range %s\n", d.Id())
+
+	return resourceOpennebulaVirtualNetworkAddressRangeRead(ctx, d, meta)
+}
+
+func resourceOpennebulaVirtualNetworkAddressRangeRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	config := meta.(*Configuration)
+	controller := config.Controller
+
+	vnetID, arID, err := parseAddressRangeID(d.Id())
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to parse address range ID",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+
+	pool, err := vnetARPoolInfo(controller, vnetID)
+	if err != nil {
+		if NoExists(err) {
+			log.Printf("[WARN] Removing address range %s from state because the virtual network no longer exists", d.Id())
+			d.SetId("")
+			return nil
+		}
+
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to retrieve informations",
+			Detail:   fmt.Sprintf("address range (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
+	ar := pool.getAR(arID)
+	if ar == nil {
+		log.Printf("[WARN] Removing address range %s from state because it no longer exists", d.Id())
+		d.SetId("")
+		return nil
+	}
+
+	arType, _ := ar.GetStr(string(vnk.Type))
+	size, _ := ar.GetInt(string(vnk.Size))
+	ip4, _ := ar.GetStr(string(vnk.IP))
+	ip6, _ := ar.GetStr("IP6")
+	ip6Global, _ := ar.GetStr("IP6_GLOBAL")
+	ip6ULA, _ := ar.GetStr("IP6_ULA")
+	mac, _ := ar.GetStr(string(vnk.Mac))
+	globalPrefix, _ := ar.GetStr(string(vnk.GlobalPrefix))
+	ulaPrefix, _ := ar.GetStr(string(vnk.UlaPrefix))
+	prefixLength, _ := ar.GetStr(string(vnk.PrefixLength))
+	gateway, _ := ar.GetStr(string(vnk.Gateway))
+	dns, _ := ar.GetStr(string(vnk.DNS))
+
+	d.Set("virtual_network_id", vnetID)
+	d.Set("ar_id", arID)
+	d.Set("ar_type", arType)
+	d.Set("size", size)
+	d.Set("ip4", configOrRead(d.Get("ip4"), ip4, ipEqual))
+	d.Set("prefix_length", prefixLength)
+	d.Set("gateway", gateway)
+	d.Set("dns", dns)
+	d.Set("computed_ip6", ip6)
+	d.Set("computed_mac", mac)
+	d.Set("computed_global_prefix", globalPrefix)
+	d.Set("computed_ula_prefix", ulaPrefix)
+	d.Set("computed_ip6_global", ip6Global)
+	d.Set("computed_ip6_ula", ip6ULA)
+
+	// if attribute set by the user, set read value
+	if len(d.Get("ip6").(string)) > 0 {
+		d.Set("ip6", configOrRead(d.Get("ip6"), ip6, ipEqual))
+	}
+	if len(d.Get("mac").(string)) > 0 {
+		d.Set("mac", configOrRead(d.Get("mac"), mac, macEqual))
+	}
+	if len(d.Get("global_prefix").(string)) > 0 {
+		d.Set("global_prefix", configOrRead(d.Get("global_prefix"), globalPrefix, ipEqual))
+	}
+	if len(d.Get("ula_prefix").(string)) > 0 {
+		d.Set("ula_prefix", configOrRead(d.Get("ula_prefix"), ulaPrefix, ipEqual))
+	}
+
+	// Get only tags from the address range
+	if tagsInterface, ok := d.GetOk("tags"); ok {
+		tags := make(map[string]interface{})
+		for k := range tagsInterface.(map[string]interface{}) {
+			tags[k], _ = ar.GetStr(strings.ToUpper(k))
+		}
+
+		err = d.Set("tags", tags)
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to set attribute",
+				Detail:   fmt.Sprintf("address range (ID: %s): %s", d.Id(), err),
+			})
+			return diags
+		}
+	}
+
+	return nil
+}
+
+func resourceOpennebulaVirtualNetworkAddressRangeUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	config := meta.(*Configuration)
+	controller := config.Controller
+
+	vnetID, arID, err := parseAddressRangeID(d.Id())
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to parse address range ID",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+
+	if d.HasChanges("size", "global_prefix", "ula_prefix", "gateway", "dns", "tags") {
+
+		arKey := &SubResourceKey{
+			Type:    "virtual_network",
+			ID:      vnetID,
+			SubType: "address_range",
+		}
+		config.mutex.Lock(arKey)
+		defer config.mutex.Unlock(arKey)
+
+		ar := generateVnetAddressRange(d)
+		ar.AddPair(string(vnk.ARID), arID)
+
+		err = controller.VirtualNetwork(vnetID).UpdateAR(ar.String())
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to update address range",
+				Detail:   fmt.Sprintf("address range (ID: %s): %s", d.Id(), err),
+			})
+			return diags
+		}
+
+		log.Printf("[INFO] Successfully updated address range %s\n", d.Id())
+	}
+
+	return resourceOpennebulaVirtualNetworkAddressRangeRead(ctx, d, meta)
+}
+
+func resourceOpennebulaVirtualNetworkAddressRangeDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	config := meta.(*Configuration)
+	controller := config.Controller
+
+	vnetID, arID, err := parseAddressRangeID(d.Id())
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to parse address range ID",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+
+	arKey := &SubResourceKey{
+		Type:    "virtual_network",
+		ID:      vnetID,
+		SubType: "address_range",
+	}
+	config.mutex.Lock(arKey)
+	defer config.mutex.Unlock(arKey)
+
+	pool, err := vnetARPoolInfo(controller, vnetID)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to retrieve informations",
+			Detail:   fmt.Sprintf("address range (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
+	vnc := controller.VirtualNetwork(vnetID)
+
+	// address ranges of a reservation are given back to the parent virtual network
+	if len(pool.ParentNetworkID) > 0 {
+		err = vnc.FreeAR(arID)
+	} else {
+		err = vnc.RmAR(arID)
+	}
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to remove address range",
+			Detail:   fmt.Sprintf("address range (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
+	log.Printf("[INFO] Successfully removed address range %s\n", d.Id())
+
+	return nil
+}
+
+// resourceOpennebulaVirtualNetworkAddressRangeImport parses an ID in the format <virtual_network_id>:<ar_id>
+func resourceOpennebulaVirtualNetworkAddressRangeImport(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+
+	vnetID, _, err := parseAddressRangeID(d.Id())
+	if err != nil {
+		return nil, err
+	}
+
+	d.Set("virtual_network_id", vnetID)
+
+	return []*schema.ResourceData{d}, nil
+}
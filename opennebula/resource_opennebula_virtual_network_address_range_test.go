@@ -0,0 +1,187 @@
+package opennebula
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestAccVirtualNetworkAddressRange(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVirtualNetworkAddressRangeDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccVirtualNetworkAddressRangeConfigBasic,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair("opennebula_virtual_network_address_range.ar1", "virtual_network_id", "opennebula_virtual_network.test", "id"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network_address_range.ar1", "ar_type", "IP4"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network_address_range.ar1", "size", "16"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network_address_range.ar1", "ip4", "172.16.100.110"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network_address_range.ar1", "gateway", "172.16.100.1"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network_address_range.ar1", "dns", "172.16.100.1"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network_address_range.ar1", "tags.%", "1"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network_address_range.ar1", "tags.team", "team1"),
+					resource.TestCheckResourceAttrSet("opennebula_virtual_network_address_range.ar1", "ar_id"),
+					resource.TestCheckResourceAttrSet("opennebula_virtual_network_address_range.ar1", "computed_mac"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network_address_range.ar2", "ar_type", "IP4"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network_address_range.ar2", "size", "8"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network_address_range.ar2", "ip4", "172.16.100.150"),
+				),
+			},
+			{
+				Config: testAccVirtualNetworkAddressRangeConfigUpdate,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("opennebula_virtual_network_address_range.ar1", "size", "20"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network_address_range.ar1", "gateway", "172.16.100.254"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network_address_range.ar1", "dns", "172.16.100.254"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network_address_range.ar1", "tags.%", "2"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network_address_range.ar1", "tags.team", "team2"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network_address_range.ar1", "tags.env", "dev"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network_address_range.ar2", "size", "8"),
+				),
+			},
+			{
+				ResourceName:            "opennebula_virtual_network_address_range.ar1",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"tags"},
+			},
+		},
+	})
+}
+
+func TestAccVirtualNetworkAddressRangeIPv6(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVirtualNetworkAddressRangeDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccVirtualNetworkAddressRangeConfigIPv6Invalid,
+				ExpectError: regexp.MustCompile("\"prefix_length\" is required for the address range type IP6_STATIC"),
+			},
+			{
+				Config: testAccVirtualNetworkAddressRangeConfigIPv6,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("opennebula_virtual_network_address_range.static", "ar_type", "IP6_STATIC"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network_address_range.static", "ip6", "2001:db8::10"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network_address_range.static", "prefix_length", "64"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network_address_range.static", "computed_ip6", "2001:db8::10"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network_address_range.dualstack", "ar_type", "IP4_6"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network_address_range.dualstack", "ip4", "172.16.100.180"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network_address_range.dualstack", "global_prefix", "2001:db8:1::"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network_address_range.dualstack", "ula_prefix", "fd00:1::"),
+					resource.TestCheckResourceAttrSet("opennebula_virtual_network_address_range.dualstack", "computed_ip6_global"),
+					resource.TestCheckResourceAttrSet("opennebula_virtual_network_address_range.dualstack", "computed_ip6_ula"),
+				),
+			},
+		},
+	})
+}
+
+func testAccCheckVirtualNetworkAddressRangeDestroy(s *terraform.State) error {
+	config := testAccProvider.Meta().(*Configuration)
+	controller := config.Controller
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "opennebula_virtual_network" {
+			continue
+		}
+
+		vnID, _ := strconv.ParseUint(rs.Primary.ID, 10, 0)
+		vnet, _ := controller.VirtualNetwork(int(vnID)).Info(false)
+		if vnet != nil {
+			return fmt.Errorf("Expected virtual network %s to have been destroyed", rs.Primary.ID)
+		}
+	}
+
+	return nil
+}
+
+var testAccVirtualNetworkAddressRangeVNet = `
+resource "opennebula_virtual_network" "test" {
+  name   = "test-virtual_network-ar"
+  type   = "dummy"
+  bridge = "onebr"
+  mtu    = 1500
+}
+`
+
+var testAccVirtualNetworkAddressRangeConfigBasic = testAccVirtualNetworkAddressRangeVNet + `
+resource "opennebula_virtual_network_address_range" "ar1" {
+  virtual_network_id = opennebula_virtual_network.test.id
+  ar_type            = "IP4"
+  size               = 16
+  ip4                = "172.16.100.110"
+  gateway            = "172.16.100.1"
+  dns                = "172.16.100.1"
+
+  tags = {
+    team = "team1"
+  }
+}
+
+resource "opennebula_virtual_network_address_range" "ar2" {
+  virtual_network_id = opennebula_virtual_network.test.id
+  ar_type            = "IP4"
+  size               = 8
+  ip4                = "172.16.100.150"
+}
+`
+
+var testAccVirtualNetworkAddressRangeConfigUpdate = testAccVirtualNetworkAddressRangeVNet + `
+resource "opennebula_virtual_network_address_range" "ar1" {
+  virtual_network_id = opennebula_virtual_network.test.id
+  ar_type            = "IP4"
+  size               = 20
+  ip4                = "172.16.100.110"
+  gateway            = "172.16.100.254"
+  dns                = "172.16.100.254"
+
+  tags = {
+    team = "team2"
+    env  = "dev"
+  }
+}
+
+resource "opennebula_virtual_network_address_range" "ar2" {
+  virtual_network_id = opennebula_virtual_network.test.id
+  ar_type            = "IP4"
+  size               = 8
+  ip4                = "172.16.100.150"
+}
+`
+
+var testAccVirtualNetworkAddressRangeConfigIPv6Invalid = testAccVirtualNetworkAddressRangeVNet + `
+resource "opennebula_virtual_network_address_range" "static" {
+  virtual_network_id = opennebula_virtual_network.test.id
+  ar_type            = "IP6_STATIC"
+  size               = 8
+  ip6                = "2001:db8::10"
+}
+`
+
+var testAccVirtualNetworkAddressRangeConfigIPv6 = testAccVirtualNetworkAddressRangeVNet + `
+resource "opennebula_virtual_network_address_range" "static" {
+  virtual_network_id = opennebula_virtual_network.test.id
+  ar_type            = "IP6_STATIC"
+  size               = 8
+  ip6                = "2001:db8::10"
+  prefix_length      = "64"
+}
+
+resource "opennebula_virtual_network_address_range" "dualstack" {
+  virtual_network_id = opennebula_virtual_network.test.id
+  ar_type            = "IP4_6"
+  size               = 8
+  ip4                = "172.16.100.180"
+  global_prefix      = "2001:db8:1::"
+  ula_prefix         = "fd00:1::"
+}
+`
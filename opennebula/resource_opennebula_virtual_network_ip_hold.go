@@ -0,0 +1,261 @@
+package opennebula
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	dyn "github.com/OpenNebula/one/src/oca/go/src/goca/dynamic"
+)
+
+func resourceOpennebulaVirtualNetworkIPHold() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceOpennebulaVirtualNetworkIPHoldCreate,
+		ReadContext:   resourceOpennebulaVirtualNetworkIPHoldRead,
+		DeleteContext: resourceOpennebulaVirtualNetworkIPHoldDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceOpennebulaVirtualNetworkIPHoldImport,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"virtual_network_id": {
+				Type:        schema.TypeInt,
+				Required:    true,
+				ForceNew:    true,
+				Description: "ID of the virtual network",
+			},
+			"ar_id": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Computed:    true,
+				ForceNew:    true,
+				Description: "ID of the address range containing the address",
+			},
+			"ip": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ForceNew:     true,
+				Description:  "IPv4 or IPv6 address to hold",
+				ExactlyOneOf: []string{"ip", "mac"},
+			},
+			"mac": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ForceNew:     true,
+				Description:  "MAC address to hold",
+				ExactlyOneOf: []string{"ip", "mac"},
+			},
+		},
+	}
+}
+
+// parseIPHoldID parses an ID in the format <virtual_network_id>:<address>,
+// the address being an IP or a MAC
+func parseIPHoldID(id string) (int, string, error) {
+
+	parts := strings.SplitN(id, ":", 2)
+	if len(parts) != 2 || len(parts[1]) == 0 {
+		return -1, "", fmt.Errorf("Invalid ID %q, expected format: <virtual_network_id>:<ip_or_mac>", id)
+	}
+
+	vnetID, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return -1, "", fmt.Errorf("Invalid virtual network ID %q: %s", parts[0], err)
+	}
+
+	return vnetID, parts[1], nil
+}
+
+// generateLeaseTemplate returns the lease description used to hold or release an address
+func generateLeaseTemplate(address string, arID int) string {
+
+	tpl := dyn.NewTemplate()
+	lease := tpl.AddVector("LEASES")
+
+	ip := net.ParseIP(address)
+	switch {
+	case ip == nil:
+		lease.AddPair("MAC", address)
+	case ip.To4() == nil:
+		lease.AddPair("IP6", address)
+	default:
+		lease.AddPair("IP", address)
+	}
+
+	if arID >= 0 {
+		lease.AddPair("AR_ID", arID)
+	}
+
+	return tpl.String()
+}
+
+// leaseMatchAddress returns true when the lease has the address, an IPv6 lease
+// may be matched by its link local, global or ULA address
+func leaseMatchAddress(lease vnetLease, address string) bool {
+
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return strings.EqualFold(lease.MAC, address)
+	}
+
+	for _, leaseIP := range []string{lease.IP, lease.IP6, lease.IP6Global, lease.IP6ULA} {
+		if len(leaseIP) > 0 && ip.Equal(net.ParseIP(leaseIP)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func resourceOpennebulaVirtualNetworkIPHoldCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	config := meta.(*Configuration)
+	controller := config.Controller
+
+	vnetID := d.Get("virtual_network_id").(int)
+
+	address := d.Get("ip").(string)
+	if len(address) == 0 {
+		address = d.Get("mac").(string)
+	}
+
+	arID := -1
+	if arIDIf, ok := d.GetOkExists("ar_id"); ok {
+		arID = arIDIf.(int)
+	}
+
+	err := controller.VirtualNetwork(vnetID).Hold(generateLeaseTemplate(address, arID))
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to hold a lease",
+			Detail:   fmt.Sprintf("virtual network (ID: %d): %s", vnetID, err),
+		})
+		return diags
+	}
+
+	d.SetId(fmt.Sprintf("%d:%s", vnetID, address))
+
+	log.Printf("[INFO] Successfully held address %s\n", d.Id())
+
+	return resourceOpennebulaVirtualNetworkIPHoldRead(ctx, d, meta)
+}
+
+func resourceOpennebulaVirtualNetworkIPHoldRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	config := meta.(*Configuration)
+	controller := config.Controller
+
+	vnetID, address, err := parseIPHoldID(d.Id())
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to parse lease ID",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+
+	vnetLeases, err := vnetLeasesInfo(controller, vnetID)
+	if err != nil {
+		if NoExists(err) {
+			log.Printf("[WARN] Removing lease %s from state because the virtual network no longer exists", d.Id())
+			d.SetId("")
+			return nil
+		}
+
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to retrieve informations",
+			Detail:   fmt.Sprintf("lease (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
+	for _, ar := range vnetLeases.ARs {
+		for _, lease := range ar.Leases {
+
+			if !leaseMatchAddress(lease, address) {
+				continue
+			}
+
+			// a held lease is owned by the VM -1
+			if lease.VM == nil || *lease.VM != -1 {
+				log.Printf("[WARN] Removing lease %s from state because it's not on hold anymore", d.Id())
+				d.SetId("")
+				return nil
+			}
+
+			d.Set("virtual_network_id", vnetID)
+			d.Set("ar_id", ar.ID)
+			// keep the held address, which may be an IPv6 one
+			ip := lease.IP
+			if net.ParseIP(address) != nil {
+				ip = address
+			}
+			d.Set("ip", ip)
+			d.Set("mac", lease.MAC)
+
+			return nil
+		}
+	}
+
+	log.Printf("[WARN] Removing lease %s from state because it no longer exists", d.Id())
+	d.SetId("")
+
+	return nil
+}
+
+func resourceOpennebulaVirtualNetworkIPHoldDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	config := meta.(*Configuration)
+	controller := config.Controller
+
+	vnetID, address, err := parseIPHoldID(d.Id())
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to parse lease ID",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+
+	err = controller.VirtualNetwork(vnetID).Release(generateLeaseTemplate(address, d.Get("ar_id").(int)))
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to release a lease on hold",
+			Detail:   fmt.Sprintf("lease (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
+	log.Printf("[INFO] Successfully released address %s\n", d.Id())
+
+	return nil
+}
+
+// resourceOpennebulaVirtualNetworkIPHoldImport parses an ID in the format <virtual_network_id>:<ip_or_mac>
+func resourceOpennebulaVirtualNetworkIPHoldImport(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+
+	vnetID, _, err := parseIPHoldID(d.Id())
+	if err != nil {
+		return nil, err
+	}
+
+	d.Set("virtual_network_id", vnetID)
+
+	return []*schema.ResourceData{d}, nil
+}
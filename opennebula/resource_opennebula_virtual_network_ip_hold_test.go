@@ -0,0 +1,161 @@
+package opennebula
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestAccVirtualNetworkIPHold(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVirtualNetworkIPHoldDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccVirtualNetworkIPHoldConfigBasic,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair("opennebula_virtual_network_ip_hold.ip", "virtual_network_id", "opennebula_virtual_network.test", "id"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network_ip_hold.ip", "ip", "172.16.100.112"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network_ip_hold.ip", "ar_id", "0"),
+					resource.TestCheckResourceAttrSet("opennebula_virtual_network_ip_hold.ip", "mac"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network_ip_hold.mac", "mac", "02:00:ac:10:64:71"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network_ip_hold.mac", "ip", "172.16.100.113"),
+				),
+			},
+			{
+				ResourceName:      "opennebula_virtual_network_ip_hold.ip",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			{
+				Config: testAccVirtualNetworkIPHoldConfigIPv6,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVirtualNetworkLeaseReleased("opennebula_virtual_network.test", "172.16.100.112"),
+					testAccCheckVirtualNetworkLeaseReleased("opennebula_virtual_network.test", "02:00:ac:10:64:71"),
+					resource.TestCheckResourceAttrPair("opennebula_virtual_network_ip_hold.ip6", "virtual_network_id", "opennebula_virtual_network.test6", "id"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network_ip_hold.ip6", "ip", "2001:db8::12"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network_ip_hold.ip6", "ar_id", "0"),
+					resource.TestCheckResourceAttrSet("opennebula_virtual_network_ip_hold.ip6", "mac"),
+				),
+			},
+			{
+				ResourceName:      "opennebula_virtual_network_ip_hold.ip6",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			{
+				Config: testAccVirtualNetworkIPHoldVNets,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckVirtualNetworkLeaseReleased("opennebula_virtual_network.test6", "2001:db8::12"),
+				),
+			},
+		},
+	})
+}
+
+// testAccCheckVirtualNetworkLeaseReleased checks that the address isn't on hold in the virtual network
+func testAccCheckVirtualNetworkLeaseReleased(vnetName, address string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[vnetName]
+		if !ok {
+			return fmt.Errorf("Virtual network %s not found in state", vnetName)
+		}
+
+		vnetID, _ := strconv.Atoi(rs.Primary.ID)
+		return testAccCheckLeaseReleased(vnetID, address)
+	}
+}
+
+func testAccCheckLeaseReleased(vnetID int, address string) error {
+	config := testAccProvider.Meta().(*Configuration)
+
+	vnetLeases, err := vnetLeasesInfo(config.Controller, vnetID)
+	if err != nil {
+		if NoExists(err) {
+			return nil
+		}
+		return err
+	}
+
+	for _, ar := range vnetLeases.ARs {
+		for _, lease := range ar.Leases {
+			if leaseMatchAddress(lease, address) {
+				return fmt.Errorf("Expected address %s of virtual network %d to have been released", address, vnetID)
+			}
+		}
+	}
+
+	return nil
+}
+
+func testAccCheckVirtualNetworkIPHoldDestroy(s *terraform.State) error {
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "opennebula_virtual_network_ip_hold" {
+			continue
+		}
+
+		vnetID, address, err := parseIPHoldID(rs.Primary.ID)
+		if err != nil {
+			return err
+		}
+
+		err = testAccCheckLeaseReleased(vnetID, address)
+		if err != nil {
+			return err
+		}
+	}
+
+	return testAccCheckVirtualNetworkAddressRangeDestroy(s)
+}
+
+var testAccVirtualNetworkIPHoldVNets = `
+resource "opennebula_virtual_network" "test" {
+  name   = "test-virtual_network-hold"
+  type   = "dummy"
+  bridge = "onebr"
+  mtu    = 1500
+  ar {
+    ar_type = "IP4"
+    size    = 16
+    ip4     = "172.16.100.110"
+  }
+}
+
+resource "opennebula_virtual_network" "test6" {
+  name   = "test-virtual_network-hold6"
+  type   = "dummy"
+  bridge = "onebr"
+  mtu    = 1500
+  ar {
+    ar_type       = "IP6_STATIC"
+    size          = 16
+    ip6           = "2001:db8::10"
+    prefix_length = "64"
+  }
+}
+`
+
+var testAccVirtualNetworkIPHoldConfigBasic = testAccVirtualNetworkIPHoldVNets + `
+resource "opennebula_virtual_network_ip_hold" "ip" {
+  virtual_network_id = opennebula_virtual_network.test.id
+  ip                 = "172.16.100.112"
+}
+
+resource "opennebula_virtual_network_ip_hold" "mac" {
+  virtual_network_id = opennebula_virtual_network.test.id
+  ar_id              = 0
+  mac                = "02:00:ac:10:64:71"
+}
+`
+
+var testAccVirtualNetworkIPHoldConfigIPv6 = testAccVirtualNetworkIPHoldVNets + `
+resource "opennebula_virtual_network_ip_hold" "ip6" {
+  virtual_network_id = opennebula_virtual_network.test6.id
+  ip                 = "2001:db8::12"
+}
+`
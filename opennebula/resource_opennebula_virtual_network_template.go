@@ -0,0 +1,712 @@
+package opennebula
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"github.com/OpenNebula/one/src/oca/go/src/goca"
+	"github.com/OpenNebula/one/src/oca/go/src/goca/parameters"
+	"github.com/OpenNebula/one/src/oca/go/src/goca/schemas/shared"
+	vn "github.com/OpenNebula/one/src/oca/go/src/goca/schemas/virtualnetwork"
+	vnk "github.com/OpenNebula/one/src/oca/go/src/goca/schemas/virtualnetwork/keys"
+)
+
+func resourceOpennebulaVirtualNetworkTemplate() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceOpennebulaVirtualNetworkTemplateCreate,
+		ReadContext:   resourceOpennebulaVirtualNetworkTemplateRead,
+		Exists:        resourceOpennebulaVirtualNetworkTemplateExists,
+		UpdateContext: resourceOpennebulaVirtualNetworkTemplateUpdate,
+		DeleteContext: resourceOpennebulaVirtualNetworkTemplateDelete,
+		CustomizeDiff: customdiff.All(vnetARsCustomizeDiff, tagsAllCustomizeDiff),
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Name of the virtual network template",
+			},
+			"description": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "Description of the virtual network template, in OpenNebula's XML or String format",
+			},
+			"permissions": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "Permissions for the virtual network template (in Unix format, owner-group-other, use-manage-admin)",
+				ValidateFunc: func(v interface{}, k string) (ws []string, errors []error) {
+					value := v.(string)
+
+					if len(value) != 3 {
+						errors = append(errors, fmt.Errorf("%q has specify 3 permission sets: owner-group-other", k))
+					}
+
+					all := true
+					for _, c := range strings.Split(value, "") {
+						if c < "0" || c > "7" {
+							all = false
+						}
+					}
+					if !all {
+						errors = append(errors, fmt.Errorf("Each character in %q should specify a Unix-like permission set with a number from 0 to 7", k))
+					}
+
+					return
+				},
+			},
+			"uid": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "ID of the user that will own the virtual network template",
+			},
+			"gid": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "ID of the group that will own the virtual network template",
+			},
+			"uname": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Name of the user that will own the virtual network template",
+			},
+			"gname": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Name of the group that will own the virtual network template",
+			},
+			"bridge": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "Name of the bridge interface to which the instantiated vnets should be associated",
+			},
+			"physical_device": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "Name of the physical device to which the instantiated vnets should be associated",
+			},
+			"type": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "bridge",
+				Description: "Type of the Virtual Network: dummy, bridge, fw, ebtables, 802.1Q, vxlan, ovswitch. Default is 'bridge'",
+				ValidateFunc: func(v interface{}, k string) (ws []string, errors []error) {
+					validtypes := []string{"dummy", "bridge", "fw", "ebtables", "802.1Q", "vxlan", "ovswitch"}
+					value := v.(string)
+
+					if inArray(value, validtypes) < 0 {
+						errors = append(errors, fmt.Errorf("Type %q must be one of: %s", k, strings.Join(validtypes, ",")))
+					}
+
+					return
+				},
+			},
+			"clusters": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Computed:    true,
+				Description: "List of cluster IDs hosting the instantiated vnets",
+				Elem: &schema.Schema{
+					Type: schema.TypeInt,
+				},
+			},
+			"vlan_id": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      true,
+				Description:   "VLAN ID. Only if 'Type' is : 802.1Q, vxlan or ovswich and if 'automatic_vlan_id' is not set",
+				ConflictsWith: []string{"automatic_vlan_id"},
+			},
+			"automatic_vlan_id": {
+				Type:          schema.TypeBool,
+				Optional:      true,
+				Computed:      true,
+				Description:   "If set, let OpenNebula to attribute VLAN ID",
+				ConflictsWith: []string{"vlan_id"},
+			},
+			"mtu": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Description: "MTU of the instantiated vnets (defaut: 1500)",
+				Default:     1500,
+			},
+			"guest_mtu": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Description: "MTU of the Guest interface. Must be lower or equal to 'mtu' (defaut: 1500)",
+				Default:     1500,
+			},
+			"gateway": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "Gateway IP if necessary",
+			},
+			"network_mask": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "Network Mask",
+			},
+			"dns": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "DNS IP if necessary",
+			},
+			"ar": {
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Description: "List of Address Ranges to be part of the instantiated vnets",
+				Elem: &schema.Resource{
+					Schema: ARFields(),
+				},
+			},
+			"security_groups": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Computed:    true,
+				Description: "List of Security Group IDs to be applied to the instantiated vnets",
+				Elem: &schema.Schema{
+					Type: schema.TypeInt,
+				},
+			},
+			"group": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Name of the Group that onws the virtual network template, If empty, it uses caller group",
+			},
+			"tags":     tagsSchema(),
+			"tags_all": tagsAllSchema(),
+			"lock":     lockSchema(),
+		},
+	}
+}
+
+func getVirtualNetworkTemplateController(d *schema.ResourceData, meta interface{}) (*goca.VNTemplateController, error) {
+	config := meta.(*Configuration)
+	controller := config.Controller
+	var vntc *goca.VNTemplateController
+
+	// Try to find the virtual network template by ID, if specified
+	if d.Id() != "" {
+		id, err := strconv.ParseUint(d.Id(), 10, 0)
+		if err != nil {
+			return nil, err
+		}
+		vntc = controller.VNTemplate(int(id))
+	}
+
+	// Otherwise, try to find the virtual network template by name as the de facto compound primary key
+	if d.Id() == "" {
+		id, err := controller.VNTemplates().ByName(d.Get("name").(string))
+		if err != nil {
+			return nil, err
+		}
+		vntc = controller.VNTemplate(id)
+	}
+
+	return vntc, nil
+}
+
+func changeVNTemplateGroup(d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Configuration)
+	controller := config.Controller
+	var gid int
+
+	vntc, err := getVirtualNetworkTemplateController(d, meta)
+	if err != nil {
+		return err
+	}
+
+	if d.Get("group") != "" {
+		group := d.Get("group").(string)
+		gid, err = controller.Groups().ByName(group)
+		if err != nil {
+			return fmt.Errorf("Can't find a group with name `%s`: %s", group, err)
+		}
+	} else {
+		gid = d.Get("gid").(int)
+	}
+
+	err = vntc.Chown(-1, gid)
+	if err != nil {
+		return fmt.Errorf("Can't find a group with ID `%d`: %s", gid, err)
+	}
+
+	return nil
+}
+
+// generateVNTemplate builds the whole virtual network template from the configuration
+func generateVNTemplate(d *schema.ResourceData) (string, error) {
+
+	tpl := vn.NewTemplate()
+
+	err := addVnDefinition(d, tpl)
+	if err != nil {
+		return "", err
+	}
+
+	err = addVnTemplateAttributes(d, tpl)
+	if err != nil {
+		return "", err
+	}
+
+	if clusters, ok := d.GetOk("clusters"); ok {
+		tpl.AddPair("CLUSTER_IDS", ArrayToString(clusters.([]interface{}), ","))
+	}
+
+	if securitygroups, ok := d.GetOk("security_groups"); ok {
+		tpl.Add(vnk.SecGroups, ArrayToString(securitygroups.([]interface{}), ","))
+	}
+
+	ars := d.Get("ar").(*schema.Set).List()
+	for _, arinterface := range ars {
+		armap := arinterface.(map[string]interface{})
+		tpl.Elements = append(tpl.Elements, generateAR(armap))
+	}
+
+	tplStr := tpl.String()
+	log.Printf("[INFO] VNET template definition: %s", tplStr)
+
+	return tplStr, nil
+}
+
+func resourceOpennebulaVirtualNetworkTemplateCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	config := meta.(*Configuration)
+	controller := config.Controller
+
+	var diags diag.Diagnostics
+
+	setTagsAll(d, meta)
+
+	tplStr, err := generateVNTemplate(d)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to generate description",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+
+	vntID, err := controller.VNTemplate(-1).Create(tplStr)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to create the virtual network template",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+
+	d.SetId(fmt.Sprintf("%v", vntID))
+
+	vntc := controller.VNTemplate(vntID)
+
+	// update permisions
+	if perms, ok := d.GetOk("permissions"); ok {
+		err = vntc.Chmod(permissionUnix(perms.(string)))
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to change permissions",
+				Detail:   fmt.Sprintf("virtual network template (ID: %s): %s", d.Id(), err),
+			})
+			return diags
+		}
+	}
+
+	if d.Get("group") != "" {
+		err = changeVNTemplateGroup(d, meta)
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to change group",
+				Detail:   fmt.Sprintf("virtual network template (ID: %s): %s", d.Id(), err),
+			})
+			return diags
+		}
+	}
+
+	if lock, ok := d.GetOk("lock"); ok && lock.(string) != "UNLOCK" {
+
+		var level shared.LockLevel
+		err = StringToLockLevel(lock.(string), &level)
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to convert lock level",
+				Detail:   fmt.Sprintf("virtual network template (ID: %s): %s", d.Id(), err),
+			})
+			return diags
+		}
+
+		err = vntc.Lock(level)
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to lock",
+				Detail:   fmt.Sprintf("virtual network template (ID: %s): %s", d.Id(), err),
+			})
+			return diags
+		}
+	}
+
+	return resourceOpennebulaVirtualNetworkTemplateRead(ctx, d, meta)
+}
+
+func resourceOpennebulaVirtualNetworkTemplateRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+
+	var diags diag.Diagnostics
+
+	vntc, err := getVirtualNetworkTemplateController(d, meta)
+	if err != nil {
+		if NoExists(err) {
+			log.Printf("[WARN] Removing virtual network template %s from state because it no longer exists in", d.Get("name"))
+			d.SetId("")
+			return nil
+		}
+
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to get the virtual network template controller",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+
+	vntemplate, err := vntc.Info(false)
+	if err != nil {
+		if NoExists(err) {
+			log.Printf("[WARN] Removing virtual network template %s from state because it no longer exists in", d.Get("name"))
+			d.SetId("")
+			return nil
+		}
+
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to retrieve informations",
+			Detail:   fmt.Sprintf("virtual network template (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
+	d.SetId(strconv.Itoa(vntemplate.ID))
+	d.Set("name", vntemplate.Name)
+	d.Set("uid", vntemplate.UID)
+	d.Set("gid", vntemplate.GID)
+	d.Set("uname", vntemplate.UName)
+	d.Set("gname", vntemplate.GName)
+	d.Set("permissions", permissionsUnixString(vntemplate.Permissions))
+
+	tpl := &vn.Template{Template: vntemplate.Template}
+
+	vnmad, _ := tpl.Get(vnk.VNMad)
+	d.Set("type", vnmad)
+	bridge, _ := tpl.Get(vnk.Bridge)
+	d.Set("bridge", bridge)
+	phydev, _ := tpl.Get(vnk.PhyDev)
+	d.Set("physical_device", phydev)
+	vlanID, _ := tpl.Get(vnk.VlanID)
+	if vlanID != "" {
+		d.Set("vlan_id", vlanID)
+	}
+	automaticVlanID, _ := tpl.Get(vnk.AutomaticVlanID)
+	if automaticVlanID == "YES" {
+		d.Set("automatic_vlan_id", true)
+	}
+	guestMTU, err := tpl.GetI(vnk.GuestMTU)
+	if err == nil {
+		d.Set("guest_mtu", guestMTU)
+	}
+	dns, _ := tpl.Get(vnk.DNS)
+	d.Set("dns", dns)
+	gateway, _ := tpl.Get(vnk.Gateway)
+	d.Set("gateway", gateway)
+	networkMask, _ := tpl.Get(vnk.NetworkMask)
+	d.Set("network_mask", networkMask)
+
+	clusterIDs, _ := tpl.GetStr("CLUSTER_IDS")
+	clusters := make([]int, 0)
+	for _, idStr := range strings.Split(clusterIDs, ",") {
+		if idStr == "" {
+			continue
+		}
+		id, err := strconv.Atoi(strings.TrimSpace(idStr))
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to parse cluster ID",
+				Detail:   fmt.Sprintf("virtual network template (ID: %s): %s", d.Id(), err),
+			})
+			return diags
+		}
+		clusters = append(clusters, id)
+	}
+	d.Set("clusters", clusters)
+
+	err = flattenVnetTemplate(d, tpl)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to flatten template",
+			Detail:   fmt.Sprintf("virtual network template (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
+	err = flattenVNTemplateARs(d, tpl)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to flatten address ranges",
+			Detail:   fmt.Sprintf("virtual network template (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
+	if vntemplate.LockInfos != nil {
+		d.Set("lock", LockLevelToString(vntemplate.LockInfos.Locked))
+	}
+
+	err = flattenTagsAll(d, meta, &tpl.Template)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to set attribute",
+			Detail:   fmt.Sprintf("virtual network template (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
+	return nil
+}
+
+func flattenVNTemplateARs(d *schema.ResourceData, tpl *vn.Template) error {
+
+	ARVecs := tpl.GetARs()
+	ARSet := make([]map[string]interface{}, 0, len(ARVecs))
+	ARConfigs := d.Get("ar").(*schema.Set).List()
+
+	for _, ARVec := range ARVecs {
+
+		size, _ := ARVec.GetI(vnk.Size)
+		AR := vn.AR{Size: size}
+		AR.Type, _ = ARVec.Get(vnk.Type)
+		AR.IP, _ = ARVec.Get(vnk.IP)
+		AR.IP6, _ = ARVec.Get(vnk.AddressRange("IP6"))
+		AR.MAC, _ = ARVec.Get(vnk.Mac)
+		AR.GlobalPrefix, _ = ARVec.Get(vnk.GlobalPrefix)
+		AR.ULAPrefix, _ = ARVec.Get(vnk.UlaPrefix)
+
+		match := false
+
+		// retrieve the associated AR config
+		for _, ARConfigIf := range ARConfigs {
+
+			ARConfig := ARConfigIf.(map[string]interface{})
+
+			if !matchARs(ARConfig, AR) {
+				continue
+			}
+
+			match = true
+			ARMap := flattenAR(ARConfig, AR)
+			ARSet = append(ARSet, ARMap)
+
+			break
+		}
+
+		if !match {
+			log.Printf("[WARN] Configuration for AR of type %s and size %d not found.", AR.Type, AR.Size)
+		}
+	}
+
+	return d.Set("ar", ARSet)
+}
+
+func resourceOpennebulaVirtualNetworkTemplateExists(d *schema.ResourceData, meta interface{}) (bool, error) {
+	config := meta.(*Configuration)
+	controller := config.Controller
+
+	vntID, err := strconv.ParseInt(d.Id(), 10, 0)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = controller.VNTemplate(int(vntID)).Info(false)
+	if NoExists(err) {
+		return false, err
+	}
+
+	return true, err
+}
+
+func resourceOpennebulaVirtualNetworkTemplateUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+
+	var diags diag.Diagnostics
+
+	setTagsAll(d, meta)
+
+	vntc, err := getVirtualNetworkTemplateController(d, meta)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to get the virtual network template controller",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+
+	lock, lockOk := d.GetOk("lock")
+	if d.HasChange("lock") && lockOk && lock.(string) == "UNLOCK" {
+
+		err = vntc.Unlock()
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to unlock",
+				Detail:   fmt.Sprintf("virtual network template (ID: %s): %s", d.Id(), err),
+			})
+			return diags
+		}
+	}
+
+	if d.HasChange("name") {
+		err = vntc.Rename(d.Get("name").(string))
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to rename",
+				Detail:   fmt.Sprintf("virtual network template (ID: %s): %s", d.Id(), err),
+			})
+			return diags
+		}
+		log.Printf("[INFO] Successfully updated name for virtual network template %s\n", d.Id())
+	}
+
+	if d.HasChanges("description", "bridge", "physical_device", "type", "clusters", "vlan_id",
+		"automatic_vlan_id", "mtu", "guest_mtu", "gateway", "network_mask", "dns", "ar",
+		"security_groups", "tags_all") {
+
+		// the template is generated from the whole configuration then replaced
+		tplStr, err := generateVNTemplate(d)
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to generate description",
+				Detail:   fmt.Sprintf("virtual network template (ID: %s): %s", d.Id(), err),
+			})
+			return diags
+		}
+
+		err = vntc.Update(tplStr, parameters.Replace)
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to update content",
+				Detail:   fmt.Sprintf("virtual network template (ID: %s): %s", d.Id(), err),
+			})
+			return diags
+		}
+		log.Printf("[INFO] Successfully updated virtual network template %s\n", d.Id())
+	}
+
+	if d.HasChange("permissions") {
+		if perms, ok := d.GetOk("permissions"); ok {
+			err = vntc.Chmod(permissionUnix(perms.(string)))
+			if err != nil {
+				diags = append(diags, diag.Diagnostic{
+					Severity: diag.Error,
+					Summary:  "Failed to change permissions",
+					Detail:   fmt.Sprintf("virtual network template (ID: %s): %s", d.Id(), err),
+				})
+				return diags
+			}
+		}
+		log.Printf("[INFO] Successfully updated permissions for virtual network template %s\n", d.Id())
+	}
+
+	if d.HasChange("group") {
+		err = changeVNTemplateGroup(d, meta)
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to change group",
+				Detail:   fmt.Sprintf("virtual network template (ID: %s): %s", d.Id(), err),
+			})
+			return diags
+		}
+		log.Printf("[INFO] Successfully updated group for virtual network template %s\n", d.Id())
+	}
+
+	if d.HasChange("lock") && lockOk && lock.(string) != "UNLOCK" {
+
+		var level shared.LockLevel
+
+		err = StringToLockLevel(lock.(string), &level)
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to convert lock level",
+				Detail:   fmt.Sprintf("virtual network template (ID: %s): %s", d.Id(), err),
+			})
+			return diags
+		}
+
+		err = vntc.Lock(level)
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Failed to lock",
+				Detail:   fmt.Sprintf("virtual network template (ID: %s): %s", d.Id(), err),
+			})
+			return diags
+		}
+	}
+
+	return resourceOpennebulaVirtualNetworkTemplateRead(ctx, d, meta)
+}
+
+func resourceOpennebulaVirtualNetworkTemplateDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+
+	var diags diag.Diagnostics
+
+	vntc, err := getVirtualNetworkTemplateController(d, meta)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to get the virtual network template controller",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+
+	err = vntc.Delete()
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to delete",
+			Detail:   fmt.Sprintf("virtual network template (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
+	log.Printf("[INFO] Successfully deleted virtual network template %s\n", d.Id())
+	return nil
+}
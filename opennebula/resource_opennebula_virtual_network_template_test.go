@@ -0,0 +1,165 @@
+package opennebula
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestAccVirtualNetworkTemplate(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckVirtualNetworkTemplateDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccVirtualNetworkTemplateConfigBasic,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("opennebula_virtual_network_template.test", "name", "test-vntemplate"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network_template.test", "type", "dummy"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network_template.test", "bridge", "onebr"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network_template.test", "mtu", "1500"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network_template.test", "dns", "172.16.100.1"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network_template.test", "gateway", "172.16.100.1"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network_template.test", "permissions", "642"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network_template.test", "clusters.#", "1"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network_template.test", "clusters.0", "0"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network_template.test", "security_groups.#", "1"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network_template.test", "security_groups.0", "0"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network_template.test", "ar.#", "1"),
+					resource.TestCheckTypeSetElemNestedAttrs("opennebula_virtual_network_template.test", "ar.*", map[string]string{
+						"ar_type": "IP4",
+						"size":    "16",
+						"ip4":     "172.16.100.110",
+					}),
+					resource.TestCheckResourceAttr("opennebula_virtual_network_template.test", "tags.%", "1"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network_template.test", "tags.env", "prod"),
+					resource.TestCheckResourceAttrSet("opennebula_virtual_network_template.test", "uid"),
+					resource.TestCheckResourceAttrSet("opennebula_virtual_network_template.test", "gid"),
+					resource.TestCheckResourceAttrSet("opennebula_virtual_network_template.test", "uname"),
+					resource.TestCheckResourceAttrSet("opennebula_virtual_network_template.test", "gname"),
+				),
+			},
+			{
+				Config: testAccVirtualNetworkTemplateConfigUpdate,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("opennebula_virtual_network_template.test", "name", "test-vntemplate-renamed"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network_template.test", "dns", "172.16.100.254"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network_template.test", "gateway", "172.16.100.254"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network_template.test", "permissions", "660"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network_template.test", "ar.#", "2"),
+					resource.TestCheckTypeSetElemNestedAttrs("opennebula_virtual_network_template.test", "ar.*", map[string]string{
+						"ar_type": "IP4",
+						"size":    "16",
+						"ip4":     "172.16.100.110",
+					}),
+					resource.TestCheckTypeSetElemNestedAttrs("opennebula_virtual_network_template.test", "ar.*", map[string]string{
+						"ar_type": "IP4",
+						"size":    "12",
+						"ip4":     "172.16.100.130",
+					}),
+					resource.TestCheckResourceAttr("opennebula_virtual_network_template.test", "tags.%", "2"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network_template.test", "tags.env", "dev"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network_template.test", "tags.customer", "test"),
+				),
+			},
+			{
+				Config: testAccVirtualNetworkTemplateConfigInstantiate,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("opennebula_virtual_network.instance", "name", "test-vntemplate-instance"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network.instance", "description", "instantiated from a template"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network.instance", "type", "dummy"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network.instance", "bridge", "onebr"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network.instance", "permissions", "660"),
+					resource.TestCheckResourceAttrPair("opennebula_virtual_network.instance", "template_id", "opennebula_virtual_network_template.test", "id"),
+				),
+			},
+		},
+	})
+}
+
+func testAccCheckVirtualNetworkTemplateDestroy(s *terraform.State) error {
+	config := testAccProvider.Meta().(*Configuration)
+	controller := config.Controller
+
+	for _, rs := range s.RootModule().Resources {
+		switch rs.Type {
+		case "opennebula_virtual_network_template":
+			vntID, _ := strconv.ParseUint(rs.Primary.ID, 10, 0)
+			vntemplate, _ := controller.VNTemplate(int(vntID)).Info(false)
+			if vntemplate != nil {
+				return fmt.Errorf("Expected virtual network template %s to have been destroyed", rs.Primary.ID)
+			}
+		case "opennebula_virtual_network":
+			vnID, _ := strconv.ParseUint(rs.Primary.ID, 10, 0)
+			vnet, _ := controller.VirtualNetwork(int(vnID)).Info(false)
+			if vnet != nil {
+				return fmt.Errorf("Expected virtual network %s to have been destroyed", rs.Primary.ID)
+			}
+		}
+	}
+
+	return nil
+}
+
+var testAccVirtualNetworkTemplateConfigBasic = `
+resource "opennebula_virtual_network_template" "test" {
+  name            = "test-vntemplate"
+  type            = "dummy"
+  bridge          = "onebr"
+  mtu             = 1500
+  gateway         = "172.16.100.1"
+  dns             = "172.16.100.1"
+  ar {
+    ar_type = "IP4"
+    size    = 16
+    ip4     = "172.16.100.110"
+  }
+  permissions     = "642"
+  security_groups = [0]
+  clusters        = [0]
+  tags = {
+    env = "prod"
+  }
+}
+`
+
+var testAccVirtualNetworkTemplateConfigUpdate = `
+resource "opennebula_virtual_network_template" "test" {
+  name            = "test-vntemplate-renamed"
+  type            = "dummy"
+  bridge          = "onebr"
+  mtu             = 1500
+  gateway         = "172.16.100.254"
+  dns             = "172.16.100.254"
+  ar {
+    ar_type = "IP4"
+    size    = 16
+    ip4     = "172.16.100.110"
+  }
+  ar {
+    ar_type = "IP4"
+    size    = 12
+    ip4     = "172.16.100.130"
+  }
+  permissions     = "660"
+  security_groups = [0]
+  clusters        = [0]
+  tags = {
+    env      = "dev"
+    customer = "test"
+  }
+}
+`
+
+var testAccVirtualNetworkTemplateConfigInstantiate = testAccVirtualNetworkTemplateConfigUpdate + `
+resource "opennebula_virtual_network" "instance" {
+  name        = "test-vntemplate-instance"
+  description = "instantiated from a template"
+  template_id = opennebula_virtual_network_template.test.id
+  permissions = "660"
+}
+`
@@ -123,6 +123,29 @@ func TestAccVirtualNetwork(t *testing.T) {
 					}),
 				),
 			},
+			{
+				Config: testAccVirtualNetworkConfigDefaultTags,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("opennebula_virtual_network.test", "tags.%", "3"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network.test", "tags_all.%", "5"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network.test", "tags_all.env", "dev"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network.test", "tags_all.owner", "ops"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network.test", "tags_all.team", "infra"),
+					testAccCheckVirtualNetworkTag("ENV", "dev"),
+					testAccCheckVirtualNetworkTag("TEAM", "infra"),
+				),
+			},
+			{
+				Config: testAccVirtualNetworkConfigDefaultTagsRemoved,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("opennebula_virtual_network.test", "tags.%", "3"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network.test", "tags_all.%", "4"),
+					resource.TestCheckResourceAttr("opennebula_virtual_network.test", "tags_all.owner", "ops"),
+					resource.TestCheckNoResourceAttr("opennebula_virtual_network.test", "tags_all.team"),
+					testAccCheckVirtualNetworkTag("OWNER", "ops"),
+					testAccCheckVirtualNetworkTag("TEAM", ""),
+				),
+			},
 			{
 				Config:             testAccVirtualNetworkReservationConfig,
 				ExpectNonEmptyPlan: true,
@@ -214,6 +237,39 @@ func testAccCheckVirtualNetworkPermissions(expected *shared.Permissions) resourc
 	}
 }
 
+// testAccCheckVirtualNetworkTag checks the value of a tag in the template of
+// the virtual network, an empty value meaning that the tag has been deleted
+func testAccCheckVirtualNetworkTag(key, value string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		config := testAccProvider.Meta().(*Configuration)
+		controller := config.Controller
+
+		for _, rs := range s.RootModule().Resources {
+			if rs.Type != "opennebula_virtual_network" {
+				continue
+			}
+			vnID, _ := strconv.ParseUint(rs.Primary.ID, 10, 0)
+			vn, err := controller.VirtualNetwork(int(vnID)).Info(false)
+			if err != nil {
+				return fmt.Errorf("Expected virtual network %s to exist when checking tags: %s", rs.Primary.ID, err)
+			}
+
+			tagValue, err := vn.Template.GetStr(key)
+			if value == "" {
+				if err == nil {
+					return fmt.Errorf("Expected tag %s of virtual network %s to be deleted, got %s", key, rs.Primary.ID, tagValue)
+				}
+				continue
+			}
+			if err != nil || tagValue != value {
+				return fmt.Errorf("Expected tag %s = %s for virtual network %s, got %s", key, value, rs.Primary.ID, tagValue)
+			}
+		}
+
+		return nil
+	}
+}
+
 func testAccVirtualNetworkSG(slice []int) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 		config := testAccProvider.Meta().(*Configuration)
@@ -330,6 +386,28 @@ resource "opennebula_virtual_network" "test" {
 }
 `
 
+var testAccVirtualNetworkConfigDefaultTags = `
+provider "opennebula" {
+  default_tags {
+    tags = {
+      env   = "default"
+      owner = "ops"
+      team  = "infra"
+    }
+  }
+}
+` + testAccVirtualNetworkConfigUpdate
+
+var testAccVirtualNetworkConfigDefaultTagsRemoved = `
+provider "opennebula" {
+  default_tags {
+    tags = {
+      owner = "ops"
+    }
+  }
+}
+` + testAccVirtualNetworkConfigUpdate
+
 var testAccVirtualNetworkReservationConfig = `
 resource "opennebula_virtual_network" "test" {
   name = "test-virtual_network-renamed"
@@ -28,6 +28,7 @@ func resourceOpennebulaVirtualRouter() *schema.Resource {
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
+		CustomizeDiff: tagsAllCustomizeDiff,
 
 		Schema: map[string]*schema.Schema{
 			"name": {
@@ -95,8 +96,9 @@ func resourceOpennebulaVirtualRouter() *schema.Resource {
 				Optional:    true,
 				Description: "A description of the entity",
 			},
-			"lock": lockSchema(),
-			"tags": tagsSchema(),
+			"lock":     lockSchema(),
+			"tags":     tagsSchema(),
+			"tags_all": tagsAllSchema(),
 		},
 	}
 }
@@ -155,6 +157,8 @@ func changeVirtualRouterGroup(d *schema.ResourceData, meta interface{}) error {
 func resourceOpennebulaVirtualRouterCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	var diags diag.Diagnostics
 
+	setTagsAll(d, meta)
+
 	config := meta.(*Configuration)
 	controller := config.Controller
 
@@ -326,6 +330,16 @@ func resourceOpennebulaVirtualRouterRead(ctx context.Context, d *schema.Resource
 		}
 	}
 
+	err = flattenTagsAll(d, meta, &vr.Template.Template)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Failed to set attribute",
+			Detail:   fmt.Sprintf("virtual router (ID: %s): %s", d.Id(), err),
+		})
+		return diags
+	}
+
 	return nil
 }
 
@@ -349,6 +363,8 @@ func resourceOpennebulaVirtualRouterExists(d *schema.ResourceData, meta interfac
 func resourceOpennebulaVirtualRouterUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	var diags diag.Diagnostics
 
+	setTagsAll(d, meta)
+
 	//Get virtual router
 	vrc, err := getVirtualRouterController(d, meta)
 	if err != nil {
@@ -442,11 +458,9 @@ func resourceOpennebulaVirtualRouterUpdate(ctx context.Context, d *schema.Resour
 		newTpl.Add("DESCRIPTION", d.Get("description").(string))
 	}
 
-	if d.HasChange("tags") {
+	if d.HasChange("tags_all") {
 
-		oldTagsIf, newTagsIf := d.GetChange("tags")
-		oldTags := oldTagsIf.(map[string]interface{})
-		newTags := newTagsIf.(map[string]interface{})
+		oldTags, newTags := tagsAllChange(d)
 
 		// delete tags
 		for k, _ := range oldTags {
@@ -549,7 +563,7 @@ func generateVirtualRouter(d *schema.ResourceData) string {
 		tpl.Add("DESCRIPTION", descr.(string))
 	}
 
-	tagsInterface := d.Get("tags").(map[string]interface{})
+	tagsInterface := d.Get("tags_all").(map[string]interface{})
 	for k, v := range tagsInterface {
 		tpl.AddPair(strings.ToUpper(k), v)
 	}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/hashicorp/go-uuid"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 
 	"github.com/OpenNebula/one/src/oca/go/src/goca/parameters"
@@ -27,7 +28,7 @@ func resourceOpennebulaVirtualRouterInstance() *schema.Resource {
 		Exists:        resourceOpennebulaVirtualRouterInstanceExists,
 		UpdateContext: resourceOpennebulaVirtualRouterInstanceUpdate,
 		DeleteContext: resourceOpennebulaVirtualRouterInstanceDelete,
-		CustomizeDiff: resourceVMCustomizeDiff,
+		CustomizeDiff: customdiff.All(resourceVMCustomizeDiff, tagsAllCustomizeDiff),
 		Timeouts: &schema.ResourceTimeout{
 			Create: schema.DefaultTimeout(defaultVMTimeout),
 			Update: schema.DefaultTimeout(defaultVMTimeout),
@@ -56,6 +57,8 @@ func resourceOpennebulaVirtualRouterInstanceCreate(ctx context.Context, d *schem
 	config := meta.(*Configuration)
 	controller := config.Controller
 
+	setTagsAll(d, meta)
+
 	vRouterID := d.Get("virtual_router_id").(int)
 
 	// avoid creation of multiple NICs and instances at the same time
@@ -22,7 +22,8 @@ func resourceOpennebulaVirtualRouterInstanceTemplate() *schema.Resource {
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
-		Schema: commonTemplateSchemas(),
+		CustomizeDiff: tagsAllCustomizeDiff,
+		Schema:        commonTemplateSchemas(),
 	}
 }
 
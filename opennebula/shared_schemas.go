@@ -60,6 +60,32 @@ func commonVMSchemas() map[string]*schema.Schema {
 					return
 				},
 			},
+			"host_id": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "ID of the host of the VM",
+			},
+			"host_name": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Name of the host of the VM",
+			},
+			"cluster_id": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "ID of the cluster of the host of the VM",
+			},
+			"system_datastore_id": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "ID of the system datastore of the VM",
+			},
+			"deploy_id": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "ID of the VM in the hypervisor",
+			},
+			"history":       historyVMSchema(),
 			"template_disk": templateDiskVMSchema(),
 			"disk":          diskVMSchema(),
 			"hard_shutdown": {
@@ -72,6 +98,58 @@ func commonVMSchemas() map[string]*schema.Schema {
 	)
 }
 
+func historyVMSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Computed:    true,
+		Description: "Placement history of the VM",
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"sequence": {
+					Type:        schema.TypeInt,
+					Computed:    true,
+					Description: "Sequence number of the history record",
+				},
+				"host_id": {
+					Type:        schema.TypeInt,
+					Computed:    true,
+					Description: "ID of the host",
+				},
+				"host_name": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "Name of the host",
+				},
+				"cluster_id": {
+					Type:        schema.TypeInt,
+					Computed:    true,
+					Description: "ID of the cluster of the host",
+				},
+				"system_datastore_id": {
+					Type:        schema.TypeInt,
+					Computed:    true,
+					Description: "ID of the system datastore",
+				},
+				"start_time": {
+					Type:        schema.TypeInt,
+					Computed:    true,
+					Description: "Start time of the record",
+				},
+				"end_time": {
+					Type:        schema.TypeInt,
+					Computed:    true,
+					Description: "End time of the record, 0 for the current record",
+				},
+				"reason": {
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "Reason of the end of the record: NONE, ERROR, USER",
+				},
+			},
+		},
+	}
+}
+
 func commonInstanceSchema() map[string]*schema.Schema {
 	return map[string]*schema.Schema{
 		"cpu":      cpuSchema(),
@@ -83,6 +161,7 @@ func commonInstanceSchema() map[string]*schema.Schema {
 		"os":       osSchema(),
 		"vmgroup":  vmGroupSchema(),
 		"tags":     tagsSchema(),
+		"tags_all": tagsAllSchema(),
 		"permissions": {
 			Type:        schema.TypeString,
 			Optional:    true,
@@ -137,6 +216,7 @@ func commonInstanceSchema() map[string]*schema.Schema {
 		"sched_requirements":    schedReqSchema(),
 		"sched_ds_requirements": schedDSReqSchema(),
 		"description":           descriptionSchema(),
+		"sched_action":          schedActionSchema(),
 	}
 }
 
@@ -395,6 +475,17 @@ func tagsSchema() *schema.Schema {
 	}
 }
 
+func tagsAllSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeMap,
+		Computed:    true,
+		Description: "Tags of the resource merged with the provider default tags",
+		Elem: &schema.Schema{
+			Type: schema.TypeString,
+		},
+	}
+}
+
 var locktypes = []string{"USE", "MANAGE", "ADMIN", "ALL", "UNLOCK"}
 
 func lockSchema() *schema.Schema {
@@ -430,6 +521,88 @@ func schedDSReqSchema() *schema.Schema {
 	}
 }
 
+var schedActionRepeatTypes = []string{"WEEKLY", "MONTHLY", "YEARLY", "HOURLY"}
+var schedActionEndTypes = []string{"NEVER", "TIMES", "DATE"}
+
+func schedActionSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Optional:    true,
+		Description: "Actions scheduled on the virtual machine",
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"id": {
+					Type:        schema.TypeInt,
+					Computed:    true,
+					Description: "ID of the scheduled action",
+				},
+				"action": {
+					Type:        schema.TypeString,
+					Required:    true,
+					Description: "Action to execute: terminate, poweroff, snapshot-create...",
+				},
+				"time": {
+					Type:        schema.TypeString,
+					Required:    true,
+					Description: "Date of the action as an epoch timestamp, or relative to the VM start time when prefixed by +",
+					ValidateFunc: func(v interface{}, k string) (ws []string, errors []error) {
+						value := strings.TrimPrefix(v.(string), "+")
+
+						if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+							errors = append(errors, fmt.Errorf("%q must be an epoch timestamp or a number of seconds prefixed by +", k))
+						}
+
+						return
+					},
+				},
+				"repeat": {
+					Type:        schema.TypeString,
+					Optional:    true,
+					Description: "Repeat the action: WEEKLY, MONTHLY, YEARLY, HOURLY",
+					ValidateFunc: func(v interface{}, k string) (ws []string, errors []error) {
+						value := v.(string)
+
+						if inArray(value, schedActionRepeatTypes) < 0 {
+							errors = append(errors, fmt.Errorf("Type %q must be one of: %s", k, strings.Join(schedActionRepeatTypes, ",")))
+						}
+
+						return
+					},
+				},
+				"days": {
+					Type:        schema.TypeString,
+					Optional:    true,
+					Description: "Comma separated list of days to repeat the action, meaning depends on repeat",
+				},
+				"end_type": {
+					Type:        schema.TypeString,
+					Optional:    true,
+					Description: "End of the repetition: NEVER, TIMES, DATE",
+					ValidateFunc: func(v interface{}, k string) (ws []string, errors []error) {
+						value := v.(string)
+
+						if inArray(value, schedActionEndTypes) < 0 {
+							errors = append(errors, fmt.Errorf("Type %q must be one of: %s", k, strings.Join(schedActionEndTypes, ",")))
+						}
+
+						return
+					},
+				},
+				"end_value": {
+					Type:        schema.TypeInt,
+					Optional:    true,
+					Description: "Number of repetitions or end date, depending on end_type",
+				},
+				"args": {
+					Type:        schema.TypeString,
+					Optional:    true,
+					Description: "Comma separated arguments of the action, i.e. the snapshot name",
+				},
+			},
+		},
+	}
+}
+
 func descriptionSchema() *schema.Schema {
 	return &schema.Schema{
 		Type:        schema.TypeString,
@@ -506,6 +679,42 @@ func makeNICVector(nicConfig map[string]interface{}) *shared.NIC {
 	return nic
 }
 
+func makeSchedActionVector(tpl *vm.Template, actionConfig map[string]interface{}) *vm.SchedAction {
+	action := tpl.AddSchedAction()
+
+	action.Add(vmk.Action, actionConfig["action"].(string))
+	action.Add(vmk.Time, actionConfig["time"].(string))
+
+	repeat := actionConfig["repeat"].(string)
+	if len(repeat) > 0 {
+		action.Add(vmk.Repeat, inArray(repeat, schedActionRepeatTypes))
+	}
+	days := actionConfig["days"].(string)
+	if len(days) > 0 {
+		action.Add(vmk.Days, days)
+	}
+	endType := actionConfig["end_type"].(string)
+	if len(endType) > 0 {
+		action.Add(vmk.EndType, inArray(endType, schedActionEndTypes))
+		if endType != "NEVER" {
+			action.Add(vmk.EndValue, actionConfig["end_value"].(int))
+		}
+	}
+	args := actionConfig["args"].(string)
+	if len(args) > 0 {
+		action.AddPair("ARGS", args)
+	}
+
+	return action
+}
+
+func addSchedActions(tpl *vm.Template, actions []interface{}) {
+
+	for i := 0; i < len(actions); i++ {
+		makeSchedActionVector(tpl, actions[i].(map[string]interface{}))
+	}
+}
+
 func addOS(tpl *vm.Template, os []interface{}) {
 
 	for i := 0; i < len(os); i++ {
@@ -605,7 +814,7 @@ func generateVMTemplate(d *schema.ResourceData, tpl *vm.Template) error {
 		tpl.VCPU(vmvcpu.(int))
 	}
 
-	tagsInterface := d.Get("tags").(map[string]interface{})
+	tagsInterface := d.Get("tags_all").(map[string]interface{})
 	for k, v := range tagsInterface {
 		tpl.AddPair(strings.ToUpper(k), v)
 	}
@@ -627,6 +836,9 @@ func generateVMTemplate(d *schema.ResourceData, tpl *vm.Template) error {
 		tpl.Add(vmk.Description, descr.(string))
 	}
 
+	//Generate SCHED_ACTION definition
+	addSchedActions(tpl, d.Get("sched_action").([]interface{}))
+
 	return nil
 }
 
@@ -685,6 +897,70 @@ func flattenDisk(disk shared.Disk) map[string]interface{} {
 	}
 }
 
+func flattenSchedAction(action *dynamic.Vector) map[string]interface{} {
+
+	id, _ := action.GetInt(string(vmk.ActionID))
+	actionName, _ := action.GetStr(string(vmk.Action))
+	actionTime, _ := action.GetStr(string(vmk.Time))
+	days, _ := action.GetStr(string(vmk.Days))
+	args, _ := action.GetStr("ARGS")
+
+	repeat := ""
+	repeatIdx, err := action.GetInt(string(vmk.Repeat))
+	if err == nil && repeatIdx >= 0 && repeatIdx < len(schedActionRepeatTypes) {
+		repeat = schedActionRepeatTypes[repeatIdx]
+	}
+
+	endType := ""
+	endValue := 0
+	endTypeIdx, err := action.GetInt(string(vmk.EndType))
+	if err == nil && endTypeIdx >= 0 && endTypeIdx < len(schedActionEndTypes) {
+		endType = schedActionEndTypes[endTypeIdx]
+		endValue, _ = action.GetInt(string(vmk.EndValue))
+	}
+
+	return map[string]interface{}{
+		"id":        id,
+		"action":    actionName,
+		"time":      actionTime,
+		"repeat":    repeat,
+		"days":      days,
+		"end_type":  endType,
+		"end_value": endValue,
+		"args":      args,
+	}
+}
+
+func flattenTemplateSchedActions(d *schema.ResourceData, vmTemplate *vm.Template) error {
+
+	// Set scheduled actions to resource only when managed by the configuration,
+	// a VM inherits the actions of the template it was instantiated from
+	actionsConfig := d.Get("sched_action").([]interface{})
+	if len(actionsConfig) == 0 {
+		return nil
+	}
+	actions := vmTemplate.GetVectors(vmk.SchedActionVec)
+
+	actionsList := make([]interface{}, 0, len(actions))
+	for i, action := range actions {
+		actionMap := flattenSchedAction(action)
+
+		// OpenNebula converts a time relative to the VM start to an absolute
+		// date, keep the relative value from the configuration in this case
+		if i < len(actionsConfig) {
+			actionConfig := actionsConfig[i].(map[string]interface{})
+			configTime := actionConfig["time"].(string)
+			if strings.HasPrefix(configTime, "+") && actionConfig["action"] == actionMap["action"] {
+				actionMap["time"] = configTime
+			}
+		}
+
+		actionsList = append(actionsList, actionMap)
+	}
+
+	return d.Set("sched_action", actionsList)
+}
+
 func flattenTemplateVMGroup(d *schema.ResourceData, vmTemplate *vm.Template) error {
 	var err error
 
@@ -780,6 +1056,11 @@ func flattenTemplate(d *schema.ResourceData, vmTemplate *vm.Template) error {
 		return err
 	}
 
+	err = flattenTemplateSchedActions(d, vmTemplate)
+	if err != nil {
+		return err
+	}
+
 	// Set OS to resource
 	if arch != "" {
 		osMap = append(osMap, map[string]interface{}{